@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/gin-gonic/gin"
@@ -8,12 +9,14 @@ import (
 	"github.com/kbsonlong/kaiops/initializers"
 	"github.com/kbsonlong/kaiops/middlewares"
 	"github.com/kbsonlong/kaiops/routes"
+	"github.com/kbsonlong/kaiops/utils"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 func init() {
 	initializers.LoadEnvVariables()
+	initializers.LoadEncryptionKeys()
 	initializers.ConnectDB()
 }
 
@@ -34,11 +37,32 @@ func main() {
 	// Swagger 文档路由
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Kubernetes 客户端提供者，同时管理各集群的资源缓存
+	clients := utils.NewClientProvider(utils.InformerIdleTimeoutFromEnv())
+	go clients.Informers.Run(context.Background())
+
+	// 定期检查集群健康状态
+	prober := utils.NewClusterProber(initializers.DB, clients, utils.ProbeIntervalFromEnv())
+	go prober.Run(context.Background())
+
+	// 定期对比工作负载记录与集群实际状态
+	reconciler := utils.NewWorkloadReconciler(initializers.DB, clients, utils.ReconcileIntervalFromEnv())
+	go reconciler.Run(context.Background())
+
 	// Cluster Routes
-	routes.SetupClusterRoutes(r, initializers.DB)
+	routes.SetupClusterRoutes(r, initializers.DB, clients)
 
 	// Workload Routes
-	routes.SetupWorkloadRoutes(r, initializers.DB)
+	routes.SetupWorkloadRoutes(r, initializers.DB, clients)
+
+	// Service Routes
+	routes.SetupServiceRoutes(r, initializers.DB, clients)
+
+	// ConfigMap and Secret Routes
+	routes.SetupConfigRoutes(r, initializers.DB, clients)
+
+	// Namespace Routes
+	routes.SetupNamespaceRoutes(r, initializers.DB, clients)
 
 	r.Run()
 }
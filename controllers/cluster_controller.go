@@ -1,32 +1,148 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/kbsonlong/kaiops/models"
 	"github.com/kbsonlong/kaiops/utils"
 )
 
+const (
+	// 集群校验的超时时间
+	clusterValidateTimeout = 15 * time.Second
+	// 等待集群资源缓存首次同步的超时时间
+	cacheSyncTimeout = 10 * time.Second
+)
+
+// errClusterValidation 集群校验未通过
+var errClusterValidation = errors.New("集群校验失败")
+
+// ImportClustersRequest 从 kubeconfig 导入集群的请求
+type ImportClustersRequest struct {
+	// 原始或 base64 编码的 kubeconfig
+	KubeConfig string `json:"kube_config" binding:"required"`
+	// 要导入的上下文，为空时只返回上下文列表
+	Contexts []string `json:"contexts"`
+	// 导入集群的类型
+	ClusterType string `json:"cluster_type" example:"kubernetes"`
+	// 导入集群的区域
+	ClusterRegion string `json:"cluster_region" example:"cn-east-1"`
+}
+
+// ImportContext kubeconfig 中可导入的上下文
+type ImportContext struct {
+	utils.KubeContextInfo
+	// API Server 地址是否已注册
+	Registered bool `json:"registered"`
+}
+
+// ImportResult 单个上下文的导入结果
+type ImportResult struct {
+	// 上下文名称
+	Context string `json:"context"`
+	// 导入状态: created, skipped, failed
+	Status string `json:"status" example:"created"`
+	// 跳过或失败的原因
+	Message string `json:"message,omitempty"`
+	// 创建的集群
+	Cluster *models.ClusterResponse `json:"cluster,omitempty"`
+	// 校验报告
+	Report *utils.ClusterValidationReport `json:"report,omitempty"`
+}
+
 type ClusterController struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Clients utils.ClientProvider
+}
+
+// kubeClientErrorStatus 根据客户端初始化错误的类型返回对应的 HTTP 状态码
+func kubeClientErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrKubeConfigDecode),
+		errors.Is(err, utils.ErrInvalidKubeConfig),
+		errors.Is(err, utils.ErrNoCurrentContext),
+		errors.Is(err, utils.ErrContextNotFound),
+		errors.Is(err, utils.ErrUnsupportedAuthType),
+		errors.Is(err, utils.ErrInvalidAuthConfig),
+		errors.Is(err, utils.ErrInvalidConnectionSettings):
+		return http.StatusBadRequest
+	case errors.Is(err, utils.ErrAPIServerUnreachable):
+		return http.StatusBadGateway
+	case errors.Is(err, utils.ErrCacheNotSynced):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// syncedCache 获取集群资源缓存并等待要读取的资源完成首次同步
+func syncedCache(ctx context.Context, clients utils.ClientProvider, cluster models.Cluster, resources ...string) (*utils.ClusterCache, error) {
+	return utils.SyncedCache(ctx, clients, cluster, cacheSyncTimeout, resources...)
+}
+
+// validateClusterConfig 校验集群的认证配置和连接参数
+func validateClusterConfig(cluster models.Cluster) error {
+	if err := utils.ValidateAuthConfig(cluster); err != nil {
+		return err
+	}
+	return utils.ValidateConnectionSettings(cluster)
+}
+
+// registerCluster 校验集群连通性，通过后在事务中保存集群并初始化客户端
+func (c *ClusterController) registerCluster(ctx context.Context, cluster *models.Cluster) (utils.ClusterValidationReport, error) {
+	// 保存前校验 kubeconfig 和集群连通性
+	validateCtx, cancel := context.WithTimeout(ctx, clusterValidateTimeout)
+	defer cancel()
+	report := utils.ValidateCluster(validateCtx, c.Clients, *cluster)
+	if !report.Valid {
+		return report, errClusterValidation
+	}
+
+	// 使用校验结果填充集群信息
+	now := time.Now()
+	cluster.ClusterStatus = true
+	cluster.ClusterVersion = report.ServerVersion
+	cluster.LastCheckedAt = &now
+	if cluster.ClusterApi == "" {
+		cluster.ClusterApi = report.ServerAddress
+	}
+
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cluster).Error; err != nil {
+			return err
+		}
+		// 初始化Kubernetes客户端，失败时回滚集群记录
+		if _, err := c.Clients.GetClient(*cluster); err != nil {
+			return fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
+		}
+		return nil
+	})
+	return report, err
 }
 
 // CreateCluster godoc
 // @Summary      创建新的集群
-// @Description  创建一个新的 Kubernetes 集群
+// @Description  校验集群连通性后创建一个新的 Kubernetes 集群，无法访问的集群不会被保存
 // @Tags         clusters
 // @Accept       json
 // @Produce      json
 // @Param        cluster body models.Cluster true "集群信息"
-// @Success      201 {object} models.Cluster "创建成功"
+// @Success      201 {object} models.ClusterResponse "创建成功"
 // @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "集群校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters [post]
 func (c *ClusterController) CreateCluster(ctx *gin.Context) {
@@ -35,19 +151,153 @@ func (c *ClusterController) CreateCluster(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateClusterConfig(cluster); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := c.registerCluster(ctx, &cluster)
+	if errors.Is(err, errClusterValidation) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, cluster.ToResponse())
+}
+
+// ValidateCluster godoc
+// @Summary      校验集群
+// @Description  校验 kubeconfig、API Server 连通性以及当前凭据的权限，不保存集群
+// @Tags         clusters
+// @Accept       json
+// @Produce      json
+// @Param        cluster body models.Cluster true "集群信息"
+// @Success      200 {object} utils.ClusterValidationReport "校验报告"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Router       /api/v1/clusters/validate [post]
+func (c *ClusterController) ValidateCluster(ctx *gin.Context) {
+	var cluster models.Cluster
+	if err := ctx.ShouldBindJSON(&cluster); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	validateCtx, cancel := context.WithTimeout(ctx, clusterValidateTimeout)
+	defer cancel()
+
+	ctx.JSON(http.StatusOK, utils.ValidateCluster(validateCtx, c.Clients, cluster))
+}
+
+// ImportClusters godoc
+// @Summary      从 kubeconfig 导入集群
+// @Description  未指定 contexts 时返回 kubeconfig 中的上下文列表；指定 contexts 时为每个上下文创建一个集群，API Server 地址已注册的上下文会被跳过
+// @Tags         clusters
+// @Accept       json
+// @Produce      json
+// @Param        request body ImportClustersRequest true "导入请求"
+// @Success      200 {object} map[string]interface{} "上下文列表"
+// @Success      201 {object} map[string]interface{} "导入结果"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/import [post]
+func (c *ClusterController) ImportClusters(ctx *gin.Context) {
+	var request ImportClustersRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := utils.LoadKubeConfig(request.KubeConfig)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := c.DB.Create(&cluster).Error; err != nil {
+	// 已注册集群的 API Server 地址
+	var servers []string
+	if err := c.DB.Model(&models.Cluster{}).Pluck("cluster_api", &servers).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	registered := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		registered[utils.NormalizeServerURL(server)] = true
+	}
 
-	// 初始化Kubernetes客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("初始化Kubernetes客户端失败: %v", err)})
+	contexts := utils.ListKubeContexts(config)
+	if len(request.Contexts) == 0 {
+		result := make([]ImportContext, 0, len(contexts))
+		for _, info := range contexts {
+			result = append(result, ImportContext{KubeContextInfo: info, Registered: registered[utils.NormalizeServerURL(info.Server)]})
+		}
+		ctx.JSON(http.StatusOK, gin.H{"contexts": result})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, cluster)
+	contextServers := make(map[string]string, len(contexts))
+	for _, info := range contexts {
+		contextServers[info.Name] = info.Server
+	}
+
+	results := make([]ImportResult, 0, len(request.Contexts))
+	for _, contextName := range request.Contexts {
+		result := ImportResult{Context: contextName}
+		server, exists := contextServers[contextName]
+		if !exists {
+			result.Status = "failed"
+			result.Message = fmt.Sprintf("%v: %s", utils.ErrContextNotFound, contextName)
+			results = append(results, result)
+			continue
+		}
+
+		// 同一 API Server 只注册一次，包括同一次导入中的多个上下文
+		normalized := utils.NormalizeServerURL(server)
+		if registered[normalized] {
+			result.Status = "skipped"
+			result.Message = fmt.Sprintf("API Server %s 已注册", server)
+			results = append(results, result)
+			continue
+		}
+
+		kubeconfig, err := utils.ExtractKubeContext(config, contextName)
+		if err != nil {
+			result.Status = "failed"
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		cluster := models.Cluster{
+			Name:          contextName,
+			ClusterType:   request.ClusterType,
+			ClusterRegion: request.ClusterRegion,
+			KubeConfig:    models.EncryptedString(kubeconfig),
+			KubeContext:   contextName,
+			ClusterApi:    server,
+		}
+		report, err := c.registerCluster(ctx, &cluster)
+		if err != nil {
+			result.Status = "failed"
+			result.Message = err.Error()
+			if errors.Is(err, errClusterValidation) {
+				result.Report = &report
+			}
+			results = append(results, result)
+			continue
+		}
+
+		registered[normalized] = true
+		response := cluster.ToResponse()
+		result.Status = "created"
+		result.Cluster = &response
+		results = append(results, result)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"results": results})
 }
 
 // GetCluster godoc
@@ -57,7 +307,7 @@ func (c *ClusterController) CreateCluster(ctx *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id path int true "集群ID"
-// @Success      200 {object} models.Cluster "获取成功"
+// @Success      200 {object} models.ClusterResponse "获取成功"
 // @Failure      404 {object} map[string]string "集群不存在"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id} [get]
@@ -74,23 +324,8 @@ func (c *ClusterController) GetCluster(ctx *gin.Context) {
 		return
 	}
 
-	// 检查并初始化Kubernetes客户端
-	clusterIDUint, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "无效的集群ID"})
-		return
-	}
-
-	_, err = utils.GetKubernetesClient(uint(clusterIDUint))
-	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("初始化Kubernetes客户端失败: %v", err)})
-			return
-		}
-	}
-
-	ctx.JSON(http.StatusOK, cluster)
+	// 集群连通性由后台健康检查维护，此处直接返回最近一次的检查结果
+	ctx.JSON(http.StatusOK, cluster.ToResponse())
 }
 
 // ListClusters godoc
@@ -134,8 +369,13 @@ func (c *ClusterController) ListClusters(ctx *gin.Context) {
 	var total int64
 	query.Count(&total)
 
+	responses := make([]models.ClusterResponse, 0, len(clusters))
+	for _, cluster := range clusters {
+		responses = append(responses, cluster.ToResponse())
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
-		"data": clusters,
+		"data": responses,
 		"meta": gin.H{
 			"total":     total,
 			"page":      page,
@@ -152,7 +392,7 @@ func (c *ClusterController) ListClusters(ctx *gin.Context) {
 // @Produce      json
 // @Param        id path int true "集群ID"
 // @Param        cluster body models.Cluster true "更新的集群信息"
-// @Success      200 {object} models.Cluster "更新成功"
+// @Success      200 {object} models.ClusterResponse "更新成功"
 // @Failure      400 {object} map[string]string "请求参数错误"
 // @Failure      404 {object} map[string]string "集群不存在"
 // @Failure      500 {object} map[string]string "服务器内部错误"
@@ -170,17 +410,43 @@ func (c *ClusterController) UpdateCluster(ctx *gin.Context) {
 		return
 	}
 
+	fingerprint := utils.ClusterFingerprint(cluster)
+	kubeConfig, bearerToken, clientKeyData := cluster.KubeConfig, cluster.BearerToken, cluster.ClientKeyData
+	proxyURL, redactedProxyURL := cluster.ProxyURL, cluster.ToResponse().ProxyURL
 	if err := ctx.ShouldBindJSON(&cluster); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// 响应中不返回敏感凭据，未提交新值时保留原值
+	if cluster.KubeConfig == "" {
+		cluster.KubeConfig = kubeConfig
+	}
+	if cluster.BearerToken == "" {
+		cluster.BearerToken = bearerToken
+	}
+	if cluster.ClientKeyData == "" {
+		cluster.ClientKeyData = clientKeyData
+	}
+	// 响应中的代理地址隐藏了密码，原样提交时保留原值
+	if string(cluster.ProxyURL) == redactedProxyURL {
+		cluster.ProxyURL = proxyURL
+	}
+	if err := validateClusterConfig(cluster); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := c.DB.Save(&cluster).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, cluster)
+	// 凭据变化时清理旧的客户端，下次访问时使用新凭据重新创建
+	if utils.ClusterFingerprint(cluster) != fingerprint {
+		c.Clients.RemoveClient(cluster.ID)
+	}
+
+	ctx.JSON(http.StatusOK, cluster.ToResponse())
 }
 
 // DeleteCluster godoc
@@ -214,12 +480,55 @@ func (c *ClusterController) DeleteCluster(ctx *gin.Context) {
 		return
 	}
 
+	// 清理集群的客户端连接
+	c.Clients.RemoveClient(cluster.ID)
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "集群删除成功"})
 }
 
+// DownloadKubeConfig godoc
+// @Summary      下载集群 kubeconfig
+// @Description  下载集群的 kubeconfig 文件，需要管理员令牌
+// @Tags         clusters
+// @Produce      application/yaml
+// @Security     AdminToken
+// @Param        id path int true "集群ID"
+// @Success      200 {string} string "kubeconfig 文件"
+// @Failure      401 {object} map[string]string "未授权"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/kubeconfig [get]
+func (c *ClusterController) DownloadKubeConfig(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var cluster models.Cluster
+
+	if err := c.DB.First(&cluster, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cluster.KubeConfig == "" {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "集群未配置 kubeconfig"})
+		return
+	}
+
+	kubeconfigBytes, err := utils.DecodeKubeConfig(string(cluster.KubeConfig))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", cluster.Name+".kubeconfig"))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/yaml", kubeconfigBytes)
+}
+
 // GetClusterNodes godoc
 // @Summary      获取集群节点状态
-// @Description  通过 kubeconfig 获取集群的 WorkNode 节点状态
+// @Description  从集群的 informer 缓存中获取 WorkNode 节点状态
 // @Tags         clusters
 // @Accept       json
 // @Produce      json
@@ -227,6 +536,7 @@ func (c *ClusterController) DeleteCluster(ctx *gin.Context) {
 // @Success      200 {object} map[string]interface{} "获取成功"
 // @Failure      404 {object} map[string]string "集群不存在"
 // @Failure      500 {object} map[string]string "服务器内部错误"
+// @Failure      503 {object} map[string]string "集群资源缓存同步中"
 // @Router       /api/v1/clusters/{id}/nodes [get]
 func (c *ClusterController) GetClusterNodes(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -242,34 +552,55 @@ func (c *ClusterController) GetClusterNodes(ctx *gin.Context) {
 		return
 	}
 
-	// 获取已初始化的客户端
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 从集群资源缓存中获取节点列表
+	clusterCache, err := syncedCache(ctx, c.Clients, cluster, utils.ResourceNodes)
 	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// 重新获取客户端
-		clientset, err = utils.GetKubernetesClient(cluster.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
-
-	// 获取节点列表
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := clusterCache.Nodes().List(labels.Everything())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取节点列表失败"})
 		return
 	}
 
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	nodeList := corev1.NodeList{Items: make([]corev1.Node, 0, len(nodes))}
+	for _, node := range nodes {
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
-		"nodes": nodes,
+		"nodes": nodeList,
 	})
 }
 
+// GetClusterCacheStatus godoc
+// @Summary      获取集群资源缓存状态
+// @Description  返回集群 informer 缓存是否已启动以及各资源的同步状态
+// @Tags         clusters
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Success      200 {object} utils.CacheStatus "缓存状态"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/cache [get]
+func (c *ClusterController) GetClusterCacheStatus(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var cluster models.Cluster
+	if err := c.DB.First(&cluster, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.Clients.CacheStatus(cluster.ID))
+}
+
 // UpdateNodeLabels godoc
 // @Summary      更新节点标签
 // @Description  更新指定集群中特定节点的标签
@@ -307,20 +638,11 @@ func (c *ClusterController) UpdateNodeLabels(ctx *gin.Context) {
 		return
 	}
 
-	// 获取已初始化的客户端
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取集群客户端
+	clientset, err := c.Clients.GetClient(cluster)
 	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// 重新获取客户端
-		clientset, err = utils.GetKubernetesClient(cluster.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	// 获取节点
@@ -370,20 +692,11 @@ func (c *ClusterController) DeleteNodeLabel(ctx *gin.Context) {
 		return
 	}
 
-	// 获取已初始化的客户端
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取集群客户端
+	clientset, err := c.Clients.GetClient(cluster)
 	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// 重新获取客户端
-		clientset, err = utils.GetKubernetesClient(cluster.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	// 获取节点
@@ -448,20 +761,11 @@ func (c *ClusterController) UpdateNodeTaints(ctx *gin.Context) {
 		return
 	}
 
-	// 获取已初始化的客户端
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取集群客户端
+	clientset, err := c.Clients.GetClient(cluster)
 	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// 重新获取客户端
-		clientset, err = utils.GetKubernetesClient(cluster.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	// 获取节点
@@ -517,20 +821,11 @@ func (c *ClusterController) DeleteNodeTaint(ctx *gin.Context) {
 		return
 	}
 
-	// 获取已初始化的客户端
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取集群客户端
+	clientset, err := c.Clients.GetClient(cluster)
 	if err != nil {
-		// 如果客户端未初始化，则进行初始化
-		if err := utils.InitKubernetesClient(cluster); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		// 重新获取客户端
-		clientset, err = utils.GetKubernetesClient(cluster.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
 	}
 
 	// 获取节点
@@ -557,3 +852,115 @@ func (c *ClusterController) DeleteNodeTaint(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "节点污点删除成功"})
 }
+
+// maxManifestSize 清单请求体的最大字节数
+const maxManifestSize = 4 << 20
+
+// ApplyManifestResponse 应用清单的响应
+type ApplyManifestResponse struct {
+	// 每个资源的应用结果，顺序与清单一致
+	Results []utils.ApplyResult `json:"results"`
+	// 成功应用的资源数
+	Applied int `json:"applied" example:"2"`
+	// 应用失败的资源数
+	Failed int `json:"failed" example:"0"`
+}
+
+// ApplyManifest godoc
+// @Summary      应用资源清单
+// @Description  使用 server-side apply 应用多文档 YAML 或 JSON 清单，支持任意资源类型，字段管理器为 kaiops。单个资源失败不影响其他资源，record=true 时将 Deployment、StatefulSet 和 DaemonSet 写入工作负载记录
+// @Tags         clusters
+// @Accept       plain
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace query string false "未指定命名空间的资源使用的命名空间" default(default)
+// @Param        force query bool false "与其他字段管理器冲突时强制接管字段"
+// @Param        record query bool false "将工作负载写入工作负载记录"
+// @Param        manifest body string true "YAML 或 JSON 清单"
+// @Success      200 {object} ApplyManifestResponse "应用结果"
+// @Failure      400 {object} map[string]string "清单格式错误"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      413 {object} map[string]string "清单过大"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/apply [post]
+func (c *ClusterController) ApplyManifest(ctx *gin.Context) {
+	id := ctx.Param("id")
+	opts := utils.ApplyOptions{Namespace: ctx.DefaultQuery("namespace", "default")}
+	force, err := parseBoolQuery(ctx, "force")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts.Force = force
+	record, err := parseBoolQuery(ctx, "record")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cluster models.Cluster
+	if err := c.DB.First(&cluster, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxManifestSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("清单不能超过 %d 字节", maxManifestSize)})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	objects, err := utils.DecodeManifest(data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, mapper, err := c.Clients.GetDynamicClient(cluster)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	response := ApplyManifestResponse{Results: utils.ApplyManifest(ctx, client, mapper, objects, opts)}
+	for i := range response.Results {
+		result := &response.Results[i]
+		if result.Action == utils.ApplyFailed {
+			response.Failed++
+			continue
+		}
+		response.Applied++
+		if !record {
+			continue
+		}
+		// 资源已写入集群，记录失败只在结果中说明，不影响其他资源
+		recorded, err := utils.RecordAppliedWorkload(ctx, c.DB, cluster.ID, result.Object)
+		if err != nil {
+			result.Error = "写入工作负载记录失败: " + err.Error()
+		}
+		result.Recorded = recorded
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// parseBoolQuery 解析布尔类型的查询参数，未指定时为 false
+func parseBoolQuery(ctx *gin.Context, key string) (bool, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("查询参数 %s 必须是布尔值", key)
+	}
+	return parsed, nil
+}
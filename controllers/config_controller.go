@@ -0,0 +1,658 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigController 管理集群中的 ConfigMap 和 Secret，对象只保存在集群中，修改和删除前的内容保存在数据库中
+type ConfigController struct {
+	DB      *gorm.DB
+	Clients utils.ClientProvider
+}
+
+// ConfigRequest 创建或更新 ConfigMap 或 Secret 的请求，更新时替换全部内容
+type ConfigRequest struct {
+	// 名称，只在创建时使用
+	Name string `json:"name" example:"nginx-config"`
+	// Secret 的类型，创建时默认为 Opaque，更新时为空则保持不变。ConfigMap 不能设置
+	Type string `json:"type,omitempty" example:"Opaque"`
+	// 文本内容。更新 Secret 时值为 ****** 的键保留原值
+	Data map[string]string `json:"data"`
+	// 二进制内容，JSON 中为 base64 编码
+	BinaryData map[string][]byte `json:"binary_data,omitempty"`
+	// 标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// 是否不可修改，设置后不能取消，内容也不能再修改
+	Immutable bool `json:"immutable,omitempty"`
+}
+
+// ListConfigMaps godoc
+// @Summary      获取 ConfigMap 列表
+// @Description  获取集群中的 ConfigMap，按命名空间和名称排序
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace query string false "命名空间，为空时返回所有命名空间"
+// @Success      200 {object} map[string][]models.ConfigObject "ConfigMap 列表"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps [get]
+func (c *ConfigController) ListConfigMaps(ctx *gin.Context) {
+	c.listConfigs(ctx, "ConfigMap", "configMaps")
+}
+
+// GetConfigMap godoc
+// @Summary      获取 ConfigMap 详情
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "ConfigMap 名称"
+// @Success      200 {object} models.ConfigObject "获取成功"
+// @Failure      404 {object} map[string]string "集群或 ConfigMap 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps/{namespace}/{name} [get]
+func (c *ConfigController) GetConfigMap(ctx *gin.Context) {
+	c.getConfig(ctx, "ConfigMap", true)
+}
+
+// CreateConfigMap godoc
+// @Summary      创建 ConfigMap
+// @Tags         configs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        configMap body ConfigRequest true "ConfigMap 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} models.ConfigObject "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      409 {object} map[string]string "ConfigMap 已存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps/{namespace} [post]
+func (c *ConfigController) CreateConfigMap(ctx *gin.Context) {
+	c.createConfig(ctx, "ConfigMap")
+}
+
+// UpdateConfigMap godoc
+// @Summary      更新 ConfigMap
+// @Description  使用请求替换 ConfigMap 的内容、标签和注解，修改前的内容保存为历史版本
+// @Tags         configs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "ConfigMap 名称"
+// @Param        configMap body ConfigRequest true "ConfigMap 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} models.ConfigObject "更新成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群或 ConfigMap 不存在"
+// @Failure      409 {object} map[string]string "ConfigMap 已被修改"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps/{namespace}/{name} [put]
+func (c *ConfigController) UpdateConfigMap(ctx *gin.Context) {
+	c.updateConfig(ctx, "ConfigMap")
+}
+
+// DeleteConfigMap godoc
+// @Summary      删除 ConfigMap
+// @Description  删除前的内容保存为历史版本
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "ConfigMap 名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "删除成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      404 {object} map[string]string "集群或 ConfigMap 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps/{namespace}/{name} [delete]
+func (c *ConfigController) DeleteConfigMap(ctx *gin.Context) {
+	c.deleteConfig(ctx, "ConfigMap")
+}
+
+// GetConfigMapRevisions godoc
+// @Summary      获取 ConfigMap 历史版本
+// @Description  返回每次修改或删除前的内容，按版本号从新到旧排序。ConfigMap 删除后仍可查询
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "ConfigMap 名称"
+// @Success      200 {object} map[string][]utils.ConfigRevisionRecord "历史版本"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/configmaps/{namespace}/{name}/revisions [get]
+func (c *ConfigController) GetConfigMapRevisions(ctx *gin.Context) {
+	c.listRevisions(ctx, "ConfigMap", true)
+}
+
+// ListSecrets godoc
+// @Summary      获取 Secret 列表
+// @Description  获取集群中的 Secret，按命名空间和名称排序，值已隐藏
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace query string false "命名空间，为空时返回所有命名空间"
+// @Success      200 {object} map[string][]models.ConfigObject "Secret 列表"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets [get]
+func (c *ConfigController) ListSecrets(ctx *gin.Context) {
+	c.listConfigs(ctx, "Secret", "secrets")
+}
+
+// GetSecret godoc
+// @Summary      获取 Secret 详情
+// @Description  只返回键名，值已隐藏。查看明文使用 reveal 接口
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Success      200 {object} models.ConfigObject "获取成功"
+// @Failure      404 {object} map[string]string "集群或 Secret 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name} [get]
+func (c *ConfigController) GetSecret(ctx *gin.Context) {
+	c.getConfig(ctx, "Secret", false)
+}
+
+// RevealSecret godoc
+// @Summary      查看 Secret 明文
+// @Description  返回 Secret 的明文内容，需要管理员令牌
+// @Tags         configs
+// @Produce      json
+// @Security     AdminToken
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Success      200 {object} models.ConfigObject "获取成功"
+// @Failure      401 {object} map[string]string "未授权"
+// @Failure      404 {object} map[string]string "集群或 Secret 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name}/reveal [get]
+func (c *ConfigController) RevealSecret(ctx *gin.Context) {
+	c.getConfig(ctx, "Secret", true)
+}
+
+// CreateSecret godoc
+// @Summary      创建 Secret
+// @Description  响应中的值已隐藏
+// @Tags         configs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        secret body ConfigRequest true "Secret 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异，值已隐藏"
+// @Success      201 {object} models.ConfigObject "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      409 {object} map[string]string "Secret 已存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace} [post]
+func (c *ConfigController) CreateSecret(ctx *gin.Context) {
+	c.createConfig(ctx, "Secret")
+}
+
+// UpdateSecret godoc
+// @Summary      更新 Secret
+// @Description  使用请求替换 Secret 的内容、标签和注解，值为 ****** 的键保留原值，修改前的内容加密保存为历史版本
+// @Tags         configs
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Param        secret body ConfigRequest true "Secret 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异，值已隐藏"
+// @Success      200 {object} models.ConfigObject "更新成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群或 Secret 不存在"
+// @Failure      409 {object} map[string]string "Secret 已被修改"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name} [put]
+func (c *ConfigController) UpdateSecret(ctx *gin.Context) {
+	c.updateConfig(ctx, "Secret")
+}
+
+// DeleteSecret godoc
+// @Summary      删除 Secret
+// @Description  删除前的内容加密保存为历史版本
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异，值已隐藏"
+// @Success      200 {object} map[string]string "删除成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      404 {object} map[string]string "集群或 Secret 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name} [delete]
+func (c *ConfigController) DeleteSecret(ctx *gin.Context) {
+	c.deleteConfig(ctx, "Secret")
+}
+
+// GetSecretRevisions godoc
+// @Summary      获取 Secret 历史版本
+// @Description  返回每次修改或删除前的内容，按版本号从新到旧排序，值已隐藏
+// @Tags         configs
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Success      200 {object} map[string][]utils.ConfigRevisionRecord "历史版本"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name}/revisions [get]
+func (c *ConfigController) GetSecretRevisions(ctx *gin.Context) {
+	c.listRevisions(ctx, "Secret", false)
+}
+
+// RevealSecretRevisions godoc
+// @Summary      查看 Secret 历史版本明文
+// @Description  返回每次修改或删除前的明文内容，需要管理员令牌
+// @Tags         configs
+// @Produce      json
+// @Security     AdminToken
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Secret 名称"
+// @Success      200 {object} map[string][]utils.ConfigRevisionRecord "历史版本"
+// @Failure      401 {object} map[string]string "未授权"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/secrets/{namespace}/{name}/revisions/reveal [get]
+func (c *ConfigController) RevealSecretRevisions(ctx *gin.Context) {
+	c.listRevisions(ctx, "Secret", true)
+}
+
+// listConfigs 返回 ConfigMap 或 Secret 列表，Secret 的值已隐藏
+func (c *ConfigController) listConfigs(ctx *gin.Context, kind, key string) {
+	clientset, ok := clusterClient(ctx, c.DB, c.Clients)
+	if !ok {
+		return
+	}
+
+	namespace := ctx.Query("namespace")
+	objects := make([]models.ConfigObject, 0)
+	switch kind {
+	case "ConfigMap":
+		list, err := clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+		for i := range list.Items {
+			objects = append(objects, utils.ConfigMapObject(&list.Items[i]))
+		}
+	case "Secret":
+		list, err := clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+		for i := range list.Items {
+			objects = append(objects, utils.MaskConfigObject(utils.SecretObject(&list.Items[i])))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		a, b := objects[i], objects[j]
+		return a.Namespace < b.Namespace || (a.Namespace == b.Namespace && a.Name < b.Name)
+	})
+	ctx.JSON(http.StatusOK, gin.H{key: objects})
+}
+
+// getConfig 返回 ConfigMap 或 Secret，reveal 为 false 时隐藏 Secret 的值
+func (c *ConfigController) getConfig(ctx *gin.Context, kind string, reveal bool) {
+	clientset, ok := clusterClient(ctx, c.DB, c.Clients)
+	if !ok {
+		return
+	}
+	_, object, err := getConfigObject(ctx, clientset, kind, ctx.Param("namespace"), ctx.Param("name"))
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if !reveal {
+		object = utils.MaskConfigObject(object)
+	}
+	ctx.JSON(http.StatusOK, object)
+}
+
+// createConfig 创建 ConfigMap 或 Secret
+func (c *ConfigController) createConfig(ctx *gin.Context, kind string) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req ConfigRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name 无效 %q: %s", req.Name, strings.Join(errs, "; "))})
+		return
+	}
+	if err := validateConfigRequest(kind, req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, c.DB, c.Clients)
+	if !ok {
+		return
+	}
+	namespace := ctx.Param("namespace")
+	obj, err := buildConfigObject(kind, namespace, req.Name, req, nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := writeConfigObject(ctx, clientset, obj, nil, dryRun)
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writeConfigPreview(ctx, nil, created)
+		return
+	}
+	ctx.JSON(http.StatusCreated, utils.MaskConfigObject(configObject(created)))
+}
+
+// updateConfig 替换 ConfigMap 或 Secret 的内容，修改前的内容与修改在同一个事务中保存，修改失败时不保留
+func (c *ConfigController) updateConfig(ctx *gin.Context, kind string) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req ConfigRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateConfigRequest(kind, req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cluster, clientset, ok := clusterWithClient(ctx, c.DB, c.Clients)
+	if !ok {
+		return
+	}
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	live, previous, err := getConfigObject(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	obj, err := buildConfigObject(kind, namespace, name, req, live)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dryRun {
+		admitted, err := writeConfigObject(ctx, clientset, obj, live, true)
+		if err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+		writeConfigPreview(ctx, live, admitted)
+		return
+	}
+
+	var updated runtime.Object
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.RecordConfigRevision(tx, cluster.ID, models.ConfigActionUpdate, previous); err != nil {
+			return err
+		}
+		var err error
+		updated, err = writeConfigObject(ctx, clientset, obj, live, false)
+		return err
+	})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, utils.MaskConfigObject(configObject(updated)))
+}
+
+// deleteConfig 删除 ConfigMap 或 Secret，删除前的内容与删除在同一个事务中保存
+func (c *ConfigController) deleteConfig(ctx *gin.Context, kind string) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	cluster, clientset, ok := clusterWithClient(ctx, c.DB, c.Clients)
+	if !ok {
+		return
+	}
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	live, previous, err := getConfigObject(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	// 只删除读取到的版本，避免记录的内容与被删除的内容不一致
+	options := metav1.DeleteOptions{
+		DryRun:        utils.DryRunOptions(dryRun),
+		Preconditions: &metav1.Preconditions{ResourceVersion: &previous.ResourceVersion},
+	}
+
+	if dryRun {
+		if err := deleteConfigObject(ctx, clientset, kind, namespace, name, options); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+		writeConfigPreview(ctx, live, nil)
+		return
+	}
+
+	err = c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.RecordConfigRevision(tx, cluster.ID, models.ConfigActionDelete, previous); err != nil {
+			return err
+		}
+		return deleteConfigObject(ctx, clientset, kind, namespace, name, options)
+	})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": kind + " 删除成功"})
+}
+
+// listRevisions 返回 ConfigMap 或 Secret 的历史版本，reveal 为 false 时隐藏 Secret 的值
+func (c *ConfigController) listRevisions(ctx *gin.Context, kind string, reveal bool) {
+	var cluster models.Cluster
+	if err := c.DB.First(&cluster, ctx.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	revisions, err := utils.ListConfigRevisions(c.DB, cluster.ID, kind, ctx.Param("namespace"), ctx.Param("name"), reveal)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// validateConfigRequest 校验键名和 Secret 类型，Data 和 BinaryData 中的键不能重复
+func validateConfigRequest(kind string, req ConfigRequest) error {
+	if kind == "ConfigMap" && req.Type != "" {
+		return fmt.Errorf("ConfigMap 不能设置 type")
+	}
+	for key := range req.Data {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("data 的键无效 %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for key := range req.BinaryData {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("binary_data 的键无效 %q: %s", key, strings.Join(errs, "; "))
+		}
+		if _, ok := req.Data[key]; ok {
+			return fmt.Errorf("键 %q 不能同时出现在 data 和 binary_data 中", key)
+		}
+	}
+	return nil
+}
+
+// getConfigObject 读取集群中的 ConfigMap 或 Secret 及其明文内容
+func getConfigObject(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string) (runtime.Object, models.ConfigObject, error) {
+	switch kind {
+	case "ConfigMap":
+		configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, models.ConfigObject{}, err
+		}
+		return configMap, utils.ConfigMapObject(configMap), nil
+	default:
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, models.ConfigObject{}, err
+		}
+		return secret, utils.SecretObject(secret), nil
+	}
+}
+
+// configObject 读取 ConfigMap 或 Secret 的明文内容
+func configObject(obj runtime.Object) models.ConfigObject {
+	if secret, ok := obj.(*corev1.Secret); ok {
+		return utils.SecretObject(secret)
+	}
+	return utils.ConfigMapObject(obj.(*corev1.ConfigMap))
+}
+
+// buildConfigObject 根据请求生成 ConfigMap 或 Secret，更新时在集群中的对象上修改以保留其他元数据
+func buildConfigObject(kind, namespace, name string, req ConfigRequest, live runtime.Object) (runtime.Object, error) {
+	var immutable *bool
+	if req.Immutable {
+		immutable = &req.Immutable
+	}
+	meta := metav1.ObjectMeta{Name: name, Namespace: namespace}
+
+	if kind == "ConfigMap" {
+		configMap := &corev1.ConfigMap{ObjectMeta: meta}
+		if live != nil {
+			configMap = live.(*corev1.ConfigMap).DeepCopy()
+		}
+		configMap.Labels = req.Labels
+		configMap.Annotations = req.Annotations
+		configMap.Data = req.Data
+		configMap.BinaryData = req.BinaryData
+		configMap.Immutable = immutable
+		return configMap, nil
+	}
+
+	secret := &corev1.Secret{ObjectMeta: meta, Type: corev1.SecretTypeOpaque}
+	if live != nil {
+		secret = live.(*corev1.Secret).DeepCopy()
+	}
+	if req.Type != "" {
+		secret.Type = corev1.SecretType(req.Type)
+	}
+	previous := secret.Data
+	secret.Data = make(map[string][]byte, len(req.Data)+len(req.BinaryData))
+	for key, value := range req.Data {
+		if value == utils.MaskedValue && live != nil {
+			original, ok := previous[key]
+			if !ok {
+				return nil, fmt.Errorf("键 %q 的值为隐藏值，但 Secret 中不存在该键", key)
+			}
+			secret.Data[key] = original
+			continue
+		}
+		secret.Data[key] = []byte(value)
+	}
+	for key, value := range req.BinaryData {
+		secret.Data[key] = value
+	}
+	secret.StringData = nil
+	secret.Labels = req.Labels
+	secret.Annotations = req.Annotations
+	secret.Immutable = immutable
+	return secret, nil
+}
+
+// writeConfigObject 创建或更新 ConfigMap 或 Secret，live 为空时创建
+func writeConfigObject(ctx context.Context, clientset kubernetes.Interface, obj, live runtime.Object, dryRun bool) (runtime.Object, error) {
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		configMaps := clientset.CoreV1().ConfigMaps(o.Namespace)
+		if live == nil {
+			return configMaps.Create(ctx, o, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+		}
+		return configMaps.Update(ctx, o, metav1.UpdateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	case *corev1.Secret:
+		secrets := clientset.CoreV1().Secrets(o.Namespace)
+		if live == nil {
+			return secrets.Create(ctx, o, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+		}
+		return secrets.Update(ctx, o, metav1.UpdateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	default:
+		return nil, fmt.Errorf("%w: %T", utils.ErrUnsupportedKind, obj)
+	}
+}
+
+// deleteConfigObject 删除 ConfigMap 或 Secret
+func deleteConfigObject(ctx context.Context, clientset kubernetes.Interface, kind, namespace, name string, options metav1.DeleteOptions) error {
+	if kind == "ConfigMap" {
+		return clientset.CoreV1().ConfigMaps(namespace).Delete(ctx, name, options)
+	}
+	return clientset.CoreV1().Secrets(namespace).Delete(ctx, name, options)
+}
+
+// writeConfigPreview 写入试运行预览，Secret 的值在对比前隐藏
+func writeConfigPreview(ctx *gin.Context, live, admitted runtime.Object) {
+	liveSecret, _ := live.(*corev1.Secret)
+	admittedSecret, _ := admitted.(*corev1.Secret)
+	if liveSecret == nil && admittedSecret == nil {
+		writePreview(ctx, live, admitted)
+		return
+	}
+
+	maskedLive, maskedAdmitted := utils.MaskSecretPreview(liveSecret, admittedSecret)
+	var before, after runtime.Object
+	if maskedLive != nil {
+		before = maskedLive
+	}
+	if maskedAdmitted != nil {
+		after = maskedAdmitted
+	}
+	writePreview(ctx, before, after)
+}
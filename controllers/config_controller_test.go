@@ -0,0 +1,273 @@
+package controllers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/encryption"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestConfigMapCRUDWithRevisions(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/configmaps", cluster.ID)
+
+	if w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{"name": "web", "type": "Opaque"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("ConfigMap 设置 type 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{"name": "web", "data": gin.H{"bad key": "v"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("键名无效期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{"name": "web", "data": gin.H{"port": "80"}, "labels": gin.H{"app": "web"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 ConfigMap 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// 试运行不修改集群也不保存历史版本
+	w = doRequest(t, r, http.MethodPut, base+"/default/web?dryRun=true", gin.H{"data": gin.H{"port": "8080"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"dry_run":true`) {
+		t.Fatalf("试运行更新期望返回预览，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, port := range []string{"8080", "9090"} {
+		if w := doRequest(t, r, http.MethodPut, base+"/default/web", gin.H{"data": gin.H{"port": port}}); w.Code != http.StatusOK {
+			t.Fatalf("更新 ConfigMap 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+	configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil || configMap.Data["port"] != "9090" || len(configMap.Labels) != 0 {
+		t.Fatalf("更新后的 ConfigMap 不符合预期: %+v, %v", configMap, err)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/default/web", nil); w.Code != http.StatusOK {
+		t.Fatalf("删除 ConfigMap 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// 删除后仍可查询历史版本
+	w = doRequest(t, r, http.MethodGet, base+"/default/web/revisions", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取历史版本期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Revisions []utils.ConfigRevisionRecord `json:"revisions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(resp.Revisions) != 3 {
+		t.Fatalf("期望 3 个历史版本，实际 %d: %s", len(resp.Revisions), w.Body.String())
+	}
+	expected := []struct {
+		revision int64
+		action   string
+		port     string
+	}{{3, models.ConfigActionDelete, "9090"}, {2, models.ConfigActionUpdate, "8080"}, {1, models.ConfigActionUpdate, "80"}}
+	for i, e := range expected {
+		got := resp.Revisions[i]
+		if got.Revision != e.revision || got.Action != e.action || got.Object.Data["port"] != e.port {
+			t.Fatalf("第 %d 个历史版本不符合预期: %+v", i, got)
+		}
+	}
+	if resp.Revisions[2].Object.Labels["app"] != "web" {
+		t.Fatalf("历史版本应保留标签: %+v", resp.Revisions[2].Object)
+	}
+}
+
+func TestConfigUpdateFailureDiscardsRevision(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	clientset.CoreV1().ConfigMaps("default").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Data:       map[string]string{"port": "80"},
+	}, metav1.CreateOptions{})
+	clientset.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(corev1.Resource("configmaps"), "web", fmt.Errorf("对象已被修改"))
+	})
+
+	w := doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/configmaps/default/web", cluster.ID), gin.H{"data": gin.H{"port": "8080"}})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("更新冲突期望状态码 %d，实际 %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&models.ConfigRevision{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("更新失败时不应保存历史版本，实际 %d", count)
+	}
+}
+
+func TestSecretMaskedUnlessRevealed(t *testing.T) {
+	keyring, err := encryption.NewKeyring(encryption.Key{ID: "test", Secret: bytes.Repeat([]byte{7}, 32)})
+	if err != nil {
+		t.Fatalf("创建 Keyring 失败: %v", err)
+	}
+	encryption.SetDefault(keyring)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/secrets", cluster.ID)
+
+	w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{
+		"name": "db", "data": gin.H{"username": "admin", "password": "s3cr3t"},
+		"binary_data": gin.H{"key.bin": []byte{0xff, 0xfe}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 Secret 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "s3cr3t") {
+		t.Fatalf("创建响应中不应包含明文: %s", w.Body.String())
+	}
+	secret, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if err != nil || secret.Type != corev1.SecretTypeOpaque || string(secret.Data["password"]) != "s3cr3t" || len(secret.Data["key.bin"]) != 2 {
+		t.Fatalf("创建的 Secret 不符合预期: %+v, %v", secret, err)
+	}
+
+	for _, path := range []string{base + "/default/db", base + "?namespace=default"} {
+		w := doRequest(t, r, http.MethodGet, path, nil)
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "s3cr3t") || !strings.Contains(w.Body.String(), utils.MaskedValue) {
+			t.Fatalf("%s 应隐藏 Secret 的值，实际 %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	// 值为隐藏值的键保留原值
+	w = doRequest(t, r, http.MethodPut, base+"/default/db?dryRun=true", gin.H{"data": gin.H{"username": utils.MaskedValue, "password": "n3w"}})
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "s3cr3t") || strings.Contains(w.Body.String(), "n3w") {
+		t.Fatalf("试运行预览应隐藏 Secret 的值，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPut, base+"/default/db", gin.H{
+		"data": gin.H{"username": utils.MaskedValue, "password": "n3w"}, "binary_data": gin.H{"key.bin": []byte{0xff, 0xfe}},
+	}); w.Code != http.StatusOK {
+		t.Fatalf("更新 Secret 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	secret, _ = clientset.CoreV1().Secrets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if string(secret.Data["username"]) != "admin" || string(secret.Data["password"]) != "n3w" || len(secret.Data) != 3 {
+		t.Fatalf("更新后的 Secret 不符合预期: %v", secret.Data)
+	}
+	if w := doRequest(t, r, http.MethodPut, base+"/default/db", gin.H{"data": gin.H{"token": utils.MaskedValue}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("不存在的键使用隐藏值期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 历史版本加密存储
+	var stored string
+	db.Raw("SELECT content FROM config_revisions WHERE kind = ?", "Secret").Scan(&stored)
+	if !encryption.IsEncrypted(stored) {
+		t.Fatalf("历史版本应加密存储，实际: %s", stored)
+	}
+	w = doRequest(t, r, http.MethodGet, base+"/default/db/revisions", nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "s3cr3t") {
+		t.Fatalf("历史版本应隐藏 Secret 的值，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 查看明文需要管理员令牌
+	reveal := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := doRequest(t, r, http.MethodGet, base+"/default/db/reveal", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("未携带管理员令牌时期望状态码 %d，实际 %d", http.StatusUnauthorized, w.Code)
+	}
+	var object models.ConfigObject
+	w = reveal(base + "/default/db/reveal")
+	if err := json.Unmarshal(w.Body.Bytes(), &object); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("查看 Secret 明文失败: %d %s", w.Code, w.Body.String())
+	}
+	if object.Masked || object.Data["password"] != "n3w" || !bytes.Equal(object.BinaryData["key.bin"], []byte{0xff, 0xfe}) {
+		t.Fatalf("Secret 明文不符合预期: %+v", object)
+	}
+	w = reveal(base + "/default/db/revisions/reveal")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "s3cr3t") {
+		t.Fatalf("查看历史版本明文失败: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateDeploymentWithEnvSources(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default", cluster.ID)
+
+	request := func(env []gin.H, envFrom []gin.H) gin.H {
+		req := deploymentRequest("web")
+		req["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []gin.H{{
+			"name": "web", "image": "nginx:1.25", "env": env, "env_from": envFrom,
+		}}}}
+		return req
+	}
+	invalid := [][]gin.H{
+		{{"name": "A", "value": "1", "value_from": gin.H{"field_ref": gin.H{"field_path": "metadata.name"}}}},
+		{{"name": "A", "value_from": gin.H{}}},
+		{{"name": "A", "value_from": gin.H{"secret_key_ref": gin.H{"name": "db"}}}},
+		{{"name": "A", "value_from": gin.H{"resource_field_ref": gin.H{"resource": "limits.memory", "divisor": "abc"}}}},
+	}
+	for _, env := range invalid {
+		if w := doRequest(t, r, http.MethodPost, path, request(env, nil)); w.Code != http.StatusBadRequest {
+			t.Fatalf("环境变量 %v 期望 400，实际 %d: %s", env, w.Code, w.Body.String())
+		}
+	}
+	w := doRequest(t, r, http.MethodPost, path, request(nil, []gin.H{{"config_map_ref": gin.H{"name": "a"}, "secret_ref": gin.H{"name": "b"}}}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("env_from 同时引用 ConfigMap 和 Secret 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, r, http.MethodPost, path, request(
+		[]gin.H{
+			{"name": "MODE", "value": "prod"},
+			{"name": "PORT", "value_from": gin.H{"config_map_key_ref": gin.H{"name": "web", "key": "port"}}},
+			{"name": "DB_PASSWORD", "value_from": gin.H{"secret_key_ref": gin.H{"name": "db", "key": "password", "optional": true}}},
+			{"name": "POD_IP", "value_from": gin.H{"field_ref": gin.H{"field_path": "status.podIP"}}},
+			{"name": "MEMORY_MB", "value_from": gin.H{"resource_field_ref": gin.H{"resource": "limits.memory", "divisor": "1Mi"}}},
+		},
+		[]gin.H{{"prefix": "WEB_", "config_map_ref": gin.H{"name": "web"}}, {"secret_ref": gin.H{"name": "db"}}},
+	))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 Deployment 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	c := deployment.Spec.Template.Spec.Containers[0]
+	if len(c.Env) != 5 || c.Env[1].ValueFrom.ConfigMapKeyRef.Key != "port" ||
+		c.Env[2].ValueFrom.SecretKeyRef.Optional == nil || !*c.Env[2].ValueFrom.SecretKeyRef.Optional ||
+		c.Env[3].ValueFrom.FieldRef.FieldPath != "status.podIP" || c.Env[4].ValueFrom.ResourceFieldRef.Divisor.String() != "1Mi" {
+		t.Fatalf("环境变量不符合预期: %+v", c.Env)
+	}
+	if len(c.EnvFrom) != 2 || c.EnvFrom[0].Prefix != "WEB_" || c.EnvFrom[0].ConfigMapRef.Name != "web" || c.EnvFrom[1].SecretRef.Name != "db" {
+		t.Fatalf("env_from 不符合预期: %+v", c.EnvFrom)
+	}
+
+	// 从集群同步的容器配置与创建时一致
+	containers := utils.ContainersFromPodSpec(deployment.Spec.Template.Spec)
+	if env := containers[0].Env[2]; env.ValueFrom == nil || env.ValueFrom.SecretKeyRef.Name != "db" || !env.ValueFrom.SecretKeyRef.Optional {
+		t.Fatalf("同步的环境变量不符合预期: %+v", containers[0].Env)
+	}
+	if env := containers[0].Env[4]; env.ValueFrom.ResourceFieldRef.Divisor != "1Mi" {
+		t.Fatalf("同步的 divisor 不符合预期: %+v", env.ValueFrom.ResourceFieldRef)
+	}
+	if len(containers[0].EnvFrom) != 2 || containers[0].EnvFrom[0].ConfigMapRef.Name != "web" {
+		t.Fatalf("同步的 env_from 不符合预期: %+v", containers[0].EnvFrom)
+	}
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NamespaceController 管理集群中的命名空间
+type NamespaceController struct {
+	DB      *gorm.DB
+	Clients utils.ClientProvider
+}
+
+// NamespaceRequest 创建命名空间的请求
+type NamespaceRequest struct {
+	// 命名空间名称
+	Name string `json:"name" binding:"required" example:"team-a"`
+	// 标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// BulkDeleteNamespacesRequest 批量删除命名空间的请求
+type BulkDeleteNamespacesRequest struct {
+	// 命名空间名称
+	Names []string `json:"names" binding:"required,min=1" example:"team-a,team-b"`
+	// 命名空间中存在工作负载时是否仍然删除
+	Force bool `json:"force" example:"false"`
+}
+
+// NamespaceDeleteResult 删除单个命名空间的结果
+type NamespaceDeleteResult struct {
+	// 命名空间名称
+	Name string `json:"name" example:"team-a"`
+	// 是否已提交删除
+	Deleted bool `json:"deleted" example:"true"`
+	// 删除失败的原因
+	Error string `json:"error,omitempty"`
+	// 命名空间中的工作负载，删除命名空间时会一起删除
+	Workloads []utils.NamespaceWorkload `json:"workloads"`
+}
+
+// ListNamespaces godoc
+// @Summary      获取命名空间列表
+// @Description  获取集群中的命名空间，按名称排序
+// @Tags         namespaces
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Success      200 {object} map[string][]corev1.Namespace "命名空间列表"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/namespaces [get]
+func (n *NamespaceController) ListNamespaces(ctx *gin.Context) {
+	clientset, ok := clusterClient(ctx, n.DB, n.Clients)
+	if !ok {
+		return
+	}
+	list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	ctx.JSON(http.StatusOK, gin.H{"namespaces": list.Items})
+}
+
+// CreateNamespace godoc
+// @Summary      创建命名空间
+// @Tags         namespaces
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace body NamespaceRequest true "命名空间信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} corev1.Namespace "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      409 {object} map[string]string "命名空间已存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/namespaces [post]
+func (n *NamespaceController) CreateNamespace(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req NamespaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validation.IsDNS1123Label(req.Name); len(errs) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name 无效 %q: %s", req.Name, strings.Join(errs, "; "))})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, n.DB, n.Clients)
+	if !ok {
+		return
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Labels: req.Labels, Annotations: req.Annotations}}
+	created, err := clientset.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// DeleteNamespace godoc
+// @Summary      删除命名空间
+// @Description  命名空间中存在工作负载时返回 409 和工作负载列表，确认后设置 force=true 删除。系统命名空间不能删除。
+// @Description  命名空间中的所有资源会被集群回收，工作负载记录同时删除
+// @Tags         namespaces
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        name path string true "命名空间名称"
+// @Param        force query bool false "命名空间中存在工作负载时是否仍然删除"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} NamespaceDeleteResult "已提交删除"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "系统命名空间"
+// @Failure      404 {object} map[string]string "集群或命名空间不存在"
+// @Failure      409 {object} NamespaceDeleteResult "命名空间中存在工作负载"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/namespaces/{name} [delete]
+func (n *NamespaceController) DeleteNamespace(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	force, err := parseBoolQuery(ctx, "force")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cluster, clientset, ok := clusterWithClient(ctx, n.DB, n.Clients)
+	if !ok {
+		return
+	}
+
+	name := ctx.Param("name")
+	var live *corev1.Namespace
+	if dryRun {
+		if live, err = clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{}); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+	result := n.deleteNamespace(ctx, clientset, cluster.ID, name, force, dryRun)
+	switch {
+	case result.err == nil && dryRun:
+		writePreview(ctx, live, nil)
+	case result.err == nil:
+		ctx.JSON(http.StatusOK, result.NamespaceDeleteResult)
+	case errors.Is(result.err, utils.ErrNamespaceNotEmpty):
+		ctx.JSON(http.StatusConflict, result.NamespaceDeleteResult)
+	case errors.Is(result.err, utils.ErrSystemNamespace):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": result.Error})
+	default:
+		writeKubeError(ctx, result.err)
+	}
+}
+
+// BulkDeleteNamespaces godoc
+// @Summary      批量删除命名空间
+// @Description  逐个删除命名空间，单个失败不影响其他命名空间。存在工作负载的命名空间需要设置 force 才会删除，结果中列出其中的工作负载
+// @Tags         namespaces
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        request body BulkDeleteNamespacesRequest true "要删除的命名空间"
+// @Success      200 {object} map[string][]NamespaceDeleteResult "每个命名空间的删除结果"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/namespaces/bulk-delete [post]
+func (n *NamespaceController) BulkDeleteNamespaces(ctx *gin.Context) {
+	var req BulkDeleteNamespacesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cluster, clientset, ok := clusterWithClient(ctx, n.DB, n.Clients)
+	if !ok {
+		return
+	}
+
+	results := make([]NamespaceDeleteResult, 0, len(req.Names))
+	seen := make(map[string]bool, len(req.Names))
+	for _, name := range req.Names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		results = append(results, n.deleteNamespace(ctx, clientset, cluster.ID, name, req.Force, false).NamespaceDeleteResult)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// namespaceDeletion 删除命名空间的结果和错误
+type namespaceDeletion struct {
+	NamespaceDeleteResult
+	err error
+}
+
+// deleteNamespace 检查命名空间中的工作负载后删除命名空间，并删除该命名空间中的工作负载记录
+func (n *NamespaceController) deleteNamespace(ctx context.Context, clientset kubernetes.Interface, clusterID uint, name string, force, dryRun bool) namespaceDeletion {
+	result := namespaceDeletion{NamespaceDeleteResult: NamespaceDeleteResult{Name: name, Workloads: []utils.NamespaceWorkload{}}}
+	fail := func(err error) namespaceDeletion {
+		result.err = err
+		result.Error = err.Error()
+		return result
+	}
+
+	if utils.IsSystemNamespace(name) {
+		return fail(fmt.Errorf("%w: %s", utils.ErrSystemNamespace, name))
+	}
+	workloads, err := utils.ListNamespaceWorkloads(ctx, clientset, name)
+	if err != nil {
+		return fail(err)
+	}
+	result.Workloads = workloads
+	if len(workloads) > 0 && !force {
+		return fail(utils.ErrNamespaceNotEmpty)
+	}
+
+	if err := clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{DryRun: utils.DryRunOptions(dryRun)}); err != nil {
+		return fail(err)
+	}
+	result.Deleted = true
+	if !dryRun {
+		// 命名空间中的对象由集群异步回收，工作负载记录直接删除
+		if err := n.DB.Where("cluster_id = ? AND namespace = ?", clusterID, name).Delete(&models.Workload{}).Error; err != nil {
+			return fail(fmt.Errorf("命名空间已删除，但删除工作负载记录失败: %w", err))
+		}
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/models"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceLifecycle(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/namespaces", cluster.ID)
+
+	if w := doRequest(t, r, http.MethodPost, base, gin.H{"name": "Team_A"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("名称无效期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	for _, name := range []string{"team-b", "team-a"} {
+		w := doRequest(t, r, http.MethodPost, base, gin.H{"name": name, "labels": gin.H{"team": name}, "annotations": gin.H{"owner": "ops"}})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("创建命名空间期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+	}
+	if w := doRequest(t, r, http.MethodPost, base, gin.H{"name": "team-a"}); w.Code != http.StatusConflict {
+		t.Fatalf("重复创建期望 409，实际 %d: %s", w.Code, w.Body.String())
+	}
+	namespace, err := clientset.CoreV1().Namespaces().Get(context.Background(), "team-a", metav1.GetOptions{})
+	if err != nil || namespace.Labels["team"] != "team-a" || namespace.Annotations["owner"] != "ops" {
+		t.Fatalf("命名空间不符合预期: %+v, %v", namespace, err)
+	}
+
+	w := doRequest(t, r, http.MethodGet, base, nil)
+	var list struct {
+		Namespaces []corev1.Namespace `json:"namespaces"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取命名空间列表失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(list.Namespaces) != 2 || list.Namespaces[0].Name != "team-a" {
+		t.Fatalf("命名空间列表应按名称排序: %+v", list.Namespaces)
+	}
+
+	// 命名空间中有工作负载时需要确认，CronJob 创建的 Job 不单独列出
+	ctx := context.Background()
+	clientset.AppsV1().Deployments("team-a").Create(ctx, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}}, metav1.CreateOptions{})
+	cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "team-a", UID: "cronjob-uid"}}
+	clientset.BatchV1().CronJobs("team-a").Create(ctx, cronJob, metav1.CreateOptions{})
+	clientset.BatchV1().Jobs("team-a").Create(ctx, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name: "backup-1", Namespace: "team-a",
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+	}}, metav1.CreateOptions{})
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "team-a", ClusterID: cluster.ID})
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "team-b", ClusterID: cluster.ID})
+
+	w = doRequest(t, r, http.MethodDelete, base+"/team-a", nil)
+	var result controllers.NamespaceDeleteResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusConflict {
+		t.Fatalf("存在工作负载时期望 409，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if result.Deleted || len(result.Workloads) != 2 || result.Workloads[0].Kind != "CronJob" || result.Workloads[1].Name != "web" {
+		t.Fatalf("删除结果应列出工作负载: %+v", result)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/team-a?force=true&dryRun=true", nil); w.Code != http.StatusOK {
+		t.Fatalf("试运行删除期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{}); err != nil {
+		t.Fatalf("试运行不应删除命名空间: %v", err)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/team-a?force=true", nil); w.Code != http.StatusOK {
+		t.Fatalf("确认删除期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("命名空间应已删除: %v", err)
+	}
+	var count int64
+	db.Model(&models.Workload{}).Where("cluster_id = ?", cluster.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("只应删除该命名空间中的工作负载记录，剩余 %d", count)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/kube-system", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("删除系统命名空间期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodDelete, base+"/missing", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("删除不存在的命名空间期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBulkDeleteNamespaces(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	ctx := context.Background()
+	for _, name := range []string{"dev", "staging"} {
+		clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+	}
+	clientset.AppsV1().StatefulSets("staging").Create(ctx, &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "staging"}}, metav1.CreateOptions{})
+	path := fmt.Sprintf("/api/v1/clusters/%d/namespaces/bulk-delete", cluster.ID)
+
+	if w := doRequest(t, r, http.MethodPost, path, gin.H{"names": []string{}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("没有命名空间期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w := doRequest(t, r, http.MethodPost, path, gin.H{"names": []string{"dev", "staging", "default", "dev"}})
+	var resp struct {
+		Results []controllers.NamespaceDeleteResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("批量删除失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("重复的命名空间只应删除一次: %+v", resp.Results)
+	}
+	dev, staging, system := resp.Results[0], resp.Results[1], resp.Results[2]
+	if !dev.Deleted || staging.Deleted || len(staging.Workloads) != 1 || staging.Error == "" || system.Deleted || system.Error == "" {
+		t.Fatalf("批量删除结果不符合预期: %+v", resp.Results)
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "staging", metav1.GetOptions{}); err != nil {
+		t.Fatalf("未确认时不应删除有工作负载的命名空间: %v", err)
+	}
+
+	w = doRequest(t, r, http.MethodPost, path, gin.H{"names": []string{"staging"}, "force": true})
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Results) != 1 || !resp.Results[0].Deleted {
+		t.Fatalf("确认后批量删除失败: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateDeploymentCreatesNamespace(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	ctx := context.Background()
+
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/team-a?createNamespace=true", cluster.ID)
+	if w := doRequest(t, r, http.MethodPost, path, deploymentRequest("web")); w.Code != http.StatusCreated {
+		t.Fatalf("创建 Deployment 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "team-a", metav1.GetOptions{}); err != nil {
+		t.Fatalf("应创建命名空间: %v", err)
+	}
+	// 命名空间已存在时直接使用
+	if w := doRequest(t, r, http.MethodPost, path, deploymentRequest("api")); w.Code != http.StatusCreated {
+		t.Fatalf("已有命名空间中创建 Deployment 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Deployment 创建失败时删除新建的命名空间
+	clientset.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web", fmt.Errorf("超出配额"))
+	})
+	path = fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/team-b?createNamespace=true", cluster.ID)
+	if w := doRequest(t, r, http.MethodPost, path, deploymentRequest("web")); w.Code != http.StatusForbidden {
+		t.Fatalf("创建 Deployment 失败期望 403，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := clientset.CoreV1().Namespaces().Get(ctx, "team-b", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("应删除新建的命名空间: %v", err)
+	}
+}
@@ -0,0 +1,834 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ServiceController 管理集群中的 Service 和 Ingress，对象只保存在集群中
+type ServiceController struct {
+	DB      *gorm.DB
+	Clients utils.ClientProvider
+}
+
+// ServiceRequest 创建或更新 Service 的请求
+type ServiceRequest struct {
+	// Service 名称，只在创建时使用
+	Name string `json:"name" example:"nginx"`
+	// 类型: ClusterIP, NodePort, LoadBalancer, Headless，默认为 ClusterIP
+	Type string `json:"type" example:"ClusterIP"`
+	// Pod 选择器，为空时需要自行维护 Endpoints
+	Selector map[string]string `json:"selector"`
+	// 端口，Headless Service 可以为空
+	Ports []models.ServicePort `json:"ports"`
+	// 标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IngressRequest 创建或更新 Ingress 的请求
+type IngressRequest struct {
+	// Ingress 名称，只在创建时使用
+	Name string `json:"name" example:"nginx"`
+	// IngressClass 名称，为空时使用集群默认的 IngressClass
+	ClassName string `json:"class_name,omitempty" example:"nginx"`
+	// 转发规则
+	Rules []IngressRule `json:"rules"`
+	// TLS 配置
+	TLS []IngressTLS `json:"tls,omitempty"`
+	// 标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IngressRule Ingress 的一条转发规则
+type IngressRule struct {
+	// 域名，为空时匹配所有域名
+	Host string `json:"host,omitempty" example:"example.com"`
+	// 路径
+	Paths []IngressPath `json:"paths"`
+}
+
+// IngressPath 将一个路径转发到 Service
+type IngressPath struct {
+	// 路径，必须以 / 开头
+	Path string `json:"path" example:"/"`
+	// 匹配方式: Prefix, Exact, ImplementationSpecific，默认为 Prefix
+	PathType string `json:"path_type,omitempty" example:"Prefix"`
+	// 后端 Service 名称
+	ServiceName string `json:"service_name" example:"nginx"`
+	// 后端 Service 的端口号或端口名称
+	ServicePort intstr.IntOrString `json:"service_port" swaggertype:"string" example:"80"`
+}
+
+// IngressTLS Ingress 的 TLS 配置
+type IngressTLS struct {
+	// 证书适用的域名
+	Hosts []string `json:"hosts" example:"example.com"`
+	// 保存证书的 Secret 名称
+	SecretName string `json:"secret_name" example:"example-tls"`
+}
+
+// WorkloadServiceOptions 创建工作负载时根据容器端口生成同名 Service 的选项
+type WorkloadServiceOptions struct {
+	// Service 类型: ClusterIP, NodePort, LoadBalancer, Headless，默认为 ClusterIP。
+	// StatefulSet 只支持 Headless，生成的 Service 即 serviceName 指向的 Service
+	Type string `json:"type" example:"ClusterIP"`
+}
+
+// ListServices godoc
+// @Summary      获取 Service 列表
+// @Description  获取集群中的 Service，按命名空间和名称排序
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace query string false "命名空间，为空时返回所有命名空间"
+// @Success      200 {object} map[string][]corev1.Service "Service 列表"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/services [get]
+func (s *ServiceController) ListServices(ctx *gin.Context) {
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	list, err := clientset.CoreV1().Services(ctx.Query("namespace")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		return a.Namespace < b.Namespace || (a.Namespace == b.Namespace && a.Name < b.Name)
+	})
+	ctx.JSON(http.StatusOK, gin.H{"services": list.Items})
+}
+
+// GetService godoc
+// @Summary      获取 Service 详情
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Service 名称"
+// @Success      200 {object} corev1.Service "获取成功"
+// @Failure      404 {object} map[string]string "集群或 Service 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/services/{namespace}/{name} [get]
+func (s *ServiceController) GetService(ctx *gin.Context) {
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	service, err := clientset.CoreV1().Services(ctx.Param("namespace")).Get(ctx, ctx.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, service)
+}
+
+// CreateService godoc
+// @Summary      创建 Service
+// @Description  支持 ClusterIP、NodePort、LoadBalancer 和 Headless 类型
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        service body ServiceRequest true "Service 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} corev1.Service "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      409 {object} map[string]string "Service 已存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/services/{namespace} [post]
+func (s *ServiceController) CreateService(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req ServiceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validation.IsDNS1035Label(req.Name); len(errs) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name 无效 %q: %s", req.Name, strings.Join(errs, "; "))})
+		return
+	}
+	if err := validateServiceRequest(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	namespace := ctx.Param("namespace")
+	created, err := clientset.CoreV1().Services(namespace).Create(ctx, buildService(namespace, req.Name, req, nil), metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateService godoc
+// @Summary      更新 Service
+// @Description  使用请求替换 Service 的类型、选择器、端口、标签和注解，保留已分配的 ClusterIP 和未指定的 NodePort。Headless 与其他类型不能互相转换
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Service 名称"
+// @Param        service body ServiceRequest true "Service 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} corev1.Service "更新成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群或 Service 不存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/services/{namespace}/{name} [put]
+func (s *ServiceController) UpdateService(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req ServiceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateServiceRequest(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	services := clientset.CoreV1().Services(namespace)
+	live, err := services.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	updated, err := services.Update(ctx, buildService(namespace, name, req, live), metav1.UpdateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, updated)
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteService godoc
+// @Summary      删除 Service
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Service 名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "删除成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      404 {object} map[string]string "集群或 Service 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/services/{namespace}/{name} [delete]
+func (s *ServiceController) DeleteService(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	services := clientset.CoreV1().Services(ctx.Param("namespace"))
+
+	var live *corev1.Service
+	if dryRun {
+		var err error
+		if live, err = services.Get(ctx, ctx.Param("name"), metav1.GetOptions{}); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+	if err := services.Delete(ctx, ctx.Param("name"), metav1.DeleteOptions{DryRun: utils.DryRunOptions(dryRun)}); err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, nil)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Service 删除成功"})
+}
+
+// ListIngresses godoc
+// @Summary      获取 Ingress 列表
+// @Description  获取集群中的 Ingress，按命名空间和名称排序
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace query string false "命名空间，为空时返回所有命名空间"
+// @Success      200 {object} map[string][]networkingv1.Ingress "Ingress 列表"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/ingresses [get]
+func (s *ServiceController) ListIngresses(ctx *gin.Context) {
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	list, err := clientset.NetworkingV1().Ingresses(ctx.Query("namespace")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, b := list.Items[i], list.Items[j]
+		return a.Namespace < b.Namespace || (a.Namespace == b.Namespace && a.Name < b.Name)
+	})
+	ctx.JSON(http.StatusOK, gin.H{"ingresses": list.Items})
+}
+
+// GetIngress godoc
+// @Summary      获取 Ingress 详情
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Ingress 名称"
+// @Success      200 {object} networkingv1.Ingress "获取成功"
+// @Failure      404 {object} map[string]string "集群或 Ingress 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/ingresses/{namespace}/{name} [get]
+func (s *ServiceController) GetIngress(ctx *gin.Context) {
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	ingress, err := clientset.NetworkingV1().Ingresses(ctx.Param("namespace")).Get(ctx, ctx.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, ingress)
+}
+
+// CreateIngress godoc
+// @Summary      创建 Ingress
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        ingress body IngressRequest true "Ingress 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} networkingv1.Ingress "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      409 {object} map[string]string "Ingress 已存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/ingresses/{namespace} [post]
+func (s *ServiceController) CreateIngress(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req IngressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(req.Name); len(errs) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("name 无效 %q: %s", req.Name, strings.Join(errs, "; "))})
+		return
+	}
+	if err := validateIngressRequest(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	namespace := ctx.Param("namespace")
+	created, err := clientset.NetworkingV1().Ingresses(namespace).Create(ctx, buildIngress(namespace, req.Name, req, nil), metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// UpdateIngress godoc
+// @Summary      更新 Ingress
+// @Description  使用请求替换 Ingress 的 IngressClass、规则、TLS、标签和注解
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Ingress 名称"
+// @Param        ingress body IngressRequest true "Ingress 信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} networkingv1.Ingress "更新成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群或 Ingress 不存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/ingresses/{namespace}/{name} [put]
+func (s *ServiceController) UpdateIngress(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	var req IngressRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateIngressRequest(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	namespace, name := ctx.Param("namespace"), ctx.Param("name")
+	ingresses := clientset.NetworkingV1().Ingresses(namespace)
+	live, err := ingresses.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	updated, err := ingresses.Update(ctx, buildIngress(namespace, name, req, live), metav1.UpdateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, updated)
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteIngress godoc
+// @Summary      删除 Ingress
+// @Tags         services
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "Ingress 名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "删除成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      404 {object} map[string]string "集群或 Ingress 不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/ingresses/{namespace}/{name} [delete]
+func (s *ServiceController) DeleteIngress(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	clientset, ok := clusterClient(ctx, s.DB, s.Clients)
+	if !ok {
+		return
+	}
+	ingresses := clientset.NetworkingV1().Ingresses(ctx.Param("namespace"))
+
+	var live *networkingv1.Ingress
+	if dryRun {
+		var err error
+		if live, err = ingresses.Get(ctx, ctx.Param("name"), metav1.GetOptions{}); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+	if err := ingresses.Delete(ctx, ctx.Param("name"), metav1.DeleteOptions{DryRun: utils.DryRunOptions(dryRun)}); err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, nil)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Ingress 删除成功"})
+}
+
+// validateServiceRequest 校验 Service 的类型和端口，返回的错误以字段名开头
+func validateServiceRequest(req ServiceRequest) error {
+	switch req.Type {
+	case "", string(corev1.ServiceTypeClusterIP), string(corev1.ServiceTypeNodePort), string(corev1.ServiceTypeLoadBalancer), utils.ServiceTypeHeadless:
+	default:
+		return fmt.Errorf("type 只支持 ClusterIP、NodePort、LoadBalancer 和 Headless: %q", req.Type)
+	}
+	if len(req.Ports) == 0 && req.Type != utils.ServiceTypeHeadless {
+		return fmt.Errorf("ports 不能为空")
+	}
+	return validateServicePorts(req.Type, req.Ports)
+}
+
+// validateServicePorts 校验 Service 端口，多个端口时名称必填且不能重复
+func validateServicePorts(serviceType string, ports []models.ServicePort) error {
+	names := make(map[string]bool, len(ports))
+	for i, port := range ports {
+		if errs := validation.IsValidPortNum(int(port.Port)); len(errs) > 0 {
+			return fmt.Errorf("ports[%d].port 无效 %d: %s", i, port.Port, strings.Join(errs, "; "))
+		}
+		switch corev1.Protocol(port.Protocol) {
+		case "", corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+		default:
+			return fmt.Errorf("ports[%d].protocol 只支持 TCP、UDP 和 SCTP: %q", i, port.Protocol)
+		}
+		if port.TargetPort != (intstr.IntOrString{}) {
+			if err := validateProbePort(fmt.Sprintf("ports[%d].target_port", i), port.TargetPort); err != nil {
+				return err
+			}
+		}
+		if port.NodePort != 0 {
+			if serviceType != string(corev1.ServiceTypeNodePort) && serviceType != string(corev1.ServiceTypeLoadBalancer) {
+				return fmt.Errorf("ports[%d].node_port 只用于 NodePort 和 LoadBalancer 类型", i)
+			}
+			if errs := validation.IsValidPortNum(int(port.NodePort)); len(errs) > 0 {
+				return fmt.Errorf("ports[%d].node_port 无效 %d: %s", i, port.NodePort, strings.Join(errs, "; "))
+			}
+		}
+		if port.Name == "" {
+			if len(ports) > 1 {
+				return fmt.Errorf("ports[%d].name 在多个端口时不能为空", i)
+			}
+			continue
+		}
+		if errs := validation.IsDNS1123Label(port.Name); len(errs) > 0 {
+			return fmt.Errorf("ports[%d].name 无效 %q: %s", i, port.Name, strings.Join(errs, "; "))
+		}
+		if names[port.Name] {
+			return fmt.Errorf("ports[%d].name 重复: %s", i, port.Name)
+		}
+		names[port.Name] = true
+	}
+	return nil
+}
+
+// buildService 使用请求构建 Service。更新时传入集群中的 Service，保留已分配的 ClusterIP，
+// 类型仍使用 NodePort 时未指定的 NodePort 沿用已分配的端口
+func buildService(namespace, name string, req ServiceRequest, live *corev1.Service) *corev1.Service {
+	service := &corev1.Service{}
+	if live != nil {
+		service = live.DeepCopy()
+	}
+	service.Name = name
+	service.Namespace = namespace
+	service.Labels = req.Labels
+	service.Annotations = req.Annotations
+	service.Spec.Selector = req.Selector
+
+	switch req.Type {
+	case utils.ServiceTypeHeadless:
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+		service.Spec.ClusterIPs = []string{corev1.ClusterIPNone}
+	case "":
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+	default:
+		service.Spec.Type = corev1.ServiceType(req.Type)
+	}
+	// ClusterIP 不能修改，清除后由 API Server 拒绝 Headless 转换为其他类型
+	if req.Type != utils.ServiceTypeHeadless && service.Spec.ClusterIP == corev1.ClusterIPNone {
+		service.Spec.ClusterIP = ""
+		service.Spec.ClusterIPs = nil
+	}
+
+	allocated := make(map[string]int32)
+	if live != nil {
+		for _, port := range live.Spec.Ports {
+			allocated[fmt.Sprintf("%d/%s", port.Port, port.Protocol)] = port.NodePort
+		}
+	}
+	service.Spec.Ports = convertServicePorts(req.Ports)
+	hasNodePorts := service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer
+	for i, port := range service.Spec.Ports {
+		if hasNodePorts && port.NodePort == 0 {
+			service.Spec.Ports[i].NodePort = allocated[fmt.Sprintf("%d/%s", port.Port, port.Protocol)]
+		}
+	}
+	return service
+}
+
+// convertServicePorts 转换 Service 端口，协议默认为 TCP，目标端口默认与 Service 端口相同
+func convertServicePorts(ports []models.ServicePort) []corev1.ServicePort {
+	k8sPorts := make([]corev1.ServicePort, 0, len(ports))
+	for _, port := range ports {
+		protocol := corev1.Protocol(port.Protocol)
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		targetPort := port.TargetPort
+		if targetPort == (intstr.IntOrString{}) {
+			targetPort = intstr.FromInt32(port.Port)
+		}
+		k8sPorts = append(k8sPorts, corev1.ServicePort{
+			Name:       port.Name,
+			Protocol:   protocol,
+			Port:       port.Port,
+			TargetPort: targetPort,
+			NodePort:   port.NodePort,
+		})
+	}
+	return k8sPorts
+}
+
+// validateIngressRequest 校验 Ingress 的规则和 TLS 配置，返回的错误以字段名开头
+func validateIngressRequest(req IngressRequest) error {
+	if len(req.Rules) == 0 {
+		return fmt.Errorf("rules 不能为空")
+	}
+	for i, rule := range req.Rules {
+		if err := validateIngressHost(fmt.Sprintf("rules[%d].host", i), rule.Host); err != nil {
+			return err
+		}
+		if len(rule.Paths) == 0 {
+			return fmt.Errorf("rules[%d].paths 不能为空", i)
+		}
+		for j, p := range rule.Paths {
+			field := fmt.Sprintf("rules[%d].paths[%d]", i, j)
+			switch networkingv1.PathType(p.PathType) {
+			case "", networkingv1.PathTypePrefix, networkingv1.PathTypeExact, networkingv1.PathTypeImplementationSpecific:
+			default:
+				return fmt.Errorf("%s.path_type 只支持 Prefix、Exact 和 ImplementationSpecific: %q", field, p.PathType)
+			}
+			if !strings.HasPrefix(p.Path, "/") {
+				return fmt.Errorf("%s.path 必须以 / 开头: %q", field, p.Path)
+			}
+			if errs := validation.IsDNS1035Label(p.ServiceName); len(errs) > 0 {
+				return fmt.Errorf("%s.service_name 无效 %q: %s", field, p.ServiceName, strings.Join(errs, "; "))
+			}
+			if err := validateProbePort(field+".service_port", p.ServicePort); err != nil {
+				return err
+			}
+		}
+	}
+	for i, tls := range req.TLS {
+		for _, host := range tls.Hosts {
+			if err := validateIngressHost(fmt.Sprintf("tls[%d].hosts", i), host); err != nil {
+				return err
+			}
+		}
+		if tls.SecretName != "" {
+			if errs := validation.IsDNS1123Subdomain(tls.SecretName); len(errs) > 0 {
+				return fmt.Errorf("tls[%d].secret_name 无效 %q: %s", i, tls.SecretName, strings.Join(errs, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// validateIngressHost 校验 Ingress 域名，支持以 *. 开头的通配符域名
+func validateIngressHost(field, host string) error {
+	if host == "" {
+		return nil
+	}
+	var errs []string
+	if strings.HasPrefix(host, "*.") {
+		errs = validation.IsWildcardDNS1123Subdomain(host)
+	} else {
+		errs = validation.IsDNS1123Subdomain(host)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s 无效 %q: %s", field, host, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// buildIngress 使用请求构建 Ingress，更新时传入集群中的 Ingress 以保留其他元数据
+func buildIngress(namespace, name string, req IngressRequest, live *networkingv1.Ingress) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{}
+	if live != nil {
+		ingress = live.DeepCopy()
+	}
+	ingress.Name = name
+	ingress.Namespace = namespace
+	ingress.Labels = req.Labels
+	ingress.Annotations = req.Annotations
+
+	ingress.Spec.IngressClassName = nil
+	if req.ClassName != "" {
+		className := req.ClassName
+		ingress.Spec.IngressClassName = &className
+	}
+
+	ingress.Spec.Rules = make([]networkingv1.IngressRule, 0, len(req.Rules))
+	for _, rule := range req.Rules {
+		paths := make([]networkingv1.HTTPIngressPath, 0, len(rule.Paths))
+		for _, p := range rule.Paths {
+			pathType := networkingv1.PathType(p.PathType)
+			if pathType == "" {
+				pathType = networkingv1.PathTypePrefix
+			}
+			backend := networkingv1.IngressServiceBackend{Name: p.ServiceName}
+			if p.ServicePort.Type == intstr.String {
+				backend.Port.Name = p.ServicePort.StrVal
+			} else {
+				backend.Port.Number = p.ServicePort.IntVal
+			}
+			paths = append(paths, networkingv1.HTTPIngressPath{
+				Path:     p.Path,
+				PathType: &pathType,
+				Backend:  networkingv1.IngressBackend{Service: &backend},
+			})
+		}
+		ingress.Spec.Rules = append(ingress.Spec.Rules, networkingv1.IngressRule{
+			Host: rule.Host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+			},
+		})
+	}
+
+	ingress.Spec.TLS = nil
+	for _, tls := range req.TLS {
+		ingress.Spec.TLS = append(ingress.Spec.TLS, networkingv1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	return ingress
+}
+
+// workloadService 根据工作负载的容器端口构建同名 Service，选择器为工作负载的 Pod 标签
+// options 为空时返回空，端口按端口号和协议去重
+func workloadService(workload models.Workload, options *WorkloadServiceOptions) (*corev1.Service, error) {
+	if options == nil {
+		return nil, nil
+	}
+	req := ServiceRequest{Type: options.Type, Selector: workload.Labels, Labels: workload.Labels}
+	switch workload.Kind {
+	case "StatefulSet":
+		// StatefulSet 的 serviceName 指向同名的 Headless Service
+		if req.Type == "" {
+			req.Type = utils.ServiceTypeHeadless
+		}
+		if req.Type != utils.ServiceTypeHeadless {
+			return nil, fmt.Errorf("service.type 对 StatefulSet 只支持 Headless")
+		}
+	case "Deployment":
+	default:
+		return nil, fmt.Errorf("只有 Deployment 和 StatefulSet 支持创建 Service")
+	}
+	if len(workload.Labels) == 0 {
+		return nil, fmt.Errorf("service 需要使用 selector 中的标签选择 Pod")
+	}
+	if errs := validation.IsDNS1035Label(workload.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("service 名称无效 %q: %s", workload.Name, strings.Join(errs, "; "))
+	}
+
+	seen := make(map[string]bool)
+	for _, container := range workload.Containers {
+		for _, port := range container.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = string(corev1.ProtocolTCP)
+			}
+			key := fmt.Sprintf("%d/%s", port.ContainerPort, protocol)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			name := port.Name
+			if name == "" {
+				name = fmt.Sprintf("%s-%d", strings.ToLower(protocol), port.ContainerPort)
+			}
+			req.Ports = append(req.Ports, models.ServicePort{
+				Name:       name,
+				Protocol:   protocol,
+				Port:       port.ContainerPort,
+				TargetPort: intstr.FromInt32(port.ContainerPort),
+			})
+		}
+	}
+	if err := validateServiceRequest(req); err != nil {
+		return nil, fmt.Errorf("service 的 %v", err)
+	}
+	return buildService(workload.Namespace, workload.Name, req, nil), nil
+}
+
+// validateWorkloadService 在创建工作负载前试运行创建 Service，名称冲突、NodePort 无效或超出配额时不创建工作负载
+func validateWorkloadService(ctx context.Context, clientset kubernetes.Interface, service *corev1.Service) error {
+	_, err := clientset.CoreV1().Services(service.Namespace).Create(ctx, service.DeepCopy(), metav1.CreateOptions{DryRun: utils.DryRunOptions(true)})
+	return err
+}
+
+// createWorkloadService 创建工作负载的 Service，Service 由工作负载管理，删除工作负载时一同删除
+// 创建失败时删除刚创建的工作负载，避免留下无法访问的工作负载
+func createWorkloadService(ctx context.Context, clientset kubernetes.Interface, service *corev1.Service, owner runtime.Object, kind string, dryRun bool) (*corev1.Service, error) {
+	accessor, err := meta.Accessor(owner)
+	if err != nil {
+		return nil, err
+	}
+	// 试运行返回的对象没有 UID
+	if accessor.GetUID() != "" {
+		service.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(accessor, appsv1.SchemeGroupVersion.WithKind(kind))}
+	}
+
+	created, err := clientset.CoreV1().Services(service.Namespace).Create(ctx, service, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
+	if err == nil || dryRun {
+		return created, err
+	}
+
+	opts := metav1.DeleteOptions{PropagationPolicy: &backgroundPropagation}
+	var deleteErr error
+	switch kind {
+	case "Deployment":
+		deleteErr = clientset.AppsV1().Deployments(service.Namespace).Delete(ctx, accessor.GetName(), opts)
+	case "StatefulSet":
+		deleteErr = clientset.AppsV1().StatefulSets(service.Namespace).Delete(ctx, accessor.GetName(), opts)
+	}
+	if deleteErr != nil {
+		return nil, fmt.Errorf("%w，删除已创建的 %s 失败: %v", err, kind, deleteErr)
+	}
+	return nil, err
+}
@@ -0,0 +1,283 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestServiceCRUD(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/services", cluster.ID)
+
+	getService := func(name string) *corev1.Service {
+		t.Helper()
+		service, err := clientset.CoreV1().Services("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("获取 Service 失败: %v", err)
+		}
+		return service
+	}
+
+	// 多个端口时名称必填
+	if w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{
+		"name": "web", "type": "NodePort", "selector": gin.H{"app": "web"},
+		"ports": []gin.H{{"port": 80}, {"port": 443}},
+	}); w.Code != http.StatusBadRequest {
+		t.Fatalf("端口缺少名称期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{
+		"name": "web", "type": "ClusterIP", "ports": []gin.H{{"port": 80, "node_port": 30080}},
+	}); w.Code != http.StatusBadRequest {
+		t.Fatalf("ClusterIP 指定 node_port 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{
+		"name": "web", "type": "NodePort", "selector": gin.H{"app": "web"},
+		"ports": []gin.H{{"name": "http", "port": 80, "target_port": "http", "node_port": 30080}, {"name": "metrics", "port": 9090}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 Service 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	service := getService("web")
+	if service.Spec.Type != corev1.ServiceTypeNodePort || service.Spec.Ports[0].TargetPort.StrVal != "http" ||
+		service.Spec.Ports[1].TargetPort.IntVal != 9090 || service.Spec.Ports[1].Protocol != corev1.ProtocolTCP {
+		t.Fatalf("Service 不符合预期: %+v", service.Spec)
+	}
+
+	// 更新时未指定的 NodePort 沿用已分配的端口
+	if w := doRequest(t, r, http.MethodPut, base+"/default/web", gin.H{
+		"type": "NodePort", "selector": gin.H{"app": "web", "tier": "frontend"},
+		"ports": []gin.H{{"name": "http", "port": 80, "target_port": "http"}},
+	}); w.Code != http.StatusOK {
+		t.Fatalf("更新 Service 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	service = getService("web")
+	if len(service.Spec.Ports) != 1 || service.Spec.Ports[0].NodePort != 30080 || service.Spec.Selector["tier"] != "frontend" {
+		t.Fatalf("更新后的 Service 不符合预期: %+v", service.Spec)
+	}
+
+	if w := doRequest(t, r, http.MethodPost, base+"/default", gin.H{"name": "db", "type": "Headless", "selector": gin.H{"app": "db"}}); w.Code != http.StatusCreated {
+		t.Fatalf("创建 Headless Service 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if service := getService("db"); service.Spec.ClusterIP != corev1.ClusterIPNone || utils.ServiceType(service) != utils.ServiceTypeHeadless {
+		t.Fatalf("Headless Service 不符合预期: %+v", service.Spec)
+	}
+
+	w = doRequest(t, r, http.MethodGet, base+"?namespace=default", nil)
+	var list struct {
+		Services []corev1.Service `json:"services"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(list.Services) != 2 || list.Services[0].Name != "db" || list.Services[1].Name != "web" {
+		t.Fatalf("Service 列表不符合预期: %s", w.Body.String())
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/default/web", nil); w.Code != http.StatusOK {
+		t.Fatalf("删除 Service 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodGet, base+"/default/web", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("获取已删除的 Service 期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestIngressCRUD(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/ingresses", cluster.ID)
+
+	request := func(path string) gin.H {
+		return gin.H{
+			"name":       "web",
+			"class_name": "nginx",
+			"rules": []gin.H{{
+				"host":  "example.com",
+				"paths": []gin.H{{"path": path, "service_name": "web", "service_port": 80}},
+			}},
+			"tls": []gin.H{{"hosts": []string{"example.com"}, "secret_name": "example-tls"}},
+		}
+	}
+
+	if w := doRequest(t, r, http.MethodPost, base+"/default", request("api")); w.Code != http.StatusBadRequest {
+		t.Fatalf("路径不以 / 开头期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/default", request("/")); w.Code != http.StatusCreated {
+		t.Fatalf("创建 Ingress 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	ingress, err := clientset.NetworkingV1().Ingresses("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Ingress 失败: %v", err)
+	}
+	path := ingress.Spec.Rules[0].HTTP.Paths[0]
+	if *ingress.Spec.IngressClassName != "nginx" || *path.PathType != networkingv1.PathTypePrefix ||
+		path.Backend.Service.Name != "web" || path.Backend.Service.Port.Number != 80 || ingress.Spec.TLS[0].SecretName != "example-tls" {
+		t.Fatalf("Ingress 不符合预期: %+v", ingress.Spec)
+	}
+
+	update := request("/api")
+	update["rules"].([]gin.H)[0]["paths"].([]gin.H)[0]["service_port"] = "http"
+	delete(update, "tls")
+	if w := doRequest(t, r, http.MethodPut, base+"/default/web", update); w.Code != http.StatusOK {
+		t.Fatalf("更新 Ingress 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	ingress, _ = clientset.NetworkingV1().Ingresses("default").Get(context.Background(), "web", metav1.GetOptions{})
+	path = ingress.Spec.Rules[0].HTTP.Paths[0]
+	if path.Path != "/api" || path.Backend.Service.Port.Name != "http" || len(ingress.Spec.TLS) != 0 {
+		t.Fatalf("更新后的 Ingress 不符合预期: %+v", ingress.Spec)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/default/web", nil); w.Code != http.StatusOK {
+		t.Fatalf("删除 Ingress 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if _, err := clientset.NetworkingV1().Ingresses("default").Get(context.Background(), "web", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Ingress 应已删除: %v", err)
+	}
+}
+
+func TestCreateWorkloadWithService(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	// fake 客户端不会为对象分配 UID
+	clientset.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		action.(k8stesting.CreateAction).GetObject().(*appsv1.Deployment).UID = types.UID("deployment-uid")
+		return false, nil, nil
+	})
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads", cluster.ID)
+
+	withService := func(name, serviceType string) gin.H {
+		request := deploymentRequest(name)
+		request["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []models.Container{{
+			Name: "nginx", Image: "nginx:1.25",
+			Ports: []models.ContainerPort{{Name: "http", ContainerPort: 80}, {ContainerPort: 53, Protocol: "UDP"}},
+		}}}}
+		request["service"] = gin.H{"type": serviceType}
+		return request
+	}
+
+	w := doRequest(t, r, http.MethodPost, base+"/deployments/default", withService("web", "NodePort"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 Deployment 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Workload
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(created.Services) != 1 || created.Services[0].Type != "NodePort" {
+		t.Fatalf("响应中的 Service 不符合预期: %+v", created.Services)
+	}
+	service, err := clientset.CoreV1().Services("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Service 失败: %v", err)
+	}
+	owner := metav1.GetControllerOf(service)
+	if owner == nil || owner.Kind != "Deployment" || owner.UID != "deployment-uid" {
+		t.Fatalf("Service 应由 Deployment 管理: %+v", service.OwnerReferences)
+	}
+	if service.Spec.Selector["app"] != "web" || len(service.Spec.Ports) != 2 ||
+		service.Spec.Ports[0].Name != "http" || service.Spec.Ports[1].Name != "udp-53" || service.Spec.Ports[1].Protocol != corev1.ProtocolUDP {
+		t.Fatalf("Service 不符合预期: %+v", service.Spec)
+	}
+
+	// StatefulSet 创建 serviceName 指向的 Headless Service
+	if w := doRequest(t, r, http.MethodPost, base+"/statefulsets/default", withService("db", "NodePort")); w.Code != http.StatusBadRequest {
+		t.Fatalf("StatefulSet 使用 NodePort 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/statefulsets/default", withService("db", "")); w.Code != http.StatusCreated {
+		t.Fatalf("创建 StatefulSet 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	statefulSet, _ := clientset.AppsV1().StatefulSets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	headless, err := clientset.CoreV1().Services("default").Get(context.Background(), statefulSet.Spec.ServiceName, metav1.GetOptions{})
+	if err != nil || headless.Spec.ClusterIP != corev1.ClusterIPNone {
+		t.Fatalf("StatefulSet 的 Headless Service 不符合预期: %v %+v", err, headless)
+	}
+
+	// 试运行预览中包含将要创建的 Service
+	w = doRequest(t, r, http.MethodPost, base+"/deployments/default?dryRun=true", withService("preview", "ClusterIP"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("试运行期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var preview struct {
+		Service *corev1.Service `json:"service"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil || preview.Service == nil || preview.Service.Name != "preview" {
+		t.Fatalf("试运行预览应包含 Service: %v %s", err, w.Body.String())
+	}
+	if _, err := clientset.CoreV1().Services("default").Get(context.Background(), "preview", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("试运行不应创建 Service: %v", err)
+	}
+
+	// Service 无法创建时不创建工作负载
+	if w := doRequest(t, r, http.MethodPost, base+"/deployments/default", withService("web", "ClusterIP")); w.Code != http.StatusConflict {
+		t.Fatalf("Deployment 已存在期望 409，实际 %d: %s", w.Code, w.Body.String())
+	}
+	clientset.CoreV1().Services("default").Create(context.Background(), &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}}, metav1.CreateOptions{})
+	clientset.ClearActions()
+	if w := doRequest(t, r, http.MethodPost, base+"/deployments/default", withService("api", "ClusterIP")); w.Code != http.StatusConflict {
+		t.Fatalf("Service 已存在期望 409，实际 %d: %s", w.Code, w.Body.String())
+	}
+	for _, action := range clientset.Actions() {
+		if action.Matches("create", "deployments") {
+			t.Fatalf("Service 无法创建时不应创建 Deployment: %+v", action)
+		}
+	}
+	var count int64
+	db.Model(&models.Workload{}).Where("cluster_id = ? AND name = ?", cluster.ID, "api").Count(&count)
+	if count != 0 {
+		t.Fatal("Service 创建失败时不应写入工作负载记录")
+	}
+}
+
+func TestGetWorkloadServices(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	service := func(name string, selector map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Selector:  selector,
+				ClusterIP: "10.96.0.10",
+				Ports:     []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+	}
+	clients.AddClient(cluster.ID,
+		testDeployment("web", "nginx:1.25", 2),
+		service("web", map[string]string{"app": "web"}),
+		service("all", nil),
+		service("api", map[string]string{"app": "api"}),
+	)
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID})
+
+	w := doRequest(t, r, http.MethodGet, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var workload models.Workload
+	if err := json.Unmarshal(w.Body.Bytes(), &workload); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(workload.Services) != 1 || workload.Services[0].Name != "web" || workload.Services[0].ClusterIP != "10.96.0.10" ||
+		workload.Services[0].Ports[0].Port != 80 {
+		t.Fatalf("选择工作负载的 Service 不符合预期: %+v", workload.Services)
+	}
+}
@@ -1,21 +1,40 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kbsonlong/kaiops/models"
 	"github.com/kbsonlong/kaiops/utils"
 	"gorm.io/gorm"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
 type WorkloadController struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Clients utils.ClientProvider
 }
 
 type WorkloadRequest struct {
@@ -28,6 +47,7 @@ type WorkloadRequest struct {
 		Template struct {
 			Spec struct {
 				Containers []models.Container `json:"containers"`
+				Volumes    []models.Volume    `json:"volumes"`
 			} `json:"spec"`
 		} `json:"template"`
 		Selector struct {
@@ -36,7 +56,13 @@ type WorkloadRequest struct {
 				Value string `json:"value"`
 			} `json:"matchLabels"`
 		} `json:"selector"`
+		// 持久卷声明模板，只用于 StatefulSet
+		VolumeClaimTemplates []models.VolumeClaimTemplate `json:"volumeClaimTemplates"`
+		// Job 和 CronJob 的配置
+		Batch *models.BatchSpec `json:"batch"`
 	} `json:"spec"`
+	// 根据容器端口创建同名 Service，只用于 Deployment 和 StatefulSet
+	Service *WorkloadServiceOptions `json:"service,omitempty"`
 }
 
 // CreateDeployment godoc
@@ -48,8 +74,11 @@ type WorkloadRequest struct {
 // @Param        clusterId path int true "集群ID"
 // @Param        namespace path string true "命名空间"
 // @Param        deployment body models.Workload true "Deployment信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace} [post]
 func (w *WorkloadController) CreateWorkload(ctx *gin.Context) {
@@ -57,28 +86,50 @@ func (w *WorkloadController) CreateWorkload(ctx *gin.Context) {
 	namespace := ctx.Param("namespace")
 	kind := ctx.Param("kind")
 
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+
 	// 使用全局定义的请求结构体
 	var request WorkloadRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateContainers(request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes(kind, request.Spec.Template.Spec.Volumes, request.Spec.VolumeClaimTemplates, request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBatch(kind, request.Spec.Batch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 转换为 Workload 对象
 	workload := models.Workload{
-		Kind:       kind,
-		Name:       request.Metadata.Name,
-		Namespace:  namespace,
-		ClusterID:  uint(clusterId),
-		Containers: request.Spec.Template.Spec.Containers,
+		Kind:                 kind,
+		Name:                 request.Metadata.Name,
+		Namespace:            namespace,
+		ClusterID:            uint(clusterId),
+		Containers:           request.Spec.Template.Spec.Containers,
+		Volumes:              request.Spec.Template.Spec.Volumes,
+		VolumeClaimTemplates: request.Spec.VolumeClaimTemplates,
+		Batch:                request.Spec.Batch,
 	}
 
-	// 处理 Labels
+	// 处理 Labels，选择器和 Pod 模板标签与对象标签相同
 	labels := make(map[string]string)
 	for _, label := range request.Spec.Selector.MatchLabels {
 		labels[label.Key] = label.Value
 	}
 	workload.Labels = labels
+	workload.Selector = labels
+	workload.TemplateLabels = labels
 
 	// fmt.Println(ctx.Request.Body)
 
@@ -95,35 +146,41 @@ func (w *WorkloadController) CreateWorkload(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取 Kubernetes 客户端
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// 根据工作负载类型创建资源
+	var created runtime.Object
+	opts := metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)}
 	switch workload.Kind {
 	case "Deployment":
 		deployment := createDeployment(workload)
-		_, err = clientset.AppsV1().Deployments(workload.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().Deployments(workload.Namespace).Create(ctx, deployment, opts)
 	case "StatefulSet":
 		statefulSet := createStatefulSet(workload)
-		_, err = clientset.AppsV1().StatefulSets(workload.Namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().StatefulSets(workload.Namespace).Create(ctx, statefulSet, opts)
 	case "DaemonSet":
 		daemonSet := createDaemonSet(workload)
-		_, err = clientset.AppsV1().DaemonSets(workload.Namespace).Create(ctx, daemonSet, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().DaemonSets(workload.Namespace).Create(ctx, daemonSet, opts)
+	case "Job":
+		created, err = clientset.BatchV1().Jobs(workload.Namespace).Create(ctx, createJob(workload), opts)
+	case "CronJob":
+		created, err = clientset.BatchV1().CronJobs(workload.Namespace).Create(ctx, createCronJob(workload), opts)
 	default:
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "不支持的工作负载类型"})
 		return
 	}
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
 		return
 	}
 
@@ -138,27 +195,50 @@ func (w *WorkloadController) CreateWorkload(ctx *gin.Context) {
 
 // CreateDeployment godoc
 // @Summary      创建Deployment工作负载
-// @Description  在指定集群和命名空间中创建新的Deployment
+// @Description  在指定集群和命名空间中创建新的Deployment，指定 service 时根据容器端口创建同名 Service。
+// @Description  createNamespace 为 true 时命名空间不存在则先创建，Deployment 创建失败时删除新建的命名空间
 // @Tags         workloads
 // @Accept       json
 // @Produce      json
 // @Param        clusterId path int true "集群ID"
 // @Param        namespace path string true "命名空间"
 // @Param        deployment body models.Workload true "Deployment信息"
+// @Param        createNamespace query bool false "命名空间不存在时创建。试运行不会创建命名空间，命名空间不存在时 API Server 会拒绝试运行"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/deployments/{namespace} [post]
 func (w *WorkloadController) CreateDeployment(ctx *gin.Context) {
 	clusterId, _ := strconv.Atoi(ctx.Param("id"))
 	namespace := ctx.Param("namespace")
 
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	createNamespace, err := parseBoolQuery(ctx, "createNamespace")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 使用全局定义的请求结构体
 	var request WorkloadRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateContainers(request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes("Deployment", request.Spec.Template.Spec.Volumes, request.Spec.VolumeClaimTemplates, request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 转换为 Workload 对象
 	workload := models.Workload{
@@ -167,14 +247,17 @@ func (w *WorkloadController) CreateDeployment(ctx *gin.Context) {
 		Namespace:  namespace,
 		ClusterID:  uint(clusterId),
 		Containers: request.Spec.Template.Spec.Containers,
+		Volumes:    request.Spec.Template.Spec.Volumes,
 	}
 
-	// 处理 Labels
+	// 处理 Labels，选择器和 Pod 模板标签与对象标签相同
 	labels := make(map[string]string)
 	for _, label := range request.Spec.Selector.MatchLabels {
 		labels[label.Key] = label.Value
 	}
 	workload.Labels = labels
+	workload.Selector = labels
+	workload.TemplateLabels = labels
 
 	fmt.Println(ctx.Request.Body)
 
@@ -184,6 +267,12 @@ func (w *WorkloadController) CreateDeployment(ctx *gin.Context) {
 	workload.Namespace = namespace
 	fmt.Println(clusterId, namespace, workload)
 
+	service, err := workloadService(workload, request.Service)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取集群信息
 	var cluster models.Cluster
 	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
@@ -191,35 +280,70 @@ func (w *WorkloadController) CreateDeployment(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 获取 Kubernetes 客户端
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 按需创建命名空间
+	namespaceCreated := false
+	if createNamespace {
+		if namespaceCreated, err = utils.EnsureNamespace(ctx, clientset, namespace, dryRun); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
+	// 先试运行创建 Service，Service 无法创建时不创建工作负载
+	if service != nil && !dryRun {
+		if err := validateWorkloadService(ctx, clientset, service); err != nil {
+			if namespaceCreated {
+				err = deleteCreatedNamespace(ctx, clientset, namespace, err)
+			}
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
 	// 根据工作负载类型创建资源
+	var created runtime.Object
+	opts := metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)}
 	switch workload.Kind {
 	case "Deployment":
 		deployment := createDeployment(workload)
-		_, err = clientset.AppsV1().Deployments(workload.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().Deployments(workload.Namespace).Create(ctx, deployment, opts)
 	case "StatefulSet":
 		statefulSet := createStatefulSet(workload)
-		_, err = clientset.AppsV1().StatefulSets(workload.Namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().StatefulSets(workload.Namespace).Create(ctx, statefulSet, opts)
 	case "DaemonSet":
 		daemonSet := createDaemonSet(workload)
-		_, err = clientset.AppsV1().DaemonSets(workload.Namespace).Create(ctx, daemonSet, metav1.CreateOptions{})
+		created, err = clientset.AppsV1().DaemonSets(workload.Namespace).Create(ctx, daemonSet, opts)
+	case "Job":
+		created, err = clientset.BatchV1().Jobs(workload.Namespace).Create(ctx, createJob(workload), opts)
+	case "CronJob":
+		created, err = clientset.BatchV1().CronJobs(workload.Namespace).Create(ctx, createCronJob(workload), opts)
 	default:
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "不支持的工作负载类型"})
 		return
 	}
 
+	var createdService *corev1.Service
+	if err == nil && service != nil {
+		if createdService, err = createWorkloadService(ctx, clientset, service, created, workload.Kind, dryRun); err == nil {
+			workload.Services = []models.ServiceRef{utils.NewServiceRef(createdService)}
+		}
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if namespaceCreated && !dryRun {
+			err = deleteCreatedNamespace(ctx, clientset, namespace, err)
+		}
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writeCreatePreview(ctx, created, createdService)
 		return
 	}
 
@@ -234,7 +358,7 @@ func (w *WorkloadController) CreateDeployment(ctx *gin.Context) {
 
 // GetWorkload godoc
 // @Summary      获取工作负载详情
-// @Description  根据集群ID、类型、命名空间和名称获取工作负载详情
+// @Description  根据集群ID、类型、命名空间和名称获取工作负载详情，包含集群中的状态和选择该工作负载 Pod 的 Service
 // @Tags         workloads
 // @Accept       json
 // @Produce      json
@@ -269,28 +393,282 @@ func (w *WorkloadController) GetWorkload(ctx *gin.Context) {
 		return
 	}
 
-	// 获取工作负载状态
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	// 从集群资源缓存中获取工作负载状态
+	clusterCache, err := syncedCache(ctx, w.Clients, cluster, utils.WorkloadResource(workload.Kind), utils.ResourceServices)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	status, err := cachedWorkloadStatus(clusterCache, workload.Kind, workload.Namespace, workload.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群中不存在该工作负载"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	workload.Status = status
+
+	// 选择该工作负载 Pod 的 Service
+	if workload.Services, err = utils.WorkloadServices(clusterCache, workload.Kind, workload.Namespace, workload.Name); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workload)
+}
+
+// SyncWorkloadsRequest 从集群导入工作负载的请求
+type SyncWorkloadsRequest struct {
+	// 要同步的命名空间，为空时同步所有命名空间
+	Namespaces []string `json:"namespaces" example:"default,kube-system"`
+}
+
+// SyncWorkloads godoc
+// @Summary      从集群导入工作负载
+// @Description  读取集群中的 Deployment、StatefulSet、DaemonSet、Job 和 CronJob 并写入工作负载记录，CronJob 创建的 Job 不单独记录，删除同步范围内集群中已不存在的记录，可重复执行
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        request body SyncWorkloadsRequest false "同步范围"
+// @Success      200 {object} utils.SyncResult "同步结果"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Failure      503 {object} map[string]string "集群资源缓存同步中"
+// @Router       /api/v1/clusters/{id}/workloads/sync [post]
+func (w *WorkloadController) SyncWorkloads(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+
+	var req SyncWorkloadsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cluster models.Cluster
+	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterCache, err := syncedCache(ctx, w.Clients, cluster, utils.WorkloadResources()...)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := utils.SyncClusterWorkloads(ctx, w.DB, clusterCache, cluster.ID, req.Namespaces)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 更新工作负载状态
-	switch workload.Kind {
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetWorkloadDrift godoc
+// @Summary      获取工作负载漂移
+// @Description  将集群中所有工作负载记录与实际对象对比，返回镜像、副本数、标签或环境变量不一致以及对象已不存在的工作负载
+// @Tags         workloads
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Success      200 {object} map[string]interface{} "对比结果"
+// @Failure      404 {object} map[string]string "集群不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Failure      503 {object} map[string]string "集群资源缓存同步中"
+// @Router       /api/v1/clusters/{id}/workloads/drift [get]
+func (w *WorkloadController) GetWorkloadDrift(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+
+	var cluster models.Cluster
+	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 对比结果来自资源缓存，每次请求时重新对比开销很小
+	reconciler := utils.NewWorkloadReconciler(w.DB, w.Clients, 0)
+	reconciler.SyncTimeout = cacheSyncTimeout
+	result, err := reconciler.ReconcileCluster(ctx, cluster)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var workloads []models.Workload
+	if err := w.DB.Where("cluster_id = ?", cluster.ID).Order("id").Find(&workloads).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	drifted := make([]models.Workload, 0)
+	for _, workload := range workloads {
+		if workload.Missing || len(workload.Drift) > 0 {
+			drifted = append(drifted, workload)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"result":    result,
+		"workloads": drifted,
+	})
+}
+
+// GetWorkloadPods godoc
+// @Summary      获取工作负载的 Pod 列表
+// @Description  从集群的 informer 缓存中获取工作负载选择器匹配的 Pod
+// @Tags         workloads
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Success      200 {object} map[string]interface{} "获取成功"
+// @Failure      400 {object} map[string]string "不支持的工作负载类型"
+// @Failure      404 {object} map[string]string "工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Failure      503 {object} map[string]string "集群资源缓存同步中"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/pods [get]
+func (w *WorkloadController) GetWorkloadPods(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+	kind := ctx.Param("kind")
+	namespace := ctx.Param("namespace")
+	name := ctx.Param("name")
+
+	var cluster models.Cluster
+	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// CronJob 的 Pod 通过其创建的 Job 查找
+	resources := []string{utils.WorkloadResource(kind), utils.ResourcePods}
+	if kind == "CronJob" {
+		resources = append(resources, utils.ResourceJobs)
+	}
+	clusterCache, err := syncedCache(ctx, w.Clients, cluster, resources...)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var selector *metav1.LabelSelector
+	var podSelector labels.Selector
+	switch kind {
 	case "Deployment":
-		deployment, err := clientset.AppsV1().Deployments(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
-		if err != nil {
+		deployment, getErr := clusterCache.Deployments().Deployments(namespace).Get(name)
+		if getErr == nil {
+			selector = deployment.Spec.Selector
+		}
+		err = getErr
+	case "StatefulSet":
+		statefulSet, getErr := clusterCache.StatefulSets().StatefulSets(namespace).Get(name)
+		if getErr == nil {
+			selector = statefulSet.Spec.Selector
+		}
+		err = getErr
+	case "DaemonSet":
+		daemonSet, getErr := clusterCache.DaemonSets().DaemonSets(namespace).Get(name)
+		if getErr == nil {
+			selector = daemonSet.Spec.Selector
+		}
+		err = getErr
+	case "Job":
+		job, getErr := clusterCache.Jobs().Jobs(namespace).Get(name)
+		if getErr == nil {
+			selector = job.Spec.Selector
+		}
+		err = getErr
+	case "CronJob":
+		// CronJob 的 Pod 属于其创建的 Job，按 Job 名称标签选择
+		cronJob, getErr := clusterCache.CronJobs().CronJobs(namespace).Get(name)
+		if getErr == nil {
+			podSelector, err = cronJobPodSelector(clusterCache, cronJob)
+		} else {
+			err = getErr
+		}
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "不支持的工作负载类型"})
+		return
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "工作负载不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if podSelector == nil {
+		if podSelector, err = metav1.LabelSelectorAsSelector(selector); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		workload.Status.DesiredReplicas = *deployment.Spec.Replicas
-		workload.Status.CurrentReplicas = deployment.Status.Replicas
-		workload.Status.ReadyReplicas = deployment.Status.ReadyReplicas
-		// 其他工作负载类型的状态更新...
+	}
+	pods, err := clusterCache.Pods().Pods(namespace).List(podSelector)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx.JSON(http.StatusOK, workload)
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	items := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		items = append(items, *pod)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"pods": items})
+}
+
+// cachedWorkloadStatus 从集群资源缓存中读取工作负载的副本状态
+func cachedWorkloadStatus(clusterCache *utils.ClusterCache, kind, namespace, name string) (models.WorkloadStatus, error) {
+	switch kind {
+	case "Deployment":
+		deployment, err := clusterCache.Deployments().Deployments(namespace).Get(name)
+		if err != nil {
+			return models.WorkloadStatus{}, err
+		}
+		return utils.DeploymentStatus(deployment), nil
+	case "StatefulSet":
+		statefulSet, err := clusterCache.StatefulSets().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return models.WorkloadStatus{}, err
+		}
+		return utils.StatefulSetStatus(statefulSet), nil
+	case "DaemonSet":
+		daemonSet, err := clusterCache.DaemonSets().DaemonSets(namespace).Get(name)
+		if err != nil {
+			return models.WorkloadStatus{}, err
+		}
+		return utils.DaemonSetStatus(daemonSet), nil
+	case "Job":
+		job, err := clusterCache.Jobs().Jobs(namespace).Get(name)
+		if err != nil {
+			return models.WorkloadStatus{}, err
+		}
+		return utils.JobStatus(job), nil
+	case "CronJob":
+		cronJob, err := clusterCache.CronJobs().CronJobs(namespace).Get(name)
+		if err != nil {
+			return models.WorkloadStatus{}, err
+		}
+		return utils.CronJobStatus(cronJob), nil
+	}
+	return models.WorkloadStatus{}, nil
 }
 
 // ListWorkloads godoc
@@ -348,6 +726,8 @@ func (w *WorkloadController) ListWorkloads(ctx *gin.Context) {
 		"deployments":  make([]map[string]interface{}, 0),
 		"statefulSets": make([]map[string]interface{}, 0),
 		"daemonSets":   make([]map[string]interface{}, 0),
+		"jobs":         make([]map[string]interface{}, 0),
+		"cronJobs":     make([]map[string]interface{}, 0),
 	}
 
 	// 将工作负载按类型分类
@@ -359,6 +739,10 @@ func (w *WorkloadController) ListWorkloads(ctx *gin.Context) {
 			result["statefulSets"] = append(result["statefulSets"], w)
 		case "DaemonSet":
 			result["daemonSets"] = append(result["daemonSets"], w)
+		case "Job":
+			result["jobs"] = append(result["jobs"], w)
+		case "CronJob":
+			result["cronJobs"] = append(result["cronJobs"], w)
 		}
 	}
 
@@ -374,43 +758,69 @@ func (w *WorkloadController) ListWorkloads(ctx *gin.Context) {
 
 // CreateStatefulSet godoc
 // @Summary      创建StatefulSet工作负载
-// @Description  在指定集群和命名空间中创建新的StatefulSet
+// @Description  在指定集群和命名空间中创建新的StatefulSet，指定 service 时创建 serviceName 指向的同名 Headless Service
 // @Tags         workloads
 // @Accept       json
 // @Produce      json
 // @Param        clusterId path int true "集群ID"
 // @Param        namespace path string true "命名空间"
 // @Param        statefulSet body models.Workload true "StatefulSet信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/statefulsets/{namespace} [post]
 func (w *WorkloadController) CreateStatefulSet(ctx *gin.Context) {
 	clusterId, _ := strconv.Atoi(ctx.Param("id"))
 	namespace := ctx.Param("namespace")
 
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+
 	// 使用全局定义的请求结构体
 	var request WorkloadRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateContainers(request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes("StatefulSet", request.Spec.Template.Spec.Volumes, request.Spec.VolumeClaimTemplates, request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 转换为 Workload 对象
 	statefulSet := models.Workload{
-		Kind:       "StatefulSet",
-		Name:       request.Metadata.Name,
-		Namespace:  namespace,
-		ClusterID:  uint(clusterId),
-		Containers: request.Spec.Template.Spec.Containers,
+		Kind:                 "StatefulSet",
+		Name:                 request.Metadata.Name,
+		Namespace:            namespace,
+		ClusterID:            uint(clusterId),
+		Containers:           request.Spec.Template.Spec.Containers,
+		Volumes:              request.Spec.Template.Spec.Volumes,
+		VolumeClaimTemplates: request.Spec.VolumeClaimTemplates,
 	}
 
-	// 处理 Labels
+	// 处理 Labels，选择器和 Pod 模板标签与对象标签相同
 	labels := make(map[string]string)
 	for _, label := range request.Spec.Selector.MatchLabels {
 		labels[label.Key] = label.Value
 	}
 	statefulSet.Labels = labels
+	statefulSet.Selector = labels
+	statefulSet.TemplateLabels = labels
+
+	service, err := workloadService(statefulSet, request.Service)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 获取集群信息
 	var cluster models.Cluster
@@ -419,21 +829,36 @@ func (w *WorkloadController) CreateStatefulSet(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	// 获取 Kubernetes 客户端
 	statefulSetObj := createStatefulSet(statefulSet)
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	_, err = clientset.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSetObj, metav1.CreateOptions{})
+	// 先试运行创建 Service，Service 无法创建时不创建工作负载
+	if service != nil && !dryRun {
+		if err := validateWorkloadService(ctx, clientset, service); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
+	created, err := clientset.AppsV1().StatefulSets(statefulSet.Namespace).Create(ctx, statefulSetObj, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeKubeError(ctx, err)
+		return
+	}
+	if service != nil {
+		if service, err = createWorkloadService(ctx, clientset, service, created, "StatefulSet", dryRun); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+		statefulSet.Services = []models.ServiceRef{utils.NewServiceRef(service)}
+	}
+	if dryRun {
+		writeCreatePreview(ctx, created, service)
 		return
 	}
 
@@ -455,20 +880,36 @@ func (w *WorkloadController) CreateStatefulSet(ctx *gin.Context) {
 // @Param        clusterId path int true "集群ID"
 // @Param        namespace path string true "命名空间"
 // @Param        statefulSet body models.Workload true "StatefulSet信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/statefulsets/{namespace} [post]
 func (w *WorkloadController) CreateDaemonSet(ctx *gin.Context) {
 	clusterId, _ := strconv.Atoi(ctx.Param("id"))
 	namespace := ctx.Param("namespace")
 
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+
 	// 使用全局定义的请求结构体
 	var request WorkloadRequest
 	if err := ctx.ShouldBindJSON(&request); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateContainers(request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes("DaemonSet", request.Spec.Template.Spec.Volumes, request.Spec.VolumeClaimTemplates, request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 转换为 Workload 对象
 	daemonSet := models.Workload{
@@ -477,14 +918,17 @@ func (w *WorkloadController) CreateDaemonSet(ctx *gin.Context) {
 		Namespace:  namespace,
 		ClusterID:  uint(clusterId),
 		Containers: request.Spec.Template.Spec.Containers,
+		Volumes:    request.Spec.Template.Spec.Volumes,
 	}
 
-	// 处理 Labels
+	// 处理 Labels，选择器和 Pod 模板标签与对象标签相同
 	labels := make(map[string]string)
 	for _, label := range request.Spec.Selector.MatchLabels {
 		labels[label.Key] = label.Value
 	}
 	daemonSet.Labels = labels
+	daemonSet.Selector = labels
+	daemonSet.TemplateLabels = labels
 
 	// 获取集群信息
 	var cluster models.Cluster
@@ -493,21 +937,21 @@ func (w *WorkloadController) CreateDaemonSet(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
+	// 获取 Kubernetes 客户端
 	daemonSetObj := createDaemonSet(daemonSet)
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	_, err = clientset.AppsV1().DaemonSets(daemonSet.Namespace).Create(ctx, daemonSetObj, metav1.CreateOptions{})
+	created, err := clientset.AppsV1().DaemonSets(daemonSet.Namespace).Create(ctx, daemonSetObj, metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
 		return
 	}
 
@@ -520,20 +964,142 @@ func (w *WorkloadController) CreateDaemonSet(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, daemonSet)
 }
 
-// UpdateWorkload godoc
-// @Summary      更新工作负载
-// @Description  更新指定集群中的工作负载
+// CreateJob godoc
+// @Summary      创建Job工作负载
+// @Description  在指定集群和命名空间中创建新的Job，Pod 的重启策略默认为 OnFailure
 // @Tags         workloads
 // @Accept       json
 // @Produce      json
 // @Param        clusterId path int true "集群ID"
-// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        job body WorkloadRequest true "Job信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/jobs/{namespace} [post]
+func (w *WorkloadController) CreateJob(ctx *gin.Context) {
+	w.createBatchWorkload(ctx, "Job")
+}
+
+// CreateCronJob godoc
+// @Summary      创建CronJob工作负载
+// @Description  在指定集群和命名空间中创建新的CronJob，batch.schedule 必填
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        clusterId path int true "集群ID"
+// @Param        namespace path string true "命名空间"
+// @Param        cronJob body WorkloadRequest true "CronJob信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} models.Workload "创建成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/cronjobs/{namespace} [post]
+func (w *WorkloadController) CreateCronJob(ctx *gin.Context) {
+	w.createBatchWorkload(ctx, "CronJob")
+}
+
+// createBatchWorkload 创建请求路径命名空间中的 Job 或 CronJob
+func (w *WorkloadController) createBatchWorkload(ctx *gin.Context, kind string) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+	namespace := ctx.Param("namespace")
+
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var request WorkloadRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateContainers(request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes(kind, request.Spec.Template.Spec.Volumes, request.Spec.VolumeClaimTemplates, request.Spec.Template.Spec.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBatch(kind, request.Spec.Batch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workload := models.Workload{
+		Kind:       kind,
+		Name:       request.Metadata.Name,
+		Namespace:  namespace,
+		ClusterID:  uint(clusterId),
+		Labels:     request.Metadata.Labels,
+		Containers: request.Spec.Template.Spec.Containers,
+		Volumes:    request.Spec.Template.Spec.Volumes,
+		Batch:      request.Spec.Batch,
+	}
+	if workload.Batch == nil {
+		workload.Batch = &models.BatchSpec{}
+	}
+	// 与其他类型一致，选择器中的标签同时作为对象和 Pod 模板的标签
+	if len(request.Spec.Selector.MatchLabels) > 0 {
+		workload.Labels = make(map[string]string, len(request.Spec.Selector.MatchLabels))
+		for _, label := range request.Spec.Selector.MatchLabels {
+			workload.Labels[label.Key] = label.Value
+		}
+	}
+
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+
+	var created runtime.Object
+	var err error
+	opts := metav1.CreateOptions{DryRun: utils.DryRunOptions(dryRun)}
+	if kind == "Job" {
+		created, err = clientset.BatchV1().Jobs(namespace).Create(ctx, createJob(workload), opts)
+	} else {
+		created, err = clientset.BatchV1().CronJobs(namespace).Create(ctx, createCronJob(workload), opts)
+	}
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, created)
+		return
+	}
+
+	if err := w.DB.Create(&workload).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, workload)
+}
+
+// UpdateWorkload godoc
+// @Summary      更新工作负载
+// @Description  更新指定集群中的工作负载。Deployment、StatefulSet、DaemonSet 和 CronJob 只更新工作负载记录覆盖的字段，选择器和其他字段保持集群中的值
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        clusterId path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
 // @Param        namespace path string true "命名空间"
 // @Param        name path string true "工作负载名称"
 // @Param        workload body models.Workload true "更新的工作负载信息"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      200 {object} models.Workload "更新成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      400 {object} map[string]string "请求参数错误"
 // @Failure      404 {object} map[string]string "工作负载不存在"
+// @Failure      422 {object} map[string]interface{} "API Server 校验失败"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name} [put]
 func (w *WorkloadController) UpdateWorkload(ctx *gin.Context) {
@@ -541,6 +1107,10 @@ func (w *WorkloadController) UpdateWorkload(ctx *gin.Context) {
 	kind := ctx.Param("kind")
 	namespace := ctx.Param("namespace")
 	name := ctx.Param("name")
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
 
 	var workload models.Workload
 	if err := w.DB.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterId, kind, namespace, name).First(&workload).Error; err != nil {
@@ -556,6 +1126,18 @@ func (w *WorkloadController) UpdateWorkload(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateContainers(workload.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateVolumes(workload.Kind, workload.Volumes, workload.VolumeClaimTemplates, workload.Containers); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBatch(workload.Kind, workload.Batch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 获取集群信息
 	var cluster models.Cluster
@@ -564,32 +1146,44 @@ func (w *WorkloadController) UpdateWorkload(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
 	// 获取工作负载状态
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 试运行时读取当前对象用于对比
+	var live runtime.Object
+	if dryRun {
+		if live, err = utils.GetWorkloadObject(ctx, clientset, workload.Kind, workload.Namespace, workload.Name); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
+	var updated runtime.Object
+	opts := metav1.UpdateOptions{DryRun: utils.DryRunOptions(dryRun)}
 	switch workload.Kind {
-	case "Deployment":
-		deployment := createDeployment(workload)
-		_, err = clientset.AppsV1().Deployments(workload.Namespace).Update(ctx, deployment, metav1.UpdateOptions{})
-	case "StatefulSet":
-		statefulSet := createStatefulSet(workload)
-		_, err = clientset.AppsV1().StatefulSets(workload.Namespace).Update(ctx, statefulSet, metav1.UpdateOptions{})
-	case "DaemonSet":
-		daemonSet := createDaemonSet(workload)
-		_, err = clientset.AppsV1().DaemonSets(workload.Namespace).Update(ctx, daemonSet, metav1.UpdateOptions{})
+	case "Deployment", "StatefulSet", "DaemonSet":
+		updated, err = updateAppsWorkload(ctx, clientset, workload, opts)
+	case "Job":
+		// Job 的 Pod 模板和选择器创建后不能修改，只更新可变字段
+		var job *batchv1.Job
+		job, err = clientset.BatchV1().Jobs(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+		if err == nil {
+			updated, err = clientset.BatchV1().Jobs(workload.Namespace).Update(ctx, updateJob(job, workload), opts)
+		}
+	case "CronJob":
+		updated, err = updateCronJob(ctx, clientset, workload, opts)
 	}
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, updated)
 		return
 	}
 
@@ -612,7 +1206,9 @@ func (w *WorkloadController) UpdateWorkload(ctx *gin.Context) {
 // @Param        kind path string true "工作负载类型"
 // @Param        namespace path string true "命名空间"
 // @Param        name path string true "工作负载名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
 // @Success      200 {object} map[string]string "删除成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
 // @Failure      404 {object} map[string]string "工作负载不存在"
 // @Failure      500 {object} map[string]string "服务器内部错误"
 // @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name} [delete]
@@ -621,6 +1217,10 @@ func (w *WorkloadController) DeleteWorkload(ctx *gin.Context) {
 	kind := ctx.Param("kind")
 	namespace := ctx.Param("namespace")
 	name := ctx.Param("name")
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
 
 	var workload models.Workload
 	if err := w.DB.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterId, kind, namespace, name).First(&workload).Error; err != nil {
@@ -639,30 +1239,44 @@ func (w *WorkloadController) DeleteWorkload(ctx *gin.Context) {
 		return
 	}
 
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
 	// 删除 Kubernetes 资源
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+	clientset, err := w.Clients.GetClient(cluster)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	var live runtime.Object
+	if dryRun {
+		if live, err = utils.GetWorkloadObject(ctx, clientset, workload.Kind, workload.Namespace, workload.Name); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
+	opts := metav1.DeleteOptions{DryRun: utils.DryRunOptions(dryRun)}
 	switch workload.Kind {
 	case "Deployment":
-		err = clientset.AppsV1().Deployments(workload.Namespace).Delete(ctx, workload.Name, metav1.DeleteOptions{})
+		err = clientset.AppsV1().Deployments(workload.Namespace).Delete(ctx, workload.Name, opts)
 	case "StatefulSet":
-		err = clientset.AppsV1().StatefulSets(workload.Namespace).Delete(ctx, workload.Name, metav1.DeleteOptions{})
+		err = clientset.AppsV1().StatefulSets(workload.Namespace).Delete(ctx, workload.Name, opts)
 	case "DaemonSet":
-		err = clientset.AppsV1().DaemonSets(workload.Namespace).Delete(ctx, workload.Name, metav1.DeleteOptions{})
+		err = clientset.AppsV1().DaemonSets(workload.Namespace).Delete(ctx, workload.Name, opts)
+	case "Job":
+		// Job 默认不级联删除 Pod，与 kubectl delete 一致在后台删除
+		opts.PropagationPolicy = &backgroundPropagation
+		err = clientset.BatchV1().Jobs(workload.Namespace).Delete(ctx, workload.Name, opts)
+	case "CronJob":
+		opts.PropagationPolicy = &backgroundPropagation
+		err = clientset.BatchV1().CronJobs(workload.Namespace).Delete(ctx, workload.Name, opts)
 	}
 
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, nil)
 		return
 	}
 
@@ -672,9 +1286,6 @@ func (w *WorkloadController) DeleteWorkload(ctx *gin.Context) {
 		return
 	}
 
-	// 清理客户端连接
-	utils.RemoveKubernetesClient(cluster.ID)
-
 	ctx.JSON(http.StatusOK, gin.H{"message": "工作负载删除成功"})
 }
 
@@ -691,15 +1302,13 @@ func createDeployment(workload models.Workload) *appsv1.Deployment {
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: workload.Labels,
+				MatchLabels: workloadSelector(workload),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: workload.Labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: convertContainers(workload.Containers),
+					Labels: workloadTemplateLabels(workload),
 				},
+				Spec: podSpec(workload),
 			},
 		},
 	}
@@ -718,17 +1327,16 @@ func createStatefulSet(workload models.Workload) *appsv1.StatefulSet {
 		Spec: appsv1.StatefulSetSpec{
 			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
-				MatchLabels: workload.Labels,
+				MatchLabels: workloadSelector(workload),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: workload.Labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: convertContainers(workload.Containers),
+					Labels: workloadTemplateLabels(workload),
 				},
+				Spec: podSpec(workload),
 			},
-			ServiceName: workload.Name,
+			ServiceName:          workload.Name,
+			VolumeClaimTemplates: convertVolumeClaimTemplates(workload.VolumeClaimTemplates),
 		},
 	}
 }
@@ -744,122 +1352,1047 @@ func createDaemonSet(workload models.Workload) *appsv1.DaemonSet {
 		},
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{
-				MatchLabels: workload.Labels,
+				MatchLabels: workloadSelector(workload),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: workload.Labels,
+					Labels: workloadTemplateLabels(workload),
 				},
-				Spec: corev1.PodSpec{
-					Containers: convertContainers(workload.Containers),
+				Spec: podSpec(workload),
+			},
+		},
+	}
+}
+
+// updateAppsWorkload 读取集群中的 Deployment、StatefulSet 或 DaemonSet，只写入工作负载记录覆盖的字段后更新
+// 选择器以及 command、serviceAccountName、tolerations 等记录未覆盖的字段保持集群中的值，遇到冲突时使用最新的对象重试
+// Deployment 的 spec.paused 和 Pod 模板中的重启时间注解由暂停、重启操作维护，更新时同样保持不变
+func updateAppsWorkload(ctx context.Context, clientset kubernetes.Interface, workload models.Workload, opts metav1.UpdateOptions) (runtime.Object, error) {
+	var updated runtime.Object
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		switch workload.Kind {
+		case "Deployment":
+			deployment, err := clientset.AppsV1().Deployments(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			mergeWorkloadMeta(&deployment.ObjectMeta, workload)
+			replicas := workload.Replicas
+			deployment.Spec.Replicas = &replicas
+			mergePodTemplate(&deployment.Spec.Template, workload)
+			updated, err = clientset.AppsV1().Deployments(workload.Namespace).Update(ctx, deployment, opts)
+			return err
+		case "StatefulSet":
+			statefulSet, err := clientset.AppsV1().StatefulSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			mergeWorkloadMeta(&statefulSet.ObjectMeta, workload)
+			replicas := workload.Replicas
+			statefulSet.Spec.Replicas = &replicas
+			mergePodTemplate(&statefulSet.Spec.Template, workload)
+			updated, err = clientset.AppsV1().StatefulSets(workload.Namespace).Update(ctx, statefulSet, opts)
+			return err
+		default:
+			daemonSet, err := clientset.AppsV1().DaemonSets(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			mergeWorkloadMeta(&daemonSet.ObjectMeta, workload)
+			mergePodTemplate(&daemonSet.Spec.Template, workload)
+			updated, err = clientset.AppsV1().DaemonSets(workload.Namespace).Update(ctx, daemonSet, opts)
+			return err
+		}
+	})
+	return updated, err
+}
+
+// mergeWorkloadMeta 将工作负载的标签和注解写入集群中的对象，保留同步时忽略的注解
+func mergeWorkloadMeta(meta *metav1.ObjectMeta, workload models.Workload) {
+	meta.Labels = workload.Labels
+	meta.Annotations = utils.WithIgnoredAnnotations(meta.Annotations, workload.Annotations)
+}
+
+// mergePodTemplate 将工作负载的 Pod 模板标签、容器和卷写入集群中的 Pod 模板
+// 模板注解、initContainers、nodeSelector、affinity 等其他字段保持不变
+func mergePodTemplate(template *corev1.PodTemplateSpec, workload models.Workload) {
+	if len(workload.TemplateLabels) > 0 {
+		template.Labels = workload.TemplateLabels
+	}
+	template.Spec.Containers = mergeContainers(template.Spec.Containers, workload.Containers)
+	template.Spec.Volumes = mergeVolumes(template.Spec.Volumes, workload.Volumes)
+}
+
+// mergeContainers 按名称将工作负载的容器配置写入集群中的容器，保留 command、args、securityContext 等记录未覆盖的字段
+// 工作负载中没有的容器会被移除，新增的容器只包含记录中的字段
+func mergeContainers(live []corev1.Container, containers []models.Container) []corev1.Container {
+	merged := convertContainers(containers)
+	for i, container := range merged {
+		for _, c := range live {
+			if c.Name != container.Name {
+				continue
+			}
+			c.Image = container.Image
+			c.Resources.Requests = container.Resources.Requests
+			c.Resources.Limits = container.Resources.Limits
+			c.Env = container.Env
+			c.EnvFrom = container.EnvFrom
+			c.Ports = container.Ports
+			c.LivenessProbe = container.LivenessProbe
+			c.ReadinessProbe = container.ReadinessProbe
+			c.StartupProbe = container.StartupProbe
+			c.VolumeMounts = container.VolumeMounts
+			merged[i] = c
+			break
+		}
+	}
+	return merged
+}
+
+// mergeVolumes 使用工作负载的卷替换集群中的卷，记录不支持的卷类型（如 projected、downwardAPI）保持不变
+func mergeVolumes(live []corev1.Volume, volumes []models.Volume) []corev1.Volume {
+	merged := convertVolumes(volumes)
+	for _, volume := range live {
+		supported := len(utils.VolumesFromPodSpec(corev1.PodSpec{Volumes: []corev1.Volume{volume}})) > 0
+		named := slices.ContainsFunc(merged, func(v corev1.Volume) bool { return v.Name == volume.Name })
+		if !supported && !named {
+			merged = append(merged, volume)
+		}
+	}
+	return merged
+}
+
+// workloadSelector 返回工作负载的选择器，没有单独记录选择器的历史记录使用对象标签
+func workloadSelector(workload models.Workload) map[string]string {
+	if len(workload.Selector) > 0 {
+		return workload.Selector
+	}
+	return workload.Labels
+}
+
+// workloadTemplateLabels 返回工作负载的 Pod 模板标签，没有单独记录时使用选择器
+func workloadTemplateLabels(workload models.Workload) map[string]string {
+	if len(workload.TemplateLabels) > 0 {
+		return workload.TemplateLabels
+	}
+	return workloadSelector(workload)
+}
+
+// backgroundPropagation 删除 Job 和 CronJob 时在后台删除其创建的 Job 和 Pod
+var backgroundPropagation = metav1.DeletePropagationBackground
+
+// 创建 Job 资源，选择器由 API Server 生成
+func createJob(workload models.Workload) *batchv1.Job {
+	spec := jobSpec(workload)
+	if workload.Batch != nil {
+		spec.Suspend = optionalBool(workload.Batch.Suspend)
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workload.Name,
+			Namespace:   workload.Namespace,
+			Labels:      workload.Labels,
+			Annotations: workload.Annotations,
+		},
+		Spec: spec,
+	}
+}
+
+// 创建 CronJob 资源
+func createCronJob(workload models.Workload) *batchv1.CronJob {
+	batch := models.BatchSpec{}
+	if workload.Batch != nil {
+		batch = *workload.Batch
+	}
+	var timeZone *string
+	if batch.TimeZone != "" {
+		timeZone = &batch.TimeZone
+	}
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        workload.Name,
+			Namespace:   workload.Namespace,
+			Labels:      workload.Labels,
+			Annotations: workload.Annotations,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   batch.Schedule,
+			TimeZone:                   timeZone,
+			ConcurrencyPolicy:          batchv1.ConcurrencyPolicy(batch.ConcurrencyPolicy),
+			Suspend:                    &batch.Suspend,
+			SuccessfulJobsHistoryLimit: batch.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     batch.FailedJobsHistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: workload.Labels,
 				},
+				Spec: jobSpec(workload),
 			},
 		},
 	}
 }
 
-// ScaleWorkload godoc
-// @Summary      扩缩容工作负载
-// @Description  调整指定集群中工作负载的副本数
+// jobSpec 使用工作负载的容器、卷和 Job 配置构建 Job 的 spec，重启策略默认为 OnFailure
+func jobSpec(workload models.Workload) batchv1.JobSpec {
+	batch := models.BatchSpec{}
+	if workload.Batch != nil {
+		batch = *workload.Batch
+	}
+	pod := podSpec(workload)
+	pod.RestartPolicy = corev1.RestartPolicy(batch.RestartPolicy)
+	if pod.RestartPolicy == "" {
+		pod.RestartPolicy = corev1.RestartPolicyOnFailure
+	}
+	return batchv1.JobSpec{
+		BackoffLimit:            batch.BackoffLimit,
+		Completions:             batch.Completions,
+		Parallelism:             batch.Parallelism,
+		ActiveDeadlineSeconds:   batch.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: batch.TTLSecondsAfterFinished,
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: workload.Labels,
+			},
+			Spec: pod,
+		},
+	}
+}
+
+// updateCronJob 读取集群中的 CronJob，只写入工作负载记录覆盖的字段后更新，遇到冲突时使用最新的对象重试
+// Job 模板的标签、注解以及 Pod 模板中 nodeSelector、tolerations 等记录未覆盖的字段保持集群中的值
+func updateCronJob(ctx context.Context, clientset kubernetes.Interface, workload models.Workload, opts metav1.UpdateOptions) (runtime.Object, error) {
+	batch := models.BatchSpec{}
+	if workload.Batch != nil {
+		batch = *workload.Batch
+	}
+	var timeZone *string
+	if batch.TimeZone != "" {
+		timeZone = &batch.TimeZone
+	}
+
+	var updated runtime.Object
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cronJob, err := clientset.BatchV1().CronJobs(workload.Namespace).Get(ctx, workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		mergeWorkloadMeta(&cronJob.ObjectMeta, workload)
+		cronJob.Spec.Schedule = batch.Schedule
+		cronJob.Spec.TimeZone = timeZone
+		cronJob.Spec.ConcurrencyPolicy = batchv1.ConcurrencyPolicy(batch.ConcurrencyPolicy)
+		cronJob.Spec.Suspend = &batch.Suspend
+		cronJob.Spec.SuccessfulJobsHistoryLimit = batch.SuccessfulJobsHistoryLimit
+		cronJob.Spec.FailedJobsHistoryLimit = batch.FailedJobsHistoryLimit
+
+		jobSpec := &cronJob.Spec.JobTemplate.Spec
+		jobSpec.BackoffLimit = batch.BackoffLimit
+		jobSpec.Completions = batch.Completions
+		jobSpec.Parallelism = batch.Parallelism
+		jobSpec.ActiveDeadlineSeconds = batch.ActiveDeadlineSeconds
+		jobSpec.TTLSecondsAfterFinished = batch.TTLSecondsAfterFinished
+		mergePodTemplate(&jobSpec.Template, workload)
+		if batch.RestartPolicy != "" {
+			jobSpec.Template.Spec.RestartPolicy = corev1.RestartPolicy(batch.RestartPolicy)
+		}
+		updated, err = clientset.BatchV1().CronJobs(workload.Namespace).Update(ctx, cronJob, opts)
+		return err
+	})
+	return updated, err
+}
+
+// updateJob 将工作负载中 Job 创建后仍可修改的字段写入集群中的 Job
+func updateJob(job *batchv1.Job, workload models.Workload) *batchv1.Job {
+	job = job.DeepCopy()
+	job.Labels = workload.Labels
+	job.Annotations = workload.Annotations
+	if batch := workload.Batch; batch != nil {
+		job.Spec.Suspend = &batch.Suspend
+		job.Spec.Parallelism = batch.Parallelism
+		job.Spec.ActiveDeadlineSeconds = batch.ActiveDeadlineSeconds
+		job.Spec.TTLSecondsAfterFinished = batch.TTLSecondsAfterFinished
+	}
+	return job
+}
+
+// validateBatch 校验 Job 和 CronJob 的配置，返回的错误以字段名开头
+func validateBatch(kind string, batch *models.BatchSpec) error {
+	if kind != "Job" && kind != "CronJob" {
+		if batch != nil {
+			return fmt.Errorf("只有 Job 和 CronJob 支持 batch")
+		}
+		return nil
+	}
+	if batch == nil {
+		if kind == "CronJob" {
+			return fmt.Errorf("batch.schedule 不能为空")
+		}
+		return nil
+	}
+
+	if kind == "CronJob" {
+		if strings.TrimSpace(batch.Schedule) == "" {
+			return fmt.Errorf("batch.schedule 不能为空")
+		}
+		switch batchv1.ConcurrencyPolicy(batch.ConcurrencyPolicy) {
+		case "", batchv1.AllowConcurrent, batchv1.ForbidConcurrent, batchv1.ReplaceConcurrent:
+		default:
+			return fmt.Errorf("batch.concurrency_policy 只支持 Allow、Forbid 和 Replace: %q", batch.ConcurrencyPolicy)
+		}
+	} else if batch.Schedule != "" || batch.TimeZone != "" || batch.ConcurrencyPolicy != "" ||
+		batch.SuccessfulJobsHistoryLimit != nil || batch.FailedJobsHistoryLimit != nil {
+		return fmt.Errorf("batch.schedule、time_zone、concurrency_policy 和历史保留数只用于 CronJob")
+	}
+
+	switch corev1.RestartPolicy(batch.RestartPolicy) {
+	case "", corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+	default:
+		return fmt.Errorf("batch.restart_policy 只支持 OnFailure 和 Never: %q", batch.RestartPolicy)
+	}
+
+	for field, value := range map[string]*int32{
+		"successful_jobs_history_limit": batch.SuccessfulJobsHistoryLimit,
+		"failed_jobs_history_limit":     batch.FailedJobsHistoryLimit,
+		"backoff_limit":                 batch.BackoffLimit,
+		"completions":                   batch.Completions,
+		"parallelism":                   batch.Parallelism,
+		"ttl_seconds_after_finished":    batch.TTLSecondsAfterFinished,
+	} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("batch.%s 不能为负数: %d", field, *value)
+		}
+	}
+	if batch.ActiveDeadlineSeconds != nil && *batch.ActiveDeadlineSeconds <= 0 {
+		return fmt.Errorf("batch.active_deadline_seconds 必须大于 0: %d", *batch.ActiveDeadlineSeconds)
+	}
+	return nil
+}
+
+// cronJobPodSelector 返回选择 CronJob 所有 Job 的 Pod 的选择器，没有 Job 时不匹配任何 Pod
+func cronJobPodSelector(clusterCache *utils.ClusterCache, cronJob *batchv1.CronJob) (labels.Selector, error) {
+	jobs, err := clusterCache.Jobs().Jobs(cronJob.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, job := range jobs {
+		if owner := metav1.GetControllerOf(job); owner != nil && owner.Kind == "CronJob" && owner.Name == cronJob.Name {
+			names = append(names, job.Name)
+		}
+	}
+	if len(names) == 0 {
+		return labels.Nothing(), nil
+	}
+	requirement, err := labels.NewRequirement(utils.JobNameLabel, selection.In, names)
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*requirement), nil
+}
+
+// GetWorkloadRevisions godoc
+// @Summary      获取工作负载的版本历史
+// @Description  Deployment 的历史来自其管理的 ReplicaSet，StatefulSet 和 DaemonSet 的历史来自 ControllerRevision，按版本号从新到旧排序
 // @Tags         workloads
 // @Accept       json
 // @Produce      json
-// @Param        clusterId path int true "集群ID"
+// @Param        id path int true "集群ID"
 // @Param        kind path string true "工作负载类型"
 // @Param        namespace path string true "命名空间"
 // @Param        name path string true "工作负载名称"
-// @Param        replicas body map[string]int true "副本数"
-// @Success      200 {object} models.Workload "扩缩容成功"
-// @Failure      400 {object} map[string]string "请求参数错误"
-// @Failure      404 {object} map[string]string "工作负载不存在"
+// @Success      200 {object} map[string][]utils.WorkloadRevision "版本历史"
+// @Failure      400 {object} map[string]string "不支持的工作负载类型"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
 // @Failure      500 {object} map[string]string "服务器内部错误"
-// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/scale [put]
-func (w *WorkloadController) ScaleWorkload(ctx *gin.Context) {
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/revisions [get]
+func (w *WorkloadController) GetWorkloadRevisions(ctx *gin.Context) {
 	clusterId, _ := strconv.Atoi(ctx.Param("id"))
 	kind := ctx.Param("kind")
 	namespace := ctx.Param("namespace")
 	name := ctx.Param("name")
 
-	var scaleReq struct {
-		Replicas int `json:"replicas"`
-	}
-
-	if err := ctx.ShouldBindJSON(&scaleReq); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 获取集群信息
 	var cluster models.Cluster
 	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// 初始化并获取 Kubernetes 客户端
-	if err := utils.InitKubernetesClient(cluster); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	clientset, err := w.Clients.GetClient(cluster)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
-	// 获取工作负载状态
-	clientset, err := utils.GetKubernetesClient(cluster.ID)
+
+	revisions, err := utils.ListRevisions(ctx, clientset, kind, namespace, name)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	if revisions == nil {
+		revisions = []utils.WorkloadRevision{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
 
-	// 根据工作负载类型进行扩缩容
-	switch kind {
-	case "Deployment":
-		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "工作负载不存在"})
-			return
-		}
-		replicas := int32(scaleReq.Replicas)
-		fmt.Println("缩容前副本数:", deployment.Spec.Replicas)
+// RollbackRequest 回滚工作负载的请求
+type RollbackRequest struct {
+	// 回滚到的版本号，为 0 时回滚到上一个版本
+	Revision int64 `json:"revision" example:"3"`
+}
 
-		deployment.Spec.Replicas = &replicas
-		_, err = clientset.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		fmt.Println("缩容后副本数:", replicas)
-	case "StatefulSet":
-		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err != nil {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "工作负载不存在"})
-			return
-		}
-		replicas := int32(scaleReq.Replicas)
-		statefulSet.Spec.Replicas = &replicas
-		_, err = clientset.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, metav1.UpdateOptions{})
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	case "DaemonSet":
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "DaemonSet不支持扩缩容"})
+// RollbackWorkload godoc
+// @Summary      回滚工作负载
+// @Description  将工作负载的 Pod 模板恢复为指定版本，并在工作负载记录中保存回滚记录
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        request body RollbackRequest false "回滚到的版本"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} models.Workload "回滚成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "工作负载或历史版本不存在"
+// @Failure      409 {object} map[string]string "工作负载已被修改"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/rollback [post]
+func (w *WorkloadController) RollbackWorkload(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+	kind := ctx.Param("kind")
+	namespace := ctx.Param("namespace")
+	name := ctx.Param("name")
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
 		return
-	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "不支持的工作负载类型"})
+	}
+
+	var request RollbackRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Revision < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "revision 不能为负数"})
 		return
 	}
 
-	// 更新数据库中的副本数
 	var workload models.Workload
 	if err := w.DB.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterId, kind, namespace, name).First(&workload).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "更新数据库失败: " + err.Error()})
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "工作负载不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	workload.Replicas = int32(scaleReq.Replicas)
+	var cluster models.Cluster
+	if err := w.DB.First(&cluster, workload.ClusterID).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientset, err := w.Clients.GetClient(cluster)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var live runtime.Object
+	if dryRun {
+		if live, err = utils.GetWorkloadObject(ctx, clientset, workload.Kind, workload.Namespace, workload.Name); err != nil {
+			writeKubeError(ctx, err)
+			return
+		}
+	}
+
+	current, target, updated, err := utils.RollbackWorkload(ctx, clientset, workload.Kind, workload.Namespace, workload.Name, request.Revision, dryRun)
+	if err != nil {
+		ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, updated)
+		return
+	}
+
+	// 记录中的容器和卷与回滚后的 Pod 模板保持一致
+	template := target.Template()
+	workload.Containers = utils.ContainersFromPodSpec(template.Spec)
+	workload.Volumes = utils.VolumesFromPodSpec(template.Spec)
+	if target.ChangeCause != "" {
+		if workload.Annotations == nil {
+			workload.Annotations = models.StringMap{}
+		}
+		workload.Annotations[utils.ChangeCauseAnnotation] = target.ChangeCause
+	} else {
+		delete(workload.Annotations, utils.ChangeCauseAnnotation)
+	}
+	workload.LastRollback = &models.RollbackRecord{
+		FromRevision: current.Revision,
+		ToRevision:   target.Revision,
+		Images:       target.Images,
+		ChangeCause:  target.ChangeCause,
+		RolledBackAt: time.Now(),
+	}
 	if err := w.DB.Save(&workload).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workload)
+}
+
+const (
+	// 滚动更新进度的默认等待时间
+	defaultRolloutStatusTimeout = 10 * time.Minute
+	// 读取滚动更新进度的间隔
+	rolloutPollInterval = time.Second
+)
+
+// GetRolloutStatus godoc
+// @Summary      跟踪工作负载的滚动更新进度
+// @Description  通过 Server-Sent Events 推送滚动更新进度，进度变化时发送 progress 事件，结束时发送 complete、failed 或 timeout 事件并关闭连接。Deployment 超过 progressDeadlineSeconds 视为失败。结束时将状态条件写入工作负载记录
+// @Tags         workloads
+// @Produce      text/event-stream
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        timeout query string false "最长等待时间，默认 10m"
+// @Success      200 {object} utils.RolloutStatus "滚动更新进度"
+// @Failure      400 {object} map[string]string "请求参数错误"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Failure      503 {object} map[string]string "集群资源缓存同步中"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/rollout-status [get]
+func (w *WorkloadController) GetRolloutStatus(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+	kind := ctx.Param("kind")
+	namespace := ctx.Param("namespace")
+	name := ctx.Param("name")
+
+	timeout := defaultRolloutStatusTimeout
+	if value := ctx.Query("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("timeout 格式错误: %q", value)})
+			return
+		}
+		timeout = parsed
+	}
+
+	var cluster models.Cluster
+	if err := w.DB.First(&cluster, clusterId).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clusterCache, err := syncedCache(ctx, w.Clients, cluster, utils.WorkloadResource(kind))
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := utils.CachedRolloutStatus(clusterCache, kind, namespace, name)
+	if err != nil {
+		ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(rolloutPollInterval)
+	defer ticker.Stop()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+
+	// 首次发送当前进度，之后只在进度变化时发送
+	var last string
+	for {
+		if current := fmt.Sprintf("%+v", status); current != last {
+			last = current
+			if status.Done() {
+				ctx.SSEvent(status.Phase, status)
+				ctx.Writer.Flush()
+				w.saveRolloutStatus(clusterCache, uint(clusterId), kind, namespace, name)
+				return
+			}
+			ctx.SSEvent("progress", status)
+			ctx.Writer.Flush()
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-deadline.C:
+			status.Phase = utils.RolloutTimeout
+			status.Message = fmt.Sprintf("等待滚动更新超时: %s", timeout)
+			ctx.SSEvent(status.Phase, status)
+			ctx.Writer.Flush()
+			w.saveRolloutStatus(clusterCache, uint(clusterId), kind, namespace, name)
+			return
+		case <-ticker.C:
+		}
+
+		next, err := utils.CachedRolloutStatus(clusterCache, kind, namespace, name)
+		if err != nil {
+			ctx.SSEvent("error", gin.H{"error": err.Error()})
+			ctx.Writer.Flush()
+			return
+		}
+		status = next
+	}
+}
+
+// saveRolloutStatus 滚动更新结束后将集群中的副本状态和状态条件写入工作负载记录
+func (w *WorkloadController) saveRolloutStatus(clusterCache *utils.ClusterCache, clusterID uint, kind, namespace, name string) {
+	status, err := cachedWorkloadStatus(clusterCache, kind, namespace, name)
+	if err != nil {
+		return
+	}
+	w.DB.Model(&models.Workload{}).
+		Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterID, kind, namespace, name).
+		UpdateColumn("status", status)
+}
+
+// RestartWorkload godoc
+// @Summary      滚动重启工作负载
+// @Description  通过补丁更新 Pod 模板的 kubectl.kubernetes.io/restartedAt 注解触发滚动重启，与 kubectl rollout restart 一致
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "重启成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "不支持的工作负载类型"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/restart [post]
+func (w *WorkloadController) RestartWorkload(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+
+	kind, namespace, name := ctx.Param("kind"), ctx.Param("namespace"), ctx.Param("name")
+
+	var live runtime.Object
+	if dryRun {
+		var err error
+		if live, err = utils.GetWorkloadObject(ctx, clientset, kind, namespace, name); err != nil {
+			ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	restartedAt := time.Now()
+	restarted, err := utils.RestartWorkload(ctx, clientset, kind, namespace, name, restartedAt, dryRun)
+	if err != nil {
+		ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, restarted)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "工作负载已开始滚动重启", "restarted_at": restartedAt.Format(time.RFC3339)})
+}
+
+// PauseWorkload godoc
+// @Summary      暂停工作负载
+// @Description  Deployment 通过补丁设置 spec.paused，暂停期间对 Pod 模板的修改不会触发滚动更新；Job 和 CronJob 设置 spec.suspend 挂起执行
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "暂停成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "只有 Deployment、Job 和 CronJob 支持暂停"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/pause [post]
+func (w *WorkloadController) PauseWorkload(ctx *gin.Context) {
+	w.setWorkloadPaused(ctx, true)
+}
+
+// ResumeWorkload godoc
+// @Summary      恢复工作负载
+// @Description  Deployment 通过补丁清除 spec.paused，暂停期间的修改会开始滚动更新；Job 和 CronJob 清除 spec.suspend 继续执行
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} map[string]string "恢复成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "只有 Deployment、Job 和 CronJob 支持恢复"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/resume [post]
+func (w *WorkloadController) ResumeWorkload(ctx *gin.Context) {
+	w.setWorkloadPaused(ctx, false)
+}
+
+// setWorkloadPaused 暂停或恢复请求路径中的工作负载，Job 和 CronJob 同时更新记录中的 suspend
+func (w *WorkloadController) setWorkloadPaused(ctx *gin.Context, paused bool) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+	kind, namespace, name := ctx.Param("kind"), ctx.Param("namespace"), ctx.Param("name")
+
+	var live runtime.Object
+	if dryRun && (kind == "Deployment" || kind == "Job" || kind == "CronJob") {
+		var err error
+		if live, err = utils.GetWorkloadObject(ctx, clientset, kind, namespace, name); err != nil {
+			ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	updated, err := utils.SetWorkloadPaused(ctx, clientset, kind, namespace, name, paused, dryRun)
+	if err != nil {
+		ctx.JSON(rolloutErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if dryRun {
+		writePreview(ctx, live, updated)
+		return
+	}
+	if kind == "Deployment" {
+		message := "滚动更新已恢复"
+		if paused {
+			message = "滚动更新已暂停"
+		}
+		ctx.JSON(http.StatusOK, gin.H{"message": message})
+		return
+	}
+
+	// 工作负载记录中的 suspend 与集群保持一致，避免之后的更新恢复旧值
+	var workload models.Workload
+	err = w.DB.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", ctx.Param("id"), kind, namespace, name).First(&workload).Error
+	if err == nil {
+		if workload.Batch == nil {
+			workload.Batch = &models.BatchSpec{}
+		}
+		workload.Batch.Suspend = paused
+		err = w.DB.Model(&workload).UpdateColumn("batch", workload.Batch).Error
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	message := "已恢复执行"
+	if paused {
+		message = "已暂停执行"
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// TriggerCronJob godoc
+// @Summary      立即运行 CronJob
+// @Description  使用 CronJob 的 Job 模板立即创建一个 Job，与 kubectl create job --from=cronjob 一致，创建的 Job 计入执行历史
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      201 {object} utils.JobRecord "创建的 Job"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "只有 CronJob 支持手动触发"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/trigger [post]
+func (w *WorkloadController) TriggerCronJob(ctx *gin.Context) {
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+	kind, namespace, name := ctx.Param("kind"), ctx.Param("namespace"), ctx.Param("name")
+
+	job, err := utils.TriggerCronJob(ctx, clientset, kind, namespace, name, dryRun)
+	if err != nil {
+		if errors.Is(err, utils.ErrTriggerUnsupported) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		writeKubeError(ctx, err)
+		return
+	}
+	if dryRun {
+		writePreview(ctx, nil, job)
+		return
+	}
+	ctx.JSON(http.StatusCreated, utils.NewJobRecord(job))
+}
+
+// GetJobHistory godoc
+// @Summary      获取 Job 的执行历史
+// @Description  CronJob 返回其创建的所有 Job，Job 返回自身，包含成功和失败的 Pod 数，按创建时间从新到旧排序
+// @Tags         workloads
+// @Produce      json
+// @Param        id path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Success      200 {object} map[string][]utils.JobRecord "执行历史"
+// @Failure      400 {object} map[string]string "不支持的工作负载类型"
+// @Failure      404 {object} map[string]string "集群或工作负载不存在"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/jobs [get]
+func (w *WorkloadController) GetJobHistory(ctx *gin.Context) {
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+	kind, namespace, name := ctx.Param("kind"), ctx.Param("namespace"), ctx.Param("name")
+
+	jobs, err := utils.ListJobHistory(ctx, clientset, kind, namespace, name)
+	if err != nil {
+		writeKubeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// clusterClient 获取请求路径中集群的客户端，失败时写入错误响应并返回 false
+func (w *WorkloadController) clusterClient(ctx *gin.Context) (kubernetes.Interface, bool) {
+	return clusterClient(ctx, w.DB, w.Clients)
+}
+
+// clusterClient 获取请求路径中集群的客户端，失败时写入错误响应并返回 false
+func clusterClient(ctx *gin.Context, db *gorm.DB, clients utils.ClientProvider) (kubernetes.Interface, bool) {
+	_, clientset, ok := clusterWithClient(ctx, db, clients)
+	return clientset, ok
+}
+
+// clusterWithClient 获取请求路径中的集群及其客户端，失败时写入错误响应并返回 false
+func clusterWithClient(ctx *gin.Context, db *gorm.DB, clients utils.ClientProvider) (models.Cluster, kubernetes.Interface, bool) {
+	var cluster models.Cluster
+	if err := db.First(&cluster, ctx.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "集群不存在"})
+			return cluster, nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return cluster, nil, false
+	}
+
+	clientset, err := clients.GetClient(cluster)
+	if err != nil {
+		ctx.JSON(kubeClientErrorStatus(err), gin.H{"error": err.Error()})
+		return cluster, nil, false
+	}
+	return cluster, clientset, true
+}
+
+// deleteCreatedNamespace 删除为创建工作负载而新建的命名空间，返回的错误包含创建工作负载的错误
+func deleteCreatedNamespace(ctx context.Context, clientset kubernetes.Interface, namespace string, cause error) error {
+	if err := clientset.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("%w，删除新建的命名空间 %s 失败: %v", cause, namespace, err)
+	}
+	return cause
+}
+
+// rolloutErrorStatus 版本历史和回滚错误对应的状态码
+func rolloutErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrRolloutUnsupported), errors.Is(err, utils.ErrPauseUnsupported), errors.Is(err, utils.ErrUnsupportedKind):
+		return http.StatusBadRequest
+	case errors.Is(err, utils.ErrRevisionNotFound), apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsConflict(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// dryRunQuery 解析 dryRun 查询参数，格式错误时写入错误响应并返回 false
+func dryRunQuery(ctx *gin.Context) (bool, bool) {
+	dryRun, err := parseBoolQuery(ctx, "dryRun")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false, false
+	}
+	return dryRun, true
+}
+
+// writePreview 对比集群中的当前对象和试运行返回的对象，返回预览
+func writePreview(ctx *gin.Context, live, admitted runtime.Object) {
+	preview, err := utils.NewWorkloadPreview(live, admitted)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// writeCreatePreview 写入创建工作负载的试运行预览，同时创建的 Service 为空时不返回
+func writeCreatePreview(ctx *gin.Context, created runtime.Object, service *corev1.Service) {
+	preview, err := utils.NewWorkloadPreview(nil, created)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	preview.Service = service
+	ctx.JSON(http.StatusOK, preview)
+}
+
+// writeKubeError 写入创建、更新或删除工作负载时 API Server 返回的错误
+// 校验或准入 webhook 拒绝时返回 422 和出错的字段
+func writeKubeError(ctx *gin.Context, err error) {
+	var statusErr *apierrors.StatusError
+	switch {
+	case errors.Is(err, utils.ErrUnsupportedKind):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case apierrors.IsInvalid(err) && errors.As(err, &statusErr) && statusErr.ErrStatus.Details != nil:
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "causes": statusErr.ErrStatus.Details.Causes})
+	case apierrors.IsInvalid(err):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case apierrors.IsForbidden(err):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case apierrors.IsNotFound(err):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ScaleRequest 扩缩容请求
+type ScaleRequest struct {
+	// 期望副本数
+	Replicas *int32 `json:"replicas" binding:"required" example:"3"`
+	// 工作负载的 resourceVersion，不为空时工作负载已被修改则返回 409
+	ResourceVersion string `json:"resourceVersion,omitempty" example:"123456"`
+}
+
+// ScaleWorkload godoc
+// @Summary      扩缩容工作负载
+// @Description  通过 scale 子资源调整副本数，支持 Deployment、StatefulSet、ReplicaSet 和 ReplicationController。未指定 resourceVersion 时遇到冲突自动重试
+// @Tags         workloads
+// @Accept       json
+// @Produce      json
+// @Param        clusterId path int true "集群ID"
+// @Param        kind path string true "工作负载类型"
+// @Param        namespace path string true "命名空间"
+// @Param        name path string true "工作负载名称"
+// @Param        request body ScaleRequest true "副本数"
+// @Param        dryRun query bool false "只由 API Server 试运行，返回准入后的对象和与当前对象的差异"
+// @Success      200 {object} utils.ScaleStatus "扩缩容成功"
+// @Success      200 {object} utils.WorkloadPreview "试运行预览"
+// @Failure      400 {object} map[string]string "请求参数错误或不支持扩缩容"
+// @Failure      404 {object} map[string]string "工作负载不存在"
+// @Failure      409 {object} map[string]string "工作负载已被修改"
+// @Failure      500 {object} map[string]string "服务器内部错误"
+// @Router       /api/v1/clusters/{id}/workloads/{kind}/{namespace}/{name}/scale [put]
+func (w *WorkloadController) ScaleWorkload(ctx *gin.Context) {
+	clusterId, _ := strconv.Atoi(ctx.Param("id"))
+	kind := ctx.Param("kind")
+	namespace := ctx.Param("namespace")
+	name := ctx.Param("name")
+
+	dryRun, ok := dryRunQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var request ScaleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *request.Replicas < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "replicas 不能为负数"})
+		return
+	}
+
+	clientset, ok := w.clusterClient(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := utils.ScaleWorkload(ctx, clientset, kind, namespace, name, *request.Replicas, request.ResourceVersion, dryRun)
+	if err != nil {
+		ctx.JSON(scaleErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if dryRun {
+		writePreview(ctx, status.Live, status.Updated)
+		return
+	}
+
+	// 更新数据库中的副本数，ReplicaSet 等没有工作负载记录的类型不需要更新
+	if err := w.DB.Model(&models.Workload{}).
+		Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterId, kind, namespace, name).
+		UpdateColumn("replicas", status.Replicas).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "更新数据库失败: " + err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "扩缩容成功"})
+	ctx.JSON(http.StatusOK, status)
+}
+
+// scaleErrorStatus 扩缩容错误对应的状态码，前置条件不满足时返回 409
+func scaleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrScaleUnsupported):
+		return http.StatusBadRequest
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsConflict(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// podSpec 使用工作负载的容器和卷构建 Pod 模板
+func podSpec(workload models.Workload) corev1.PodSpec {
+	return corev1.PodSpec{
+		Containers: convertContainers(workload.Containers),
+		Volumes:    convertVolumes(workload.Volumes),
+	}
 }
 
 // 转换容器配置
@@ -873,18 +2406,279 @@ func convertContainers(containers []models.Container) []corev1.Container {
 				Requests: convertResourceList(c.Requests),
 				Limits:   convertResourceList(c.Limits),
 			},
-			Env:   convertEnvVars(c.Env),
-			Ports: convertContainerPorts(c.Ports),
+			Env:            convertEnvVars(c.Env),
+			EnvFrom:        convertEnvFrom(c.EnvFrom),
+			Ports:          convertContainerPorts(c.Ports),
+			LivenessProbe:  convertProbe(c.LivenessProbe),
+			ReadinessProbe: convertProbe(c.ReadinessProbe),
+			StartupProbe:   convertProbe(c.StartupProbe),
+			VolumeMounts:   convertVolumeMounts(c.VolumeMounts),
 		}
 		k8sContainers = append(k8sContainers, container)
 	}
 	return k8sContainers
 }
 
-// 转换资源配置
+// validateContainers 校验容器的资源配置、环境变量和探针，错误信息中包含容器名称和字段
+func validateContainers(containers []models.Container) error {
+	for _, c := range containers {
+		if err := validateEnv(c.Env, c.EnvFrom); err != nil {
+			return fmt.Errorf("容器 %s 的 %v", c.Name, err)
+		}
+		if _, err := parseResourceList(c.Requests); err != nil {
+			return fmt.Errorf("容器 %s 的 requests.%v", c.Name, err)
+		}
+		if _, err := parseResourceList(c.Limits); err != nil {
+			return fmt.Errorf("容器 %s 的 limits.%v", c.Name, err)
+		}
+		// 存活和启动探针的 success_threshold 只能为 1
+		probes := []struct {
+			field           string
+			probe           *models.Probe
+			singleThreshold bool
+		}{
+			{"liveness_probe", c.LivenessProbe, true},
+			{"readiness_probe", c.ReadinessProbe, false},
+			{"startup_probe", c.StartupProbe, true},
+		}
+		for _, p := range probes {
+			if err := validateProbe(p.probe, p.singleThreshold); err != nil {
+				return fmt.Errorf("容器 %s 的 %s.%v", c.Name, p.field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEnv 校验环境变量只配置了一种取值方式且引用完整，返回的错误以字段名开头
+func validateEnv(envVars []models.EnvVar, envFrom []models.EnvFromSource) error {
+	for _, env := range envVars {
+		if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+			return fmt.Errorf("env 名称无效 %q: %s", env.Name, strings.Join(errs, "; "))
+		}
+		source := env.ValueFrom
+		if source == nil {
+			continue
+		}
+		if env.Value != "" {
+			return fmt.Errorf("env %s 的 value 和 value_from 只能设置一个", env.Name)
+		}
+
+		sources := 0
+		for field, ref := range map[string]*models.KeySelector{"config_map_key_ref": source.ConfigMapKeyRef, "secret_key_ref": source.SecretKeyRef} {
+			if ref == nil {
+				continue
+			}
+			sources++
+			if ref.Name == "" || ref.Key == "" {
+				return fmt.Errorf("env %s 的 value_from.%s 需要 name 和 key", env.Name, field)
+			}
+			if errs := validation.IsConfigMapKey(ref.Key); len(errs) > 0 {
+				return fmt.Errorf("env %s 的 value_from.%s.key 无效 %q: %s", env.Name, field, ref.Key, strings.Join(errs, "; "))
+			}
+		}
+		if source.FieldRef != nil {
+			sources++
+			if source.FieldRef.FieldPath == "" {
+				return fmt.Errorf("env %s 的 value_from.field_ref.field_path 不能为空", env.Name)
+			}
+		}
+		if ref := source.ResourceFieldRef; ref != nil {
+			sources++
+			if ref.Resource == "" {
+				return fmt.Errorf("env %s 的 value_from.resource_field_ref.resource 不能为空", env.Name)
+			}
+			if ref.Divisor != "" {
+				if _, err := resource.ParseQuantity(ref.Divisor); err != nil {
+					return fmt.Errorf("env %s 的 value_from.resource_field_ref.divisor 无效 %q: %v", env.Name, ref.Divisor, err)
+				}
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("env %s 的 value_from 中 config_map_key_ref、secret_key_ref、field_ref 和 resource_field_ref 必须且只能配置一个", env.Name)
+		}
+	}
+
+	for _, source := range envFrom {
+		if source.Prefix != "" {
+			if errs := validation.IsEnvVarName(source.Prefix); len(errs) > 0 {
+				return fmt.Errorf("env_from.prefix 无效 %q: %s", source.Prefix, strings.Join(errs, "; "))
+			}
+		}
+		if (source.ConfigMapRef == nil) == (source.SecretRef == nil) {
+			return fmt.Errorf("env_from 中 config_map_ref 和 secret_ref 必须且只能配置一个")
+		}
+		if (source.ConfigMapRef != nil && source.ConfigMapRef.Name == "") || (source.SecretRef != nil && source.SecretRef.Name == "") {
+			return fmt.Errorf("env_from 引用的名称不能为空")
+		}
+	}
+	return nil
+}
+
+// validateProbe 校验探针只配置了一种检查方式且参数有效，返回的错误以字段名开头
+func validateProbe(probe *models.Probe, singleThreshold bool) error {
+	if probe == nil {
+		return nil
+	}
+
+	handlers := 0
+	if probe.HTTPGet != nil {
+		handlers++
+		if err := validateProbePort("http_get.port", probe.HTTPGet.Port); err != nil {
+			return err
+		}
+		switch corev1.URIScheme(strings.ToUpper(probe.HTTPGet.Scheme)) {
+		case "", corev1.URISchemeHTTP, corev1.URISchemeHTTPS:
+		default:
+			return fmt.Errorf("http_get.scheme 只支持 HTTP 和 HTTPS: %q", probe.HTTPGet.Scheme)
+		}
+		for _, header := range probe.HTTPGet.HTTPHeaders {
+			if errs := validation.IsHTTPHeaderName(header.Name); len(errs) > 0 {
+				return fmt.Errorf("http_get.http_headers 名称无效 %q: %s", header.Name, strings.Join(errs, "; "))
+			}
+		}
+	}
+	if probe.TCPSocket != nil {
+		handlers++
+		if err := validateProbePort("tcp_socket.port", probe.TCPSocket.Port); err != nil {
+			return err
+		}
+	}
+	if probe.Exec != nil {
+		handlers++
+		if len(probe.Exec.Command) == 0 {
+			return fmt.Errorf("exec.command 不能为空")
+		}
+	}
+	if probe.GRPC != nil {
+		handlers++
+		if errs := validation.IsValidPortNum(int(probe.GRPC.Port)); len(errs) > 0 {
+			return fmt.Errorf("grpc.port 无效 %d: %s", probe.GRPC.Port, strings.Join(errs, "; "))
+		}
+	}
+	if handlers != 1 {
+		return fmt.Errorf("http_get、tcp_socket、exec 和 grpc 必须且只能配置一个")
+	}
+
+	if probe.InitialDelaySeconds < 0 || probe.TimeoutSeconds < 0 || probe.PeriodSeconds < 0 ||
+		probe.SuccessThreshold < 0 || probe.FailureThreshold < 0 {
+		return fmt.Errorf("时间和阈值不能为负数")
+	}
+	if singleThreshold && probe.SuccessThreshold > 1 {
+		return fmt.Errorf("success_threshold 只能为 1")
+	}
+	return nil
+}
+
+// validateProbePort 校验探针端口，支持端口号和端口名称
+func validateProbePort(field string, port intstr.IntOrString) error {
+	var errs []string
+	if port.Type == intstr.String {
+		errs = validation.IsValidPortName(port.StrVal)
+	} else {
+		errs = validation.IsValidPortNum(port.IntValue())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s 无效 %q: %s", field, port.String(), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// 转换探针配置，未设置的时间和阈值使用 Kubernetes 默认值
+func convertProbe(probe *models.Probe) *corev1.Probe {
+	if probe == nil {
+		return nil
+	}
+
+	k8sProbe := &corev1.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		httpGet := &corev1.HTTPGetAction{
+			Path:   probe.HTTPGet.Path,
+			Port:   probe.HTTPGet.Port,
+			Host:   probe.HTTPGet.Host,
+			Scheme: corev1.URIScheme(strings.ToUpper(probe.HTTPGet.Scheme)),
+		}
+		for _, header := range probe.HTTPGet.HTTPHeaders {
+			httpGet.HTTPHeaders = append(httpGet.HTTPHeaders, corev1.HTTPHeader{Name: header.Name, Value: header.Value})
+		}
+		k8sProbe.HTTPGet = httpGet
+	case probe.TCPSocket != nil:
+		k8sProbe.TCPSocket = &corev1.TCPSocketAction{Port: probe.TCPSocket.Port, Host: probe.TCPSocket.Host}
+	case probe.Exec != nil:
+		k8sProbe.Exec = &corev1.ExecAction{Command: probe.Exec.Command}
+	case probe.GRPC != nil:
+		grpc := &corev1.GRPCAction{Port: probe.GRPC.Port}
+		if probe.GRPC.Service != "" {
+			service := probe.GRPC.Service
+			grpc.Service = &service
+		}
+		k8sProbe.GRPC = grpc
+	}
+	return k8sProbe
+}
+
+// 转换资源配置，资源配置已由 validateContainers 校验
 func convertResourceList(resources models.ResourceList) corev1.ResourceList {
-	// 实现资源配置转换逻辑
-	return corev1.ResourceList{}
+	resourceList, _ := parseResourceList(resources)
+	return resourceList
+}
+
+// parseResourceList 解析资源数量，返回的错误以字段名开头
+func parseResourceList(resources models.ResourceList) (corev1.ResourceList, error) {
+	type quantityField struct {
+		field string
+		name  corev1.ResourceName
+		value string
+	}
+	fields := []quantityField{
+		{"cpu", corev1.ResourceCPU, resources.CPU},
+		{"memory", corev1.ResourceMemory, resources.Memory},
+		{"ephemeral_storage", corev1.ResourceEphemeralStorage, resources.EphemeralStorage},
+	}
+
+	// 扩展资源按名称排序，保证错误信息稳定
+	extendedNames := make([]string, 0, len(resources.Extended))
+	for name := range resources.Extended {
+		extendedNames = append(extendedNames, name)
+	}
+	sort.Strings(extendedNames)
+	for _, name := range extendedNames {
+		field := "extended." + name
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			return nil, fmt.Errorf("%s 资源名称无效: %s", field, strings.Join(errs, "; "))
+		}
+		switch corev1.ResourceName(name) {
+		case corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+			return nil, fmt.Errorf("%s 不能用于配置内置资源", field)
+		}
+		fields = append(fields, quantityField{field, corev1.ResourceName(name), resources.Extended[name]})
+	}
+
+	resourceList := corev1.ResourceList{}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s 无效 %q: %v", f.field, f.value, err)
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("%s 不能为负数 %q", f.field, f.value)
+		}
+		resourceList[f.name] = quantity
+	}
+	if len(resourceList) == 0 {
+		return nil, nil
+	}
+	return resourceList, nil
 }
 
 // 转换环境变量
@@ -892,13 +2686,69 @@ func convertEnvVars(envVars []models.EnvVar) []corev1.EnvVar {
 	var k8sEnvVars []corev1.EnvVar
 	for _, env := range envVars {
 		k8sEnvVars = append(k8sEnvVars, corev1.EnvVar{
-			Name:  env.Name,
-			Value: env.Value,
+			Name:      env.Name,
+			Value:     env.Value,
+			ValueFrom: convertEnvVarSource(env.ValueFrom),
 		})
 	}
 	return k8sEnvVars
 }
 
+// convertEnvVarSource 转换环境变量值的来源，Optional 为 false 时不设置，与 API Server 的默认值一致
+func convertEnvVarSource(source *models.EnvVarSource) *corev1.EnvVarSource {
+	if source == nil {
+		return nil
+	}
+	k8sSource := &corev1.EnvVarSource{}
+	if ref := source.ConfigMapKeyRef; ref != nil {
+		k8sSource.ConfigMapKeyRef = &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+			Key:                  ref.Key,
+			Optional:             optionalBool(ref.Optional),
+		}
+	}
+	if ref := source.SecretKeyRef; ref != nil {
+		k8sSource.SecretKeyRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+			Key:                  ref.Key,
+			Optional:             optionalBool(ref.Optional),
+		}
+	}
+	if ref := source.FieldRef; ref != nil {
+		k8sSource.FieldRef = &corev1.ObjectFieldSelector{FieldPath: ref.FieldPath, APIVersion: ref.APIVersion}
+	}
+	if ref := source.ResourceFieldRef; ref != nil {
+		k8sSource.ResourceFieldRef = &corev1.ResourceFieldSelector{ContainerName: ref.ContainerName, Resource: ref.Resource}
+		// 格式已由 validateEnv 校验
+		if ref.Divisor != "" {
+			k8sSource.ResourceFieldRef.Divisor = resource.MustParse(ref.Divisor)
+		}
+	}
+	return k8sSource
+}
+
+// convertEnvFrom 转换从 ConfigMap 或 Secret 导入的环境变量
+func convertEnvFrom(sources []models.EnvFromSource) []corev1.EnvFromSource {
+	var k8sSources []corev1.EnvFromSource
+	for _, source := range sources {
+		k8sSource := corev1.EnvFromSource{Prefix: source.Prefix}
+		if ref := source.ConfigMapRef; ref != nil {
+			k8sSource.ConfigMapRef = &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				Optional:             optionalBool(ref.Optional),
+			}
+		}
+		if ref := source.SecretRef; ref != nil {
+			k8sSource.SecretRef = &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+				Optional:             optionalBool(ref.Optional),
+			}
+		}
+		k8sSources = append(k8sSources, k8sSource)
+	}
+	return k8sSources
+}
+
 // 转换容器端口
 func convertContainerPorts(ports []models.ContainerPort) []corev1.ContainerPort {
 	var k8sPorts []corev1.ContainerPort
@@ -912,10 +2762,281 @@ func convertContainerPorts(ports []models.ContainerPort) []corev1.ContainerPort
 	return k8sPorts
 }
 
+// validateVolumes 校验 Pod 卷、持久卷声明模板和容器的卷挂载，返回的错误以字段名开头
+func validateVolumes(kind string, volumes []models.Volume, claimTemplates []models.VolumeClaimTemplate, containers []models.Container) error {
+	if len(claimTemplates) > 0 && kind != "StatefulSet" {
+		return fmt.Errorf("只有 StatefulSet 支持 volumeClaimTemplates")
+	}
+
+	names := make(map[string]bool, len(volumes)+len(claimTemplates))
+	for _, volume := range volumes {
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("volumes 名称无效 %q: %s", volume.Name, strings.Join(errs, "; "))
+		}
+		if names[volume.Name] {
+			return fmt.Errorf("volumes.%s 名称重复", volume.Name)
+		}
+		names[volume.Name] = true
+		if err := validateVolumeSource(volume); err != nil {
+			return fmt.Errorf("volumes.%s.%v", volume.Name, err)
+		}
+	}
+
+	for _, claim := range claimTemplates {
+		if errs := validation.IsDNS1123Label(claim.Name); len(errs) > 0 {
+			return fmt.Errorf("volume_claim_templates 名称无效 %q: %s", claim.Name, strings.Join(errs, "; "))
+		}
+		if names[claim.Name] {
+			return fmt.Errorf("volume_claim_templates.%s 与已有的卷名称重复", claim.Name)
+		}
+		names[claim.Name] = true
+		if _, err := parseVolumeClaimTemplate(claim); err != nil {
+			return fmt.Errorf("volume_claim_templates.%s.%v", claim.Name, err)
+		}
+	}
+
+	for _, c := range containers {
+		mountPaths := make(map[string]bool, len(c.VolumeMounts))
+		for _, mount := range c.VolumeMounts {
+			if !names[mount.Name] {
+				return fmt.Errorf("容器 %s 的 volume_mounts.%s 引用的卷不存在", c.Name, mount.Name)
+			}
+			if !path.IsAbs(mount.MountPath) {
+				return fmt.Errorf("容器 %s 的 volume_mounts.%s.mount_path 必须是绝对路径: %q", c.Name, mount.Name, mount.MountPath)
+			}
+			if mountPaths[mount.MountPath] {
+				return fmt.Errorf("容器 %s 的 volume_mounts.%s.mount_path 重复: %s", c.Name, mount.Name, mount.MountPath)
+			}
+			mountPaths[mount.MountPath] = true
+			if path.IsAbs(mount.SubPath) || slices.Contains(strings.Split(mount.SubPath, "/"), "..") {
+				return fmt.Errorf("容器 %s 的 volume_mounts.%s.sub_path 必须是卷内的相对路径: %q", c.Name, mount.Name, mount.SubPath)
+			}
+		}
+	}
+	return nil
+}
+
+// validateVolumeSource 校验卷只配置了一种来源且参数有效
+func validateVolumeSource(volume models.Volume) error {
+	sources := 0
+	if volume.ConfigMap != nil {
+		sources++
+		if volume.ConfigMap.Name == "" {
+			return fmt.Errorf("config_map.name 不能为空")
+		}
+		if err := validateKeyToPaths("config_map", volume.ConfigMap.Items); err != nil {
+			return err
+		}
+	}
+	if volume.Secret != nil {
+		sources++
+		if volume.Secret.SecretName == "" {
+			return fmt.Errorf("secret.secret_name 不能为空")
+		}
+		if err := validateKeyToPaths("secret", volume.Secret.Items); err != nil {
+			return err
+		}
+	}
+	if volume.PersistentVolumeClaim != nil {
+		sources++
+		if volume.PersistentVolumeClaim.ClaimName == "" {
+			return fmt.Errorf("persistent_volume_claim.claim_name 不能为空")
+		}
+	}
+	if volume.EmptyDir != nil {
+		sources++
+		switch corev1.StorageMedium(volume.EmptyDir.Medium) {
+		case corev1.StorageMediumDefault, corev1.StorageMediumMemory:
+		default:
+			return fmt.Errorf("empty_dir.medium 只支持 Memory: %q", volume.EmptyDir.Medium)
+		}
+		if volume.EmptyDir.SizeLimit != "" {
+			if quantity, err := resource.ParseQuantity(volume.EmptyDir.SizeLimit); err != nil || quantity.Sign() < 0 {
+				return fmt.Errorf("empty_dir.size_limit 无效 %q", volume.EmptyDir.SizeLimit)
+			}
+		}
+	}
+	if volume.HostPath != nil {
+		sources++
+		if !path.IsAbs(volume.HostPath.Path) {
+			return fmt.Errorf("host_path.path 必须是绝对路径: %q", volume.HostPath.Path)
+		}
+		switch corev1.HostPathType(volume.HostPath.Type) {
+		case corev1.HostPathUnset, corev1.HostPathDirectoryOrCreate, corev1.HostPathDirectory, corev1.HostPathFileOrCreate,
+			corev1.HostPathFile, corev1.HostPathSocket, corev1.HostPathCharDev, corev1.HostPathBlockDev:
+		default:
+			return fmt.Errorf("host_path.type 无效: %q", volume.HostPath.Type)
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("config_map、secret、persistent_volume_claim、empty_dir 和 host_path 必须且只能配置一个")
+	}
+	return nil
+}
+
+// validateKeyToPaths 校验挂载的键和文件路径
+func validateKeyToPaths(field string, items []models.KeyToPath) error {
+	for _, item := range items {
+		if item.Key == "" {
+			return fmt.Errorf("%s.items.key 不能为空", field)
+		}
+		if item.Path == "" || path.IsAbs(item.Path) || slices.Contains(strings.Split(item.Path, "/"), "..") {
+			return fmt.Errorf("%s.items.%s 的 path 必须是卷内的相对路径: %q", field, item.Key, item.Path)
+		}
+	}
+	return nil
+}
+
+// parseVolumeClaimTemplate 解析持久卷声明模板，返回的错误以字段名开头
+func parseVolumeClaimTemplate(claim models.VolumeClaimTemplate) (corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(claim.Size)
+	if err != nil {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("size 无效 %q: %v", claim.Size, err)
+	}
+	if size.Sign() <= 0 {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("size 必须大于 0: %q", claim.Size)
+	}
+
+	accessModes := []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	if len(claim.AccessModes) > 0 {
+		accessModes = nil
+		for _, mode := range claim.AccessModes {
+			switch corev1.PersistentVolumeAccessMode(mode) {
+			case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany, corev1.ReadWriteOncePod:
+				accessModes = append(accessModes, corev1.PersistentVolumeAccessMode(mode))
+			default:
+				return corev1.PersistentVolumeClaim{}, fmt.Errorf("access_modes 无效: %q", mode)
+			}
+		}
+	}
+
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: claim.Name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if claim.StorageClassName != "" {
+		storageClassName := claim.StorageClassName
+		pvc.Spec.StorageClassName = &storageClassName
+	}
+	return pvc, nil
+}
+
+// 转换持久卷声明模板，模板已由 validateVolumes 校验
+func convertVolumeClaimTemplates(claimTemplates []models.VolumeClaimTemplate) []corev1.PersistentVolumeClaim {
+	var pvcs []corev1.PersistentVolumeClaim
+	for _, claim := range claimTemplates {
+		pvc, _ := parseVolumeClaimTemplate(claim)
+		pvcs = append(pvcs, pvc)
+	}
+	return pvcs
+}
+
+// 转换 Pod 卷
+func convertVolumes(volumes []models.Volume) []corev1.Volume {
+	var k8sVolumes []corev1.Volume
+	for _, volume := range volumes {
+		k8sVolume := corev1.Volume{Name: volume.Name}
+		switch {
+		case volume.ConfigMap != nil:
+			k8sVolume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: volume.ConfigMap.Name},
+				Items:                convertKeyToPaths(volume.ConfigMap.Items),
+				Optional:             optionalBool(volume.ConfigMap.Optional),
+			}
+		case volume.Secret != nil:
+			k8sVolume.Secret = &corev1.SecretVolumeSource{
+				SecretName: volume.Secret.SecretName,
+				Items:      convertKeyToPaths(volume.Secret.Items),
+				Optional:   optionalBool(volume.Secret.Optional),
+			}
+		case volume.PersistentVolumeClaim != nil:
+			k8sVolume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: volume.PersistentVolumeClaim.ClaimName,
+				ReadOnly:  volume.PersistentVolumeClaim.ReadOnly,
+			}
+		case volume.EmptyDir != nil:
+			emptyDir := &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMedium(volume.EmptyDir.Medium)}
+			if volume.EmptyDir.SizeLimit != "" {
+				sizeLimit := resource.MustParse(volume.EmptyDir.SizeLimit)
+				emptyDir.SizeLimit = &sizeLimit
+			}
+			k8sVolume.EmptyDir = emptyDir
+		case volume.HostPath != nil:
+			hostPath := &corev1.HostPathVolumeSource{Path: volume.HostPath.Path}
+			if volume.HostPath.Type != "" {
+				hostPathType := corev1.HostPathType(volume.HostPath.Type)
+				hostPath.Type = &hostPathType
+			}
+			k8sVolume.HostPath = hostPath
+		}
+		k8sVolumes = append(k8sVolumes, k8sVolume)
+	}
+	return k8sVolumes
+}
+
+// 转换挂载的键
+func convertKeyToPaths(items []models.KeyToPath) []corev1.KeyToPath {
+	var k8sItems []corev1.KeyToPath
+	for _, item := range items {
+		k8sItems = append(k8sItems, corev1.KeyToPath{Key: item.Key, Path: item.Path})
+	}
+	return k8sItems
+}
+
+// optionalBool 只在为 true 时设置可选字段
+func optionalBool(value bool) *bool {
+	if !value {
+		return nil
+	}
+	return &value
+}
+
+// 转换卷挂载
+func convertVolumeMounts(mounts []models.VolumeMount) []corev1.VolumeMount {
+	var k8sMounts []corev1.VolumeMount
+	for _, mount := range mounts {
+		k8sMounts = append(k8sMounts, corev1.VolumeMount{
+			Name:      mount.Name,
+			MountPath: mount.MountPath,
+			SubPath:   mount.SubPath,
+			ReadOnly:  mount.ReadOnly,
+		})
+	}
+	return k8sMounts
+}
+
 // 转换为前端期望的格式
 func convertToFrontendFormat(workloads []models.Workload) []map[string]interface{} {
 	result := make([]map[string]interface{}, len(workloads))
 	for i, w := range workloads {
+		spec := map[string]interface{}{
+			"replicas": w.Replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": w.Containers,
+				},
+			},
+		}
+		status := map[string]interface{}{
+			"replicas":          w.Status.CurrentReplicas,
+			"availableReplicas": w.Status.ReadyReplicas,
+			"readyReplicas":     w.Status.ReadyReplicas,
+			"updatedReplicas":   w.Status.CurrentReplicas,
+			"conditions":        w.Status.Conditions,
+		}
+		// Job 和 CronJob 附带运行配置和执行计数
+		if w.Batch != nil {
+			spec["batch"] = w.Batch
+			status["active"] = w.Status.Active
+			status["succeeded"] = w.Status.Succeeded
+			status["failed"] = w.Status.Failed
+			status["lastScheduleTime"] = w.Status.LastScheduleTime
+		}
 		result[i] = map[string]interface{}{
 			"kind": w.Kind,
 			"metadata": map[string]interface{}{
@@ -923,21 +3044,8 @@ func convertToFrontendFormat(workloads []models.Workload) []map[string]interface
 				"namespace": w.Namespace,
 				"labels":    w.Labels,
 			},
-			"spec": map[string]interface{}{
-				"replicas": w.Replicas,
-				"template": map[string]interface{}{
-					"spec": map[string]interface{}{
-						"containers": w.Containers,
-					},
-				},
-			},
-			"status": map[string]interface{}{
-				"replicas":          w.Status.CurrentReplicas,
-				"availableReplicas": w.Status.ReadyReplicas,
-				"readyReplicas":     w.Status.ReadyReplicas,
-				"updatedReplicas":   w.Status.CurrentReplicas,
-				"conditions":        w.Status.Conditions,
-			},
+			"spec":   spec,
+			"status": status,
 		}
 	}
 	return result
@@ -0,0 +1,1244 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// deploymentRequest 构造创建 Deployment 的请求体
+func deploymentRequest(name string) gin.H {
+	return gin.H{
+		"metadata": gin.H{"name": name},
+		"spec": gin.H{
+			"template": gin.H{"spec": gin.H{"containers": []models.Container{{Name: "nginx", Image: "nginx:1.25"}}}},
+			"selector": gin.H{"matchLabels": []gin.H{{"key": "app", "value": name}}},
+		},
+	}
+}
+
+func TestCreateDeployment(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+
+	w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default", cluster.ID), deploymentRequest("web"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Image != "nginx:1.25" {
+		t.Fatalf("容器镜像不符合预期: %s", deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	var workload models.Workload
+	if err := db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload).Error; err != nil {
+		t.Fatalf("工作负载未写入数据库: %v", err)
+	}
+	if workload.Labels["app"] != "web" {
+		t.Fatalf("工作负载标签不符合预期: %v", workload.Labels)
+	}
+}
+
+func TestScaleWorkload(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+
+	w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default", cluster.ID), deploymentRequest("web"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 Deployment 失败: %s", w.Body.String())
+	}
+
+	w = doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web/scale", cluster.ID), gin.H{"replicas": 3})
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	if *deployment.Spec.Replicas != 3 {
+		t.Fatalf("期望副本数 3，实际 %d", *deployment.Spec.Replicas)
+	}
+
+	var workload models.Workload
+	db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload)
+	if workload.Replicas != 3 {
+		t.Fatalf("数据库副本数未更新: %d", workload.Replicas)
+	}
+}
+
+func TestScaleDaemonSetRejected(t *testing.T) {
+	r, db, _ := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+
+	w := doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/workloads/DaemonSet/default/agent/scale", cluster.ID), gin.H{"replicas": 3})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestDeleteWorkload(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+	})
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID})
+
+	w := doRequest(t, r, http.MethodDelete, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	_, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("Deployment 应已被删除，实际错误: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.Workload{}).Where("cluster_id = ? AND name = ?", cluster.ID, "web").Count(&count)
+	if count != 0 {
+		t.Fatalf("数据库记录应已被删除")
+	}
+
+	if clients.Client(cluster.ID) != clientset {
+		t.Fatalf("删除工作负载不应影响集群客户端")
+	}
+}
+
+// testDeployment 构造只包含一个容器的 Deployment
+func testDeployment(name, image string, replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app": name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+			},
+		},
+	}
+}
+
+func TestGetWorkloadDrift(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clients.AddClient(cluster.ID, testDeployment("web", "nginx:1.25", 2), testDeployment("api", "api:v1", 1))
+
+	for _, workload := range []models.Workload{
+		{Name: "web", Replicas: 3, Containers: models.Containers{{Name: "app", Image: "nginx:1.14"}}},
+		{Name: "api", Replicas: 1, Containers: models.Containers{{Name: "app", Image: "api:v1"}}},
+		{Name: "gone", Replicas: 1, Containers: models.Containers{{Name: "app", Image: "gone:v1"}}},
+	} {
+		workload.Kind = "Deployment"
+		workload.Namespace = "default"
+		workload.ClusterID = cluster.ID
+		workload.Labels = models.StringMap{"app": workload.Name}
+		db.Create(&workload)
+	}
+
+	w := doRequest(t, r, http.MethodGet, fmt.Sprintf("/api/v1/clusters/%d/workloads/drift", cluster.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Result    utils.ReconcileResult `json:"result"`
+		Workloads []models.Workload     `json:"workloads"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Result != (utils.ReconcileResult{Checked: 3, Drifted: 1, Missing: 1}) {
+		t.Fatalf("对比结果不符合预期: %+v", resp.Result)
+	}
+	if len(resp.Workloads) != 2 {
+		t.Fatalf("期望 2 个不一致的工作负载，实际 %d", len(resp.Workloads))
+	}
+
+	web := resp.Workloads[0]
+	if web.Name != "web" || len(web.Drift) != 2 {
+		t.Fatalf("web 的差异不符合预期: %+v", web.Drift)
+	}
+	if web.Drift[0].Field != utils.DriftImage || web.Drift[0].Actual != "nginx:1.25" ||
+		web.Drift[1].Field != utils.DriftReplicas || web.Drift[1].Actual != "2" {
+		t.Fatalf("web 的差异不符合预期: %+v", web.Drift)
+	}
+	if gone := resp.Workloads[1]; gone.Name != "gone" || !gone.Missing {
+		t.Fatalf("gone 应被标记为不存在: %+v", gone)
+	}
+}
+
+func TestSyncWorkloads(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	agent := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "kube-system"},
+		Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "agent", Image: "agent:v1"}}},
+		}},
+	}
+	web := testDeployment("web", "nginx:1.25", 2)
+	web.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}", "team": "infra"}
+	web.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "PORT", Value: "80"}}
+	clients.AddClient(cluster.ID, web, testDeployment("api", "api:v1", 1), agent)
+	db.Create(&models.Workload{Name: "old", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID})
+
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID)
+	sync := func(body interface{}) utils.SyncResult {
+		t.Helper()
+		w := doRequest(t, r, http.MethodPost, path, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var result utils.SyncResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return result
+	}
+
+	if result := sync(nil); result != (utils.SyncResult{Created: 3, Removed: 1}) {
+		t.Fatalf("首次同步结果不符合预期: %+v", result)
+	}
+	if result := sync(nil); result != (utils.SyncResult{Unchanged: 3}) {
+		t.Fatalf("重复同步不应修改记录: %+v", result)
+	}
+	if result := sync(gin.H{"namespaces": []string{"kube-system"}}); result != (utils.SyncResult{Unchanged: 1}) {
+		t.Fatalf("按命名空间同步结果不符合预期: %+v", result)
+	}
+
+	var workload models.Workload
+	db.Where("cluster_id = ? AND kind = ? AND name = ?", cluster.ID, "Deployment", "web").First(&workload)
+	if workload.Replicas != 2 || workload.Labels["app"] != "web" || workload.Status.DesiredReplicas != 2 {
+		t.Fatalf("工作负载记录不符合预期: %+v", workload)
+	}
+	if _, exists := workload.Annotations["kubectl.kubernetes.io/last-applied-configuration"]; exists || workload.Annotations["team"] != "infra" {
+		t.Fatalf("注解不符合预期: %+v", workload.Annotations)
+	}
+	if len(workload.Containers) != 1 || workload.Containers[0].Image != "nginx:1.25" || workload.Containers[0].Env[0].Value != "80" {
+		t.Fatalf("容器配置不符合预期: %+v", workload.Containers)
+	}
+}
+
+func TestUpdateSyncedWorkloadKeepsSelector(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	web := testDeployment("web", "nginx:1.25", 2)
+	web.Labels = map[string]string{"app": "web", "team": "infra"}
+	web.Spec.Template.Labels = map[string]string{"app": "web", "version": "v1"}
+	clientset := clients.AddClient(cluster.ID, web)
+	// 与 API Server 一样拒绝修改选择器
+	clientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deployment := action.(k8stesting.UpdateAction).GetObject().(*appsv1.Deployment)
+		if !reflect.DeepEqual(deployment.Spec.Selector, web.Spec.Selector) {
+			return true, nil, apierrors.NewInvalid(appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind(), "web",
+				field.ErrorList{field.Invalid(field.NewPath("spec", "selector"), deployment.Spec.Selector, "field is immutable")})
+		}
+		return false, nil, nil
+	})
+
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var workload models.Workload
+	db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload)
+	if workload.Selector["app"] != "web" || len(workload.Selector) != 1 || workload.TemplateLabels["version"] != "v1" {
+		t.Fatalf("应分别记录选择器和 Pod 模板标签: %+v %+v", workload.Selector, workload.TemplateLabels)
+	}
+
+	body := gin.H{"labels": gin.H{"app": "web", "team": "platform"}}
+	w := doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	updated, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if updated.Labels["team"] != "platform" || !reflect.DeepEqual(updated.Spec.Template.Labels, web.Spec.Template.Labels) {
+		t.Fatalf("更新后的标签不符合预期: %v %v", updated.Labels, updated.Spec.Template.Labels)
+	}
+}
+
+func TestUpdateWorkloadKeepsUnmodeledFields(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	web := testDeployment("web", "nginx:1.25", 2)
+	podSpec := &web.Spec.Template.Spec
+	podSpec.ServiceAccountName = "web"
+	podSpec.NodeSelector = map[string]string{"disk": "ssd"}
+	podSpec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}
+	podSpec.InitContainers = []corev1.Container{{Name: "init", Image: "busybox"}}
+	podSpec.Volumes = []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{}}}}
+	podSpec.Containers[0].Command = []string{"nginx", "-g", "daemon off;"}
+	podSpec.Containers[0].Args = []string{"--debug"}
+	clientset := clients.AddClient(cluster.ID, web)
+	// 第一次更新返回冲突，应读取最新的对象后重试
+	conflicted := false
+	clientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !conflicted {
+			conflicted = true
+			return true, nil, apierrors.NewConflict(appsv1.Resource("deployments"), "web", fmt.Errorf("对象已被修改"))
+		}
+		return false, nil, nil
+	})
+
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	body := gin.H{"replicas": 3, "containers": []models.Container{{Name: "app", Image: "nginx:1.26"}}}
+	w := doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	updated, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	spec := updated.Spec.Template.Spec
+	if *updated.Spec.Replicas != 3 || spec.Containers[0].Image != "nginx:1.26" {
+		t.Fatalf("副本数或镜像未更新: %d %s", *updated.Spec.Replicas, spec.Containers[0].Image)
+	}
+	if !reflect.DeepEqual(spec.Containers[0].Command, podSpec.Containers[0].Command) || !reflect.DeepEqual(spec.Containers[0].Args, podSpec.Containers[0].Args) {
+		t.Fatalf("容器的 command 和 args 应保持不变: %v %v", spec.Containers[0].Command, spec.Containers[0].Args)
+	}
+	if spec.ServiceAccountName != "web" || spec.NodeSelector["disk"] != "ssd" || len(spec.Tolerations) != 1 || len(spec.InitContainers) != 1 {
+		t.Fatalf("Pod 模板中记录未覆盖的字段应保持不变: %+v", spec)
+	}
+	if len(spec.Volumes) != 1 || spec.Volumes[0].Projected == nil {
+		t.Fatalf("记录不支持的卷应保持不变: %+v", spec.Volumes)
+	}
+}
+
+func TestCreateDeploymentResources(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default", cluster.ID)
+
+	body := deploymentRequest("gpu")
+	body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []models.Container{{
+		Name:     "trainer",
+		Image:    "trainer:v1",
+		Requests: models.ResourceList{CPU: "500m", Memory: "1Gi", EphemeralStorage: "2Gi"},
+		Limits:   models.ResourceList{CPU: "2", Memory: "4Gi", Extended: map[string]string{"nvidia.com/gpu": "1"}},
+	}}}}
+	w := doRequest(t, r, http.MethodPost, path, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "gpu", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	resources := deployment.Spec.Template.Spec.Containers[0].Resources
+	if resources.Requests.Cpu().String() != "500m" || resources.Requests.StorageEphemeral().String() != "2Gi" {
+		t.Fatalf("requests 不符合预期: %v", resources.Requests)
+	}
+	if gpu := resources.Limits[corev1.ResourceName("nvidia.com/gpu")]; gpu.String() != "1" || resources.Limits.Memory().String() != "4Gi" {
+		t.Fatalf("limits 不符合预期: %v", resources.Limits)
+	}
+
+	tests := []struct {
+		name      string
+		container models.Container
+		want      string
+	}{
+		{"bad cpu", models.Container{Name: "app", Requests: models.ResourceList{CPU: "lots"}}, "容器 app 的 requests.cpu"},
+		{"negative memory", models.Container{Name: "app", Limits: models.ResourceList{Memory: "-1Gi"}}, "容器 app 的 limits.memory"},
+		{"bad extended", models.Container{Name: "app", Limits: models.ResourceList{Extended: map[string]string{"nvidia.com/gpu": "x"}}}, "容器 app 的 limits.extended.nvidia.com/gpu"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := deploymentRequest("invalid")
+			body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []models.Container{tt.container}}}
+			w := doRequest(t, r, http.MethodPost, path, body)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Fatalf("期望 400 且包含 %q，实际 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWorkloadProbesRoundTrip(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default", cluster.ID)
+
+	container := models.Container{
+		Name:  "app",
+		Image: "app:v1",
+		LivenessProbe: &models.Probe{
+			HTTPGet: &models.HTTPGetAction{
+				Path:        "/healthz",
+				Port:        intstr.FromString("http"),
+				Scheme:      "HTTP",
+				HTTPHeaders: []models.HTTPHeader{{Name: "X-Probe", Value: "kaiops"}},
+			},
+			InitialDelaySeconds: 10,
+			FailureThreshold:    3,
+		},
+		ReadinessProbe: &models.Probe{TCPSocket: &models.TCPSocketAction{Port: intstr.FromInt32(8080)}, SuccessThreshold: 2},
+		StartupProbe:   &models.Probe{GRPC: &models.GRPCAction{Port: 9090, Service: "health"}, PeriodSeconds: 5},
+	}
+	body := deploymentRequest("probe")
+	body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []models.Container{container}}}
+	w := doRequest(t, r, http.MethodPost, path, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "probe", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	live := deployment.Spec.Template.Spec.Containers[0]
+	if live.LivenessProbe == nil || live.LivenessProbe.HTTPGet.Port.StrVal != "http" || live.LivenessProbe.InitialDelaySeconds != 10 {
+		t.Fatalf("存活探针不符合预期: %+v", live.LivenessProbe)
+	}
+	if live.StartupProbe == nil || live.StartupProbe.GRPC == nil || *live.StartupProbe.GRPC.Service != "health" {
+		t.Fatalf("启动探针不符合预期: %+v", live.StartupProbe)
+	}
+
+	// 从集群导入的容器配置与创建时一致
+	imported := utils.ContainersFromPodSpec(deployment.Spec.Template.Spec)
+	if !reflect.DeepEqual(imported[0], container) {
+		t.Fatalf("导入的容器配置不一致:\n%+v\n%+v", imported[0], container)
+	}
+
+	w = doRequest(t, r, http.MethodGet, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/probe", cluster.ID), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var workload models.Workload
+	if err := json.Unmarshal(w.Body.Bytes(), &workload); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if !reflect.DeepEqual(workload.Containers[0], container) {
+		t.Fatalf("工作负载的容器配置不一致:\n%+v\n%+v", workload.Containers[0], container)
+	}
+
+	tests := []struct {
+		name  string
+		probe models.Probe
+		want  string
+	}{
+		{"no handler", models.Probe{PeriodSeconds: 5}, "容器 app 的 liveness_probe.http_get、tcp_socket、exec 和 grpc 必须且只能配置一个"},
+		{"two handlers", models.Probe{Exec: &models.ExecAction{Command: []string{"true"}}, GRPC: &models.GRPCAction{Port: 9090}}, "必须且只能配置一个"},
+		{"bad port", models.Probe{TCPSocket: &models.TCPSocketAction{Port: intstr.FromInt32(70000)}}, "容器 app 的 liveness_probe.tcp_socket.port"},
+		{"success threshold", models.Probe{Exec: &models.ExecAction{Command: []string{"true"}}, SuccessThreshold: 2}, "容器 app 的 liveness_probe.success_threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := deploymentRequest("invalid")
+			body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": []models.Container{{Name: "app", Image: "app:v1", LivenessProbe: &tt.probe}}}}
+			w := doRequest(t, r, http.MethodPost, path, body)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Fatalf("期望 400 且包含 %q，实际 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateStatefulSetVolumes(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/statefulsets/default", cluster.ID)
+
+	containers := []models.Container{{
+		Name:  "db",
+		Image: "postgres:16",
+		VolumeMounts: []models.VolumeMount{
+			{Name: "data", MountPath: "/var/lib/postgresql/data"},
+			{Name: "config", MountPath: "/etc/postgresql/postgresql.conf", SubPath: "postgresql.conf", ReadOnly: true},
+			{Name: "tls", MountPath: "/etc/tls", ReadOnly: true},
+			{Name: "shm", MountPath: "/dev/shm"},
+		},
+	}}
+	volumes := []models.Volume{
+		{Name: "config", ConfigMap: &models.ConfigMapVolumeSource{Name: "pg-config", Items: []models.KeyToPath{{Key: "postgresql.conf", Path: "postgresql.conf"}}}},
+		{Name: "tls", Secret: &models.SecretVolumeSource{SecretName: "pg-tls", Optional: true}},
+		{Name: "shm", EmptyDir: &models.EmptyDirVolumeSource{Medium: "Memory", SizeLimit: "256Mi"}},
+	}
+	claims := []models.VolumeClaimTemplate{{Name: "data", StorageClassName: "fast", Size: "20Gi"}}
+
+	body := deploymentRequest("pg")
+	body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": containers, "volumes": volumes}}
+	body["spec"].(gin.H)["volumeClaimTemplates"] = claims
+	w := doRequest(t, r, http.MethodPost, path, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	statefulSet, err := clientset.AppsV1().StatefulSets("default").Get(context.Background(), "pg", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 StatefulSet 失败: %v", err)
+	}
+	spec := statefulSet.Spec.Template.Spec
+	if len(spec.Volumes) != 3 || spec.Volumes[0].ConfigMap.Name != "pg-config" || *spec.Volumes[1].Secret.Optional != true ||
+		spec.Volumes[2].EmptyDir.SizeLimit.String() != "256Mi" {
+		t.Fatalf("Pod 卷不符合预期: %+v", spec.Volumes)
+	}
+	if mounts := spec.Containers[0].VolumeMounts; len(mounts) != 4 || mounts[1].SubPath != "postgresql.conf" || !mounts[1].ReadOnly {
+		t.Fatalf("卷挂载不符合预期: %+v", mounts)
+	}
+	pvc := statefulSet.Spec.VolumeClaimTemplates[0]
+	if pvc.Name != "data" || *pvc.Spec.StorageClassName != "fast" || pvc.Spec.Resources.Requests.Storage().String() != "20Gi" ||
+		pvc.Spec.AccessModes[0] != corev1.ReadWriteOnce {
+		t.Fatalf("持久卷声明模板不符合预期: %+v", pvc)
+	}
+
+	// 从集群导入的卷配置与创建时一致
+	imported := utils.StatefulSetToWorkload(statefulSet)
+	claims[0].AccessModes = []string{"ReadWriteOnce"}
+	if !reflect.DeepEqual([]models.Volume(imported.Volumes), volumes) || !reflect.DeepEqual([]models.VolumeClaimTemplate(imported.VolumeClaimTemplates), claims) {
+		t.Fatalf("导入的卷配置不一致: %+v %+v", imported.Volumes, imported.VolumeClaimTemplates)
+	}
+	if !reflect.DeepEqual(imported.Containers[0].VolumeMounts, containers[0].VolumeMounts) {
+		t.Fatalf("导入的卷挂载不一致: %+v", imported.Containers[0].VolumeMounts)
+	}
+
+	tests := []struct {
+		name       string
+		kindPath   string
+		containers []models.Container
+		volumes    []models.Volume
+		claims     []models.VolumeClaimTemplate
+		want       string
+	}{
+		{"unknown mount", "statefulsets", []models.Container{{Name: "app", VolumeMounts: []models.VolumeMount{{Name: "missing", MountPath: "/data"}}}}, nil, nil, "容器 app 的 volume_mounts.missing 引用的卷不存在"},
+		{"relative mount path", "statefulsets", []models.Container{{Name: "app", VolumeMounts: []models.VolumeMount{{Name: "tmp", MountPath: "data"}}}}, []models.Volume{{Name: "tmp", EmptyDir: &models.EmptyDirVolumeSource{}}}, nil, "容器 app 的 volume_mounts.tmp.mount_path"},
+		{"two sources", "statefulsets", nil, []models.Volume{{Name: "tmp", EmptyDir: &models.EmptyDirVolumeSource{}, HostPath: &models.HostPathVolumeSource{Path: "/tmp"}}}, nil, "volumes.tmp.config_map、secret"},
+		{"relative host path", "statefulsets", nil, []models.Volume{{Name: "logs", HostPath: &models.HostPathVolumeSource{Path: "var/log"}}}, nil, "volumes.logs.host_path.path"},
+		{"bad claim size", "statefulsets", nil, nil, []models.VolumeClaimTemplate{{Name: "data", Size: "lots"}}, "volume_claim_templates.data.size"},
+		{"claims on deployment", "deployments", nil, nil, []models.VolumeClaimTemplate{{Name: "data", Size: "1Gi"}}, "只有 StatefulSet 支持 volumeClaimTemplates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := deploymentRequest("invalid")
+			body["spec"].(gin.H)["template"] = gin.H{"spec": gin.H{"containers": tt.containers, "volumes": tt.volumes}}
+			body["spec"].(gin.H)["volumeClaimTemplates"] = tt.claims
+			w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/%s/default", cluster.ID, tt.kindPath), body)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Fatalf("期望 400 且包含 %q，实际 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// testReplicaSet 构造属于 Deployment 的指定版本的 ReplicaSet
+func testReplicaSet(deployment *appsv1.Deployment, revision, image, changeCause string) *appsv1.ReplicaSet {
+	template := *deployment.Spec.Template.DeepCopy()
+	template.Labels = map[string]string{"app": deployment.Name, appsv1.DefaultDeploymentUniqueLabelKey: "hash-" + revision}
+	template.Spec.Containers[0].Image = image
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment.Name + "-" + revision,
+			Namespace:       deployment.Namespace,
+			Labels:          template.Labels,
+			Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision, utils.ChangeCauseAnnotation: changeCause},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+		Spec: appsv1.ReplicaSetSpec{Selector: deployment.Spec.Selector, Template: template},
+	}
+}
+
+func TestWorkloadRevisionsAndRollback(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	web := testDeployment("web", "nginx:1.25", 2)
+	web.UID = "web-uid"
+	orphan := testReplicaSet(testDeployment("web", "nginx:1.23", 1), "7", "nginx:1.23", "")
+	orphan.Name = "web-orphan"
+	orphan.OwnerReferences = nil
+	clientset := clients.AddClient(cluster.ID, web,
+		testReplicaSet(web, "1", "nginx:1.24", "initial release"),
+		testReplicaSet(web, "2", "nginx:1.25", "upgrade nginx"),
+		orphan,
+	)
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID, Replicas: 2,
+		Containers: models.Containers{{Name: "app", Image: "nginx:1.25"}}})
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID)
+
+	w := doRequest(t, r, http.MethodGet, base+"/revisions", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var history struct {
+		Revisions []utils.WorkloadRevision `json:"revisions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(history.Revisions) != 2 || history.Revisions[0].Revision != 2 || !history.Revisions[0].Current ||
+		history.Revisions[1].Images["app"] != "nginx:1.24" || history.Revisions[1].ChangeCause != "initial release" {
+		t.Fatalf("版本历史不符合预期: %+v", history.Revisions)
+	}
+
+	if w := doRequest(t, r, http.MethodPost, base+"/rollback", gin.H{"revision": 9}); w.Code != http.StatusNotFound {
+		t.Fatalf("回滚到不存在的版本期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 未指定版本时回滚到上一个版本
+	w = doRequest(t, r, http.MethodPost, base+"/rollback", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Deployment 失败: %v", err)
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.24" {
+		t.Fatalf("回滚后的镜像不符合预期: %s", image)
+	}
+	if _, exists := deployment.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; exists {
+		t.Fatalf("回滚后的模板不应包含 pod-template-hash: %v", deployment.Spec.Template.Labels)
+	}
+	if deployment.Annotations[utils.ChangeCauseAnnotation] != "initial release" {
+		t.Fatalf("回滚后的变更原因不符合预期: %v", deployment.Annotations)
+	}
+
+	var workload models.Workload
+	db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload)
+	if workload.Containers[0].Image != "nginx:1.24" || workload.LastRollback == nil ||
+		workload.LastRollback.FromRevision != 2 || workload.LastRollback.ToRevision != 1 {
+		t.Fatalf("工作负载的回滚记录不符合预期: %+v %+v", workload.Containers, workload.LastRollback)
+	}
+}
+
+func TestStatefulSetRevisions(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	labels := map[string]string{"app": "pg"}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "pg", Namespace: "default", UID: "pg-uid"},
+		Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	revision := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pg-5d8f",
+			Namespace:       "default",
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(statefulSet, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))},
+		},
+		Data:     runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"db","image":"postgres:16"}]}}}}`)},
+		Revision: 3,
+	}
+	clients.AddClient(cluster.ID, statefulSet, revision)
+
+	w := doRequest(t, r, http.MethodGet, fmt.Sprintf("/api/v1/clusters/%d/workloads/StatefulSet/default/pg/revisions", cluster.ID), nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"images":{"db":"postgres:16"}`) || !strings.Contains(w.Body.String(), `"revision":3`) {
+		t.Fatalf("StatefulSet 版本历史不符合预期，状态码 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRestartPauseResumeWorkload(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID, testDeployment("web", "nginx:1.25", 2))
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID)
+
+	getDeployment := func() *appsv1.Deployment {
+		t.Helper()
+		deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("获取 Deployment 失败: %v", err)
+		}
+		return deployment
+	}
+
+	clientset.ClearActions()
+	if w := doRequest(t, r, http.MethodPost, base+"/restart", nil); w.Code != http.StatusOK {
+		t.Fatalf("重启期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if getDeployment().Spec.Template.Annotations[utils.RestartedAtAnnotation] == "" {
+		t.Fatalf("重启后 Pod 模板缺少 %s 注解", utils.RestartedAtAnnotation)
+	}
+
+	if w := doRequest(t, r, http.MethodPost, base+"/pause", nil); w.Code != http.StatusOK {
+		t.Fatalf("暂停期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !getDeployment().Spec.Paused {
+		t.Fatal("暂停后 spec.paused 应为 true")
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/resume", nil); w.Code != http.StatusOK {
+		t.Fatalf("恢复期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	deployment := getDeployment()
+	if deployment.Spec.Paused || deployment.Spec.Template.Annotations[utils.RestartedAtAnnotation] == "" {
+		t.Fatalf("恢复后的 Deployment 不符合预期: paused=%v annotations=%v", deployment.Spec.Paused, deployment.Spec.Template.Annotations)
+	}
+
+	// 三个操作都只使用补丁
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" {
+			t.Fatalf("不应使用 Update 修改工作负载: %+v", action)
+		}
+	}
+
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/StatefulSet/default/web/pause", cluster.ID), nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("暂停 StatefulSet 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/missing/restart", cluster.ID), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("重启不存在的工作负载期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdatePausedDeploymentKeepsRolloutState(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID, testDeployment("web", "nginx:1.25", 2))
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/web", cluster.ID)
+
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	for _, action := range []string{"/restart", "/pause"} {
+		if w := doRequest(t, r, http.MethodPost, base+action, nil); w.Code != http.StatusOK {
+			t.Fatalf("%s 期望状态码 %d，实际 %d: %s", action, http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+	before, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	restartedAt := before.Spec.Template.Annotations[utils.RestartedAtAnnotation]
+
+	// 暂停期间修改镜像，恢复前不应触发滚动更新，也不应丢失重启时间
+	body := gin.H{"containers": []models.Container{{Name: "app", Image: "nginx:1.26"}}}
+	if w := doRequest(t, r, http.MethodPut, base, body); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	after, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if !after.Spec.Paused {
+		t.Fatal("更新后 spec.paused 应保持为 true")
+	}
+	if restartedAt == "" || after.Spec.Template.Annotations[utils.RestartedAtAnnotation] != restartedAt {
+		t.Fatalf("更新后应保留 %s 注解: %v", utils.RestartedAtAnnotation, after.Spec.Template.Annotations)
+	}
+	if after.Spec.Template.Spec.Containers[0].Image != "nginx:1.26" {
+		t.Fatalf("镜像未更新: %s", after.Spec.Template.Spec.Containers[0].Image)
+	}
+}
+
+func TestGetRolloutStatus(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+
+	complete := testDeployment("web", "nginx:1.25", 2)
+	complete.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2,
+		Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"}}}
+	stuck := testDeployment("stuck", "nginx:bad", 2)
+	stuck.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 1,
+		Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}}}
+	slow := testDeployment("slow", "nginx:1.25", 3)
+	slow.Status = appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 1}
+	clients.AddClient(cluster.ID, complete, stuck, slow)
+	db.Create(&models.Workload{Name: "web", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID, Replicas: 2})
+
+	path := func(name, query string) string {
+		return fmt.Sprintf("/api/v1/clusters/%d/workloads/Deployment/default/%s/rollout-status%s", cluster.ID, name, query)
+	}
+
+	w := doRequest(t, r, http.MethodGet, path("web", ""), nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "event:complete") {
+		t.Fatalf("期望滚动更新完成，实际 %d: %s", w.Code, w.Body.String())
+	}
+	var workload models.Workload
+	db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload)
+	if len(workload.Status.Conditions) != 1 || workload.Status.Conditions[0].Reason != "MinimumReplicasAvailable" {
+		t.Fatalf("工作负载的状态条件不符合预期: %+v", workload.Status)
+	}
+
+	if w := doRequest(t, r, http.MethodGet, path("stuck", ""), nil); !strings.Contains(w.Body.String(), "event:failed") ||
+		!strings.Contains(w.Body.String(), "exceeded its progress deadline") {
+		t.Fatalf("期望滚动更新失败，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, r, http.MethodGet, path("slow", "?timeout=1500ms"), nil)
+	if body := w.Body.String(); !strings.Contains(body, "event:progress") || !strings.Contains(body, "1 of 3 updated replicas are available") ||
+		!strings.Contains(body, "event:timeout") {
+		t.Fatalf("期望推送进度后超时，实际 %d: %s", w.Code, body)
+	}
+
+	if w := doRequest(t, r, http.MethodGet, path("slow", "?timeout=soon"), nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("timeout 格式错误期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodGet, path("missing", ""), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("工作负载不存在期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestScaleWorkloadSubresource(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	web := testDeployment("web", "nginx:1.25", 2)
+	web.ResourceVersion = "5"
+	replicas := int32(1)
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "default"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}}},
+	}
+	clientset := clients.AddClient(cluster.ID, web, rs)
+	path := func(kind, name string) string {
+		return fmt.Sprintf("/api/v1/clusters/%d/workloads/%s/default/%s/scale", cluster.ID, kind, name)
+	}
+
+	// 第一次更新返回冲突，应读取最新的 scale 后重试
+	conflicts := 0
+	clientset.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "scale" && conflicts == 0 {
+			conflicts++
+			return true, nil, apierrors.NewConflict(appsv1.Resource("deployments"), "web", fmt.Errorf("对象已被修改"))
+		}
+		return false, nil, nil
+	})
+	w := doRequest(t, r, http.MethodPut, path("Deployment", "web"), gin.H{"replicas": 4})
+	if w.Code != http.StatusOK || conflicts != 1 {
+		t.Fatalf("期望冲突后重试成功，实际 %d（冲突 %d 次）: %s", w.Code, conflicts, w.Body.String())
+	}
+	var status utils.ScaleStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if status.Replicas != 4 || status.Selector != "app=web" {
+		t.Fatalf("scale 状态不符合预期: %+v", status)
+	}
+
+	// 前置条件不满足时不重试，直接返回 409
+	if w := doRequest(t, r, http.MethodPut, path("Deployment", "web"), gin.H{"replicas": 1, "resourceVersion": "4"}); w.Code != http.StatusConflict {
+		t.Fatalf("resourceVersion 不匹配期望 409，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPut, path("Deployment", "web"), gin.H{"replicas": 1, "resourceVersion": "5"}); w.Code != http.StatusOK {
+		t.Fatalf("resourceVersion 匹配期望 200，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(t, r, http.MethodPut, path("ReplicaSet", "batch"), gin.H{"replicas": 3}); w.Code != http.StatusOK {
+		t.Fatalf("ReplicaSet 扩缩容期望 200，实际 %d: %s", w.Code, w.Body.String())
+	}
+	scaled, err := clientset.AppsV1().ReplicaSets("default").Get(context.Background(), "batch", metav1.GetOptions{})
+	if err != nil || *scaled.Spec.Replicas != 3 {
+		t.Fatalf("ReplicaSet 副本数未更新: %v", err)
+	}
+
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() != "scale" {
+			t.Fatalf("扩缩容不应更新整个对象: %+v", action)
+		}
+	}
+
+	if w := doRequest(t, r, http.MethodPut, path("Deployment", "missing"), gin.H{"replicas": 1}); w.Code != http.StatusNotFound {
+		t.Fatalf("工作负载不存在期望 404，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPut, path("Deployment", "web"), gin.H{"replicas": -1}); w.Code != http.StatusBadRequest {
+		t.Fatalf("副本数为负数期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWorkloadDryRun(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID, testDeployment("web", "nginx:1.25", 2))
+	db.Create(&models.Workload{
+		Name: "web", Kind: "Deployment", Namespace: "default", ClusterID: cluster.ID, Replicas: 2,
+		Labels:     models.StringMap{"app": "web"},
+		Containers: models.Containers{{Name: "app", Image: "nginx:1.25"}},
+	})
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads", cluster.ID)
+
+	decode := func(w *httptest.ResponseRecorder) utils.WorkloadPreview {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var preview struct {
+			utils.WorkloadPreview
+			Object json.RawMessage `json:"object"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if !preview.DryRun {
+			t.Fatalf("响应应标记为试运行: %s", w.Body.String())
+		}
+		return preview.WorkloadPreview
+	}
+
+	// 创建试运行不写入集群和数据库
+	preview := decode(doRequest(t, r, http.MethodPost, base+"/deployments/default?dryRun=true", deploymentRequest("api")))
+	if !strings.Contains(preview.Diff, "+  name: api") {
+		t.Fatalf("创建预览应包含新对象: %s", preview.Diff)
+	}
+	if _, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("试运行不应创建 Deployment: %v", err)
+	}
+	var count int64
+	db.Model(&models.Workload{}).Where("cluster_id = ? AND name = ?", cluster.ID, "api").Count(&count)
+	if count != 0 {
+		t.Fatalf("试运行不应写入数据库")
+	}
+
+	// 更新试运行返回字段变更和统一格式差异
+	preview = decode(doRequest(t, r, http.MethodPut, base+"/Deployment/default/web?dryRun=true", gin.H{
+		"replicas":   2,
+		"containers": []models.Container{{Name: "app", Image: "nginx:1.26"}},
+	}))
+	var imageChange *utils.FieldChange
+	for i, change := range preview.Changes {
+		if change.Path == "spec.template.spec.containers[0].image" {
+			imageChange = &preview.Changes[i]
+		}
+	}
+	if imageChange == nil || imageChange.Op != utils.ChangeReplace || imageChange.Old != "nginx:1.25" || imageChange.New != "nginx:1.26" {
+		t.Fatalf("镜像变更不符合预期: %+v", preview.Changes)
+	}
+	if !strings.Contains(preview.Diff, "-      - image: nginx:1.25\n+      - image: nginx:1.26\n") {
+		t.Fatalf("统一格式差异不符合预期: %s", preview.Diff)
+	}
+	deployment, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if deployment.Spec.Template.Spec.Containers[0].Image != "nginx:1.25" {
+		t.Fatalf("试运行不应修改集群中的对象: %s", deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+	var workload models.Workload
+	db.Where("cluster_id = ? AND name = ?", cluster.ID, "web").First(&workload)
+	if workload.Containers[0].Image != "nginx:1.25" {
+		t.Fatalf("试运行不应修改数据库记录: %s", workload.Containers[0].Image)
+	}
+
+	// 扩缩容和暂停试运行
+	preview = decode(doRequest(t, r, http.MethodPut, base+"/Deployment/default/web/scale?dryRun=true", gin.H{"replicas": 5}))
+	if len(preview.Changes) != 1 || preview.Changes[0].Path != "spec.replicas" {
+		t.Fatalf("扩缩容预览不符合预期: %+v", preview.Changes)
+	}
+	preview = decode(doRequest(t, r, http.MethodPost, base+"/Deployment/default/web/pause?dryRun=true", nil))
+	if len(preview.Changes) != 1 || preview.Changes[0].Path != "spec.paused" || preview.Changes[0].Op != utils.ChangeAdd {
+		t.Fatalf("暂停预览不符合预期: %+v", preview.Changes)
+	}
+	deployment, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if *deployment.Spec.Replicas != 2 || deployment.Spec.Paused {
+		t.Fatalf("试运行不应修改集群中的对象: %+v", deployment.Spec)
+	}
+
+	// 删除试运行保留对象和记录
+	preview = decode(doRequest(t, r, http.MethodDelete, base+"/Deployment/default/web?dryRun=true", nil))
+	if !strings.Contains(preview.Diff, "-  name: web") {
+		t.Fatalf("删除预览应包含被删除的对象: %s", preview.Diff)
+	}
+	if _, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Fatalf("试运行不应删除 Deployment: %v", err)
+	}
+
+	if w := doRequest(t, r, http.MethodDelete, base+"/Deployment/default/web?dryRun=maybe", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestWorkloadDryRunValidationError(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	clientset.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInvalid(schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}, "web", field.ErrorList{
+			field.Forbidden(field.NewPath("spec", "template", "spec", "hostNetwork"), "denied by policy"),
+		})
+	})
+
+	w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/deployments/default?dryRun=true", cluster.ID), deploymentRequest("web"))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Causes []metav1.StatusCause `json:"causes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(resp.Causes) != 1 || resp.Causes[0].Field != "spec.template.spec.hostNetwork" {
+		t.Fatalf("校验错误不符合预期: %+v", resp.Causes)
+	}
+}
+
+// testCronJobJob 构造由 CronJob 创建的 Job
+func testCronJobJob(cronJob *batchv1.CronJob, name string, created time.Time, succeeded, failed int32) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         cronJob.Namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			OwnerReferences:   []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Status: batchv1.JobStatus{Succeeded: succeeded, Failed: failed},
+	}
+	condition := batchv1.JobComplete
+	if failed > 0 {
+		condition = batchv1.JobFailed
+	}
+	job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue}}
+	return job
+}
+
+func TestJobAndCronJobWorkloads(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	clientset := clients.AddClient(cluster.ID)
+	base := fmt.Sprintf("/api/v1/clusters/%d/workloads", cluster.ID)
+
+	batchRequest := func(name string, batch gin.H) gin.H {
+		return gin.H{
+			"metadata": gin.H{"name": name},
+			"spec": gin.H{
+				"template": gin.H{"spec": gin.H{"containers": []models.Container{{Name: "backup", Image: "busybox:1.36"}}}},
+				"selector": gin.H{"matchLabels": []gin.H{{"key": "app", "value": name}}},
+				"batch":    batch,
+			},
+		}
+	}
+
+	// CronJob 必须指定调度规则
+	if w := doRequest(t, r, http.MethodPost, base+"/cronjobs/default", batchRequest("backup", gin.H{})); w.Code != http.StatusBadRequest {
+		t.Fatalf("缺少 schedule 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/cronjobs/default", batchRequest("backup", gin.H{"schedule": "0 * * * *", "concurrency_policy": "Sometimes"})); w.Code != http.StatusBadRequest {
+		t.Fatalf("无效的并发策略期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	w := doRequest(t, r, http.MethodPost, base+"/cronjobs/default", batchRequest("backup", gin.H{
+		"schedule":                   "0 * * * *",
+		"concurrency_policy":         "Forbid",
+		"backoff_limit":              2,
+		"ttl_seconds_after_finished": 600,
+	}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建 CronJob 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	cronJob, err := clientset.BatchV1().CronJobs("default").Get(context.Background(), "backup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 CronJob 失败: %v", err)
+	}
+	jobSpec := cronJob.Spec.JobTemplate.Spec
+	if cronJob.Spec.Schedule != "0 * * * *" || cronJob.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent ||
+		*jobSpec.BackoffLimit != 2 || *jobSpec.TTLSecondsAfterFinished != 600 ||
+		jobSpec.Template.Spec.RestartPolicy != corev1.RestartPolicyOnFailure {
+		t.Fatalf("CronJob 配置不符合预期: %+v", cronJob.Spec)
+	}
+
+	if w := doRequest(t, r, http.MethodPost, base+"/jobs/default", batchRequest("migrate", gin.H{"restart_policy": "Never", "completions": 3})); w.Code != http.StatusCreated {
+		t.Fatalf("创建 Job 期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	job, err := clientset.BatchV1().Jobs("default").Get(context.Background(), "migrate", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取 Job 失败: %v", err)
+	}
+	if *job.Spec.Completions != 3 || job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("Job 配置不符合预期: %+v", job.Spec)
+	}
+
+	// 立即运行创建由 CronJob 管理的 Job
+	w = doRequest(t, r, http.MethodPost, base+"/CronJob/default/backup/trigger", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("手动触发期望状态码 %d，实际 %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var triggered utils.JobRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &triggered); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	manualJob, err := clientset.BatchV1().Jobs("default").Get(context.Background(), triggered.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("获取手动触发的 Job 失败: %v", err)
+	}
+	if !triggered.Manual || !strings.HasPrefix(triggered.Name, "backup-manual-") || metav1.GetControllerOf(manualJob).Name != "backup" ||
+		*manualJob.Spec.BackoffLimit != 2 {
+		t.Fatalf("手动触发的 Job 不符合预期: %+v", manualJob)
+	}
+	if w := doRequest(t, r, http.MethodPost, base+"/Job/default/migrate/trigger", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("触发 Job 期望 400，实际 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 执行历史只包含该 CronJob 的 Job，按创建时间从新到旧排序
+	now := time.Now()
+	clientset.BatchV1().Jobs("default").Create(context.Background(), testCronJobJob(cronJob, "backup-1", now.Add(-2*time.Hour), 1, 0), metav1.CreateOptions{})
+	clientset.BatchV1().Jobs("default").Create(context.Background(), testCronJobJob(cronJob, "backup-2", now.Add(-time.Hour), 0, 3), metav1.CreateOptions{})
+	w = doRequest(t, r, http.MethodGet, base+"/CronJob/default/backup/jobs", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取执行历史期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var history struct {
+		Jobs []utils.JobRecord `json:"jobs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &history); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(history.Jobs) != 3 || history.Jobs[0].Name != "backup-2" || history.Jobs[1].Name != "backup-1" {
+		t.Fatalf("执行历史不符合预期: %+v", history.Jobs)
+	}
+	if history.Jobs[0].Status != utils.JobFailed || history.Jobs[0].Failed != 3 ||
+		history.Jobs[1].Status != utils.JobComplete || history.Jobs[1].Succeeded != 1 {
+		t.Fatalf("执行记录的状态不符合预期: %+v", history.Jobs)
+	}
+
+	// 暂停 CronJob 同时更新工作负载记录
+	if w := doRequest(t, r, http.MethodPost, base+"/CronJob/default/backup/pause", nil); w.Code != http.StatusOK {
+		t.Fatalf("暂停期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	cronJob, _ = clientset.BatchV1().CronJobs("default").Get(context.Background(), "backup", metav1.GetOptions{})
+	if cronJob.Spec.Suspend == nil || !*cronJob.Spec.Suspend {
+		t.Fatal("暂停后 spec.suspend 应为 true")
+	}
+	var record models.Workload
+	db.Where("cluster_id = ? AND kind = ? AND name = ?", cluster.ID, "CronJob", "backup").First(&record)
+	if record.Batch == nil || !record.Batch.Suspend || record.Batch.Schedule != "0 * * * *" {
+		t.Fatalf("暂停后工作负载记录不符合预期: %+v", record.Batch)
+	}
+
+	// 列表按类型分组
+	w = doRequest(t, r, http.MethodGet, base, nil)
+	var list struct {
+		Data map[string][]map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if len(list.Data["jobs"]) != 1 || len(list.Data["cronJobs"]) != 1 || len(list.Data["deployments"]) != 0 {
+		t.Fatalf("工作负载分组不符合预期: %s", w.Body.String())
+	}
+
+	// 删除 Job
+	if w := doRequest(t, r, http.MethodDelete, base+"/Job/default/migrate", nil); w.Code != http.StatusOK {
+		t.Fatalf("删除 Job 期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if _, err := clientset.BatchV1().Jobs("default").Get(context.Background(), "migrate", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Job 应已删除: %v", err)
+	}
+}
+
+func TestSyncBatchWorkloads(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	template := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers:    []corev1.Container{{Name: "backup", Image: "busybox:1.36"}},
+	}}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec: batchv1.CronJobSpec{
+			Schedule:    "0 2 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}},
+		},
+		Status: batchv1.CronJobStatus{LastScheduleTime: &metav1.Time{Time: time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)}},
+	}
+	scheduled := testCronJobJob(cronJob, "backup-1", time.Now(), 1, 0)
+	scheduled.Spec.Template = template
+	migrate := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec:       batchv1.JobSpec{Template: template},
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+	clients.AddClient(cluster.ID, cronJob, scheduled, migrate)
+
+	path := fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID)
+	for _, expected := range []utils.SyncResult{{Created: 2}, {Unchanged: 2}} {
+		w := doRequest(t, r, http.MethodPost, path, nil)
+		var result utils.SyncResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		// CronJob 创建的 Job 不单独记录
+		if result != expected {
+			t.Fatalf("同步结果期望 %+v，实际 %+v", expected, result)
+		}
+	}
+
+	var workload models.Workload
+	db.Where("cluster_id = ? AND kind = ? AND name = ?", cluster.ID, "CronJob", "backup").First(&workload)
+	if workload.Batch == nil || workload.Batch.Schedule != "0 2 * * *" || workload.Batch.RestartPolicy != "Never" ||
+		workload.Status.LastScheduleTime == nil || len(workload.Containers) != 1 {
+		t.Fatalf("CronJob 记录不符合预期: %+v %+v", workload.Batch, workload.Status)
+	}
+	var job models.Workload
+	db.Where("cluster_id = ? AND kind = ? AND name = ?", cluster.ID, "Job", "migrate").First(&job)
+	if job.Status.Active != 1 || job.Status.DesiredReplicas != 1 {
+		t.Fatalf("Job 状态不符合预期: %+v", job.Status)
+	}
+}
+
+func TestUpdateCronJobKeepsUnmodeledFields(t *testing.T) {
+	r, db, clients := setupTestRouter(t)
+	cluster := createTestCluster(t, db)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 2 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"owner": "dba"}},
+				Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: "backup",
+					NodeSelector:       map[string]string{"disk": "ssd"},
+					Tolerations:        []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+					Containers:         []corev1.Container{{Name: "backup", Image: "busybox:1.36", Command: []string{"/backup.sh"}}},
+				}}},
+			},
+		},
+	}
+	clientset := clients.AddClient(cluster.ID, cronJob)
+	// 第一次更新返回冲突，应读取最新的对象后重试
+	conflicted := false
+	clientset.PrependReactor("update", "cronjobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !conflicted {
+			conflicted = true
+			return true, nil, apierrors.NewConflict(batchv1.Resource("cronjobs"), "backup", fmt.Errorf("对象已被修改"))
+		}
+		return false, nil, nil
+	})
+
+	if w := doRequest(t, r, http.MethodPost, fmt.Sprintf("/api/v1/clusters/%d/workloads/sync", cluster.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	body := gin.H{
+		"batch":      gin.H{"schedule": "0 3 * * *"},
+		"containers": []models.Container{{Name: "backup", Image: "busybox:1.37"}},
+	}
+	w := doRequest(t, r, http.MethodPut, fmt.Sprintf("/api/v1/clusters/%d/workloads/CronJob/default/backup", cluster.ID), body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望状态码 %d，实际 %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	updated, _ := clientset.BatchV1().CronJobs("default").Get(context.Background(), "backup", metav1.GetOptions{})
+	template := updated.Spec.JobTemplate
+	spec := template.Spec.Template.Spec
+	if !conflicted || updated.Spec.Schedule != "0 3 * * *" || spec.Containers[0].Image != "busybox:1.37" {
+		t.Fatalf("调度规则或镜像未更新: %s %s", updated.Spec.Schedule, spec.Containers[0].Image)
+	}
+	if template.Annotations["owner"] != "dba" || spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("Job 模板中记录未覆盖的字段应保持不变: %+v", template)
+	}
+	if spec.ServiceAccountName != "backup" || spec.NodeSelector["disk"] != "ssd" || len(spec.Tolerations) != 1 ||
+		!reflect.DeepEqual(spec.Containers[0].Command, []string{"/backup.sh"}) {
+		t.Fatalf("Pod 模板中记录未覆盖的字段应保持不变: %+v", spec)
+	}
+}
@@ -0,0 +1,243 @@
+// Package encryption 提供敏感字段的信封加密
+//
+// 每次加密都会生成随机的数据密钥(DEK)加密明文，再用主密钥(KEK)加密数据密钥。
+// 主密钥通过 Keyring 管理，第一个主密钥用于加密，其余主密钥只用于解密旧数据，
+// 以便在轮换主密钥后重新加密已有数据。
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// 加密数据的前缀，用于区分历史遗留的明文数据
+	prefix = "enc:v1:"
+	// 主密钥和数据密钥的长度（AES-256）
+	keySize = 32
+)
+
+var (
+	// ErrNoKeyring 未配置主密钥
+	ErrNoKeyring = errors.New("未配置加密主密钥")
+	// ErrUnknownKey 密文使用的主密钥不在 Keyring 中
+	ErrUnknownKey = errors.New("未知的加密主密钥")
+	// ErrMalformedCiphertext 密文格式错误或已被篡改
+	ErrMalformedCiphertext = errors.New("密文格式错误")
+)
+
+// Key 用于加密数据密钥的主密钥
+type Key struct {
+	// 主密钥标识，写入密文以便解密时选择主密钥
+	ID string
+	// 32 字节的主密钥
+	Secret []byte
+}
+
+// Keyring 主密钥集合，第一个主密钥为当前使用的主密钥
+type Keyring struct {
+	keys []Key
+}
+
+// NewKeyring 创建 Keyring，第一个主密钥用于加密
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeyring
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, fmt.Errorf("主密钥标识 %q 无效", key.ID)
+		}
+		if len(key.Secret) != keySize {
+			return nil, fmt.Errorf("主密钥 %s 长度必须为 %d 字节", key.ID, keySize)
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("主密钥标识 %s 重复", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	return &Keyring{keys: keys}, nil
+}
+
+// ParseKeyring 解析 "id:base64密钥,id:base64密钥" 格式的主密钥配置
+func ParseKeyring(spec string) (*Keyring, error) {
+	var keys []Key
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, found := strings.Cut(item, ":")
+		if !found {
+			return nil, fmt.Errorf("主密钥配置 %q 格式错误，应为 id:base64密钥", item)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("主密钥 %s base64 解码失败: %v", id, err)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	return NewKeyring(keys...)
+}
+
+// PrimaryKeyID 返回当前用于加密的主密钥标识
+func (k *Keyring) PrimaryKeyID() string {
+	return k.keys[0].ID
+}
+
+// Encrypt 使用当前主密钥加密明文
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	primary := k.keys[0]
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("生成数据密钥失败: %v", err)
+	}
+
+	wrappedKey, err := seal(primary.Secret, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + primary.ID + ":" +
+		base64.StdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	keyID, wrappedKey, ciphertext, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	key, found := k.key(keyID)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	dataKey, err := open(key.Secret, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, ciphertext)
+}
+
+// NeedsRotation 判断密文是否未使用当前主密钥加密
+func (k *Keyring) NeedsRotation(value string) bool {
+	if !IsEncrypted(value) {
+		return true
+	}
+	keyID, _, _, err := parse(value)
+	return err != nil || keyID != k.PrimaryKeyID()
+}
+
+// key 根据标识查找主密钥
+func (k *Keyring) key(id string) (Key, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// IsEncrypted 判断字符串是否为加密后的密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// parse 拆分密文中的主密钥标识、加密后的数据密钥和数据密文
+func parse(value string) (string, []byte, []byte, error) {
+	if !IsEncrypted(value) {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	return parts[0], wrappedKey, ciphertext, nil
+}
+
+// seal 使用 AES-GCM 加密，返回 nonce 与密文的拼接
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("生成 nonce 失败: %v", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open 解密 seal 生成的数据
+func open(key, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrMalformedCiphertext
+	}
+	return plaintext, nil
+}
+
+// newGCM 创建 AES-GCM 加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+var (
+	// defaultKeyring 全局使用的 Keyring
+	defaultKeyring *Keyring
+	// defaultKeyringMutex 用于保护 defaultKeyring 的并发访问
+	defaultKeyringMutex sync.RWMutex
+)
+
+// SetDefault 设置全局使用的 Keyring
+func SetDefault(keyring *Keyring) {
+	defaultKeyringMutex.Lock()
+	defaultKeyring = keyring
+	defaultKeyringMutex.Unlock()
+}
+
+// Default 返回全局使用的 Keyring，未配置时返回 nil
+func Default() *Keyring {
+	defaultKeyringMutex.RLock()
+	defer defaultKeyringMutex.RUnlock()
+	return defaultKeyring
+}
@@ -0,0 +1,104 @@
+package encryption_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kbsonlong/kaiops/encryption"
+)
+
+// testKey 生成指定标识的测试主密钥
+func testKey(id string, fill byte) encryption.Key {
+	return encryption.Key{ID: id, Secret: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring, err := encryption.NewKeyring(testKey("k1", 1))
+	if err != nil {
+		t.Fatalf("创建 Keyring 失败: %v", err)
+	}
+
+	plaintext := []byte("apiVersion: v1\nkind: Config\n")
+	ciphertext, err := keyring.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if !encryption.IsEncrypted(ciphertext) || strings.Contains(ciphertext, "apiVersion") {
+		t.Fatalf("密文格式不符合预期: %s", ciphertext)
+	}
+
+	got, err := keyring.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("解密结果不一致: %q", got)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKeyring, _ := encryption.NewKeyring(testKey("k1", 1))
+	ciphertext, err := oldKeyring.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	// 新主密钥在前，旧主密钥仅用于解密
+	rotated, _ := encryption.NewKeyring(testKey("k2", 2), testKey("k1", 1))
+	if !rotated.NeedsRotation(ciphertext) {
+		t.Fatalf("旧主密钥加密的数据应需要轮换")
+	}
+	plaintext, err := rotated.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("使用旧主密钥解密失败: %v", err)
+	}
+
+	reencrypted, err := rotated.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("重新加密失败: %v", err)
+	}
+	if rotated.NeedsRotation(reencrypted) {
+		t.Fatalf("重新加密后不应再需要轮换")
+	}
+
+	newOnly, _ := encryption.NewKeyring(testKey("k2", 2))
+	if _, err := newOnly.Decrypt(ciphertext); !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Fatalf("期望错误 %v，实际 %v", encryption.ErrUnknownKey, err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	keyring, _ := encryption.NewKeyring(testKey("k1", 1))
+	ciphertext, _ := keyring.Encrypt([]byte("secret"))
+
+	parts := strings.Split(ciphertext, ":")
+	data, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+	data[len(data)-1] ^= 0xff
+	parts[len(parts)-1] = base64.StdEncoding.EncodeToString(data)
+
+	if _, err := keyring.Decrypt(strings.Join(parts, ":")); !errors.Is(err, encryption.ErrMalformedCiphertext) {
+		t.Fatalf("期望错误 %v，实际 %v", encryption.ErrMalformedCiphertext, err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	keyring, err := encryption.ParseKeyring("k2:" + secret + ", k1:" + secret)
+	if err != nil {
+		t.Fatalf("解析主密钥配置失败: %v", err)
+	}
+	if keyring.PrimaryKeyID() != "k2" {
+		t.Fatalf("期望当前主密钥为 k2，实际 %s", keyring.PrimaryKeyID())
+	}
+
+	if _, err := encryption.ParseKeyring("k1:" + base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Fatalf("长度不足的主密钥应解析失败")
+	}
+	if _, err := encryption.ParseKeyring(""); !errors.Is(err, encryption.ErrNoKeyring) {
+		t.Fatalf("期望错误 %v，实际 %v", encryption.ErrNoKeyring, err)
+	}
+}
@@ -60,6 +60,13 @@ const ClusterDetail: React.FC = () => {
           <Descriptions.Item label="状态">
             <Badge status={cluster.cluster_status ? 'success' : 'error'} text={cluster.cluster_status ? '正常' : '异常'} />
           </Descriptions.Item>
+          <Descriptions.Item label="API 延迟">{cluster.api_latency ? `${cluster.api_latency} ms` : '-'}</Descriptions.Item>
+          <Descriptions.Item label="最近检查时间">
+            {cluster.last_checked_at ? new Date(cluster.last_checked_at).toLocaleString() : '-'}
+          </Descriptions.Item>
+          {!cluster.cluster_status && cluster.last_error && (
+            <Descriptions.Item label="最近错误" span={2}>{cluster.last_error}</Descriptions.Item>
+          )}
         </Descriptions>
 
         <Tabs defaultActiveKey="workloads" style={{ marginTop: '24px' }}>
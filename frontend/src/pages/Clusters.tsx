@@ -291,9 +291,9 @@ const Clusters: React.FC = () => {
           <Form.Item
             name="kube_config"
             label="KubeConfig"
-            rules={[{ required: true, message: '请输入 KubeConfig' }]}
+            rules={[{ required: !editingCluster, message: '请输入 KubeConfig' }]}
           >
-            <Input.TextArea rows={4} />
+            <Input.TextArea rows={4} placeholder={editingCluster ? '留空则保持原有 KubeConfig 不变' : undefined} />
           </Form.Item>
         </Form>
       </Modal>
@@ -7,10 +7,27 @@ export interface Cluster {
   name: string;
   cn_name: string;
   cluster_type: string;
-  kube_config: string;
+  kube_config?: string;
+  has_kube_config?: boolean;
+  kube_context?: string;
+  auth_type?: 'kubeconfig' | 'token' | 'certificate' | 'in_cluster';
+  bearer_token?: string;
+  ca_data?: string;
+  client_cert_data?: string;
+  client_key_data?: string;
   cluster_api: string;
+  qps?: number;
+  burst?: number;
+  request_timeout?: number;
+  proxy_url?: string;
+  tls_server_name?: string;
+  insecure_skip_verify?: boolean;
   cluster_status: boolean;
   cluster_version: string;
+  api_latency?: number;
+  last_checked_at?: string | null;
+  last_error_at?: string | null;
+  last_error?: string;
   cluster_region: string;
   cluster_zone: string[];
   cluster_network: {
@@ -78,6 +95,23 @@ export interface NodeInfo {
   };
 }
 
+export interface ClusterValidationReport {
+  valid: boolean;
+  server_address?: string;
+  server_version?: string;
+  checks: Array<{
+    name: string;
+    passed: boolean;
+    message?: string;
+  }>;
+  permissions?: Array<{
+    group: string;
+    resource: string;
+    verb: string;
+    allowed: boolean;
+  }>;
+}
+
 interface ApiResponse<T> {
   data: T;
   meta: {
@@ -121,6 +155,12 @@ export const clusterService = {
     return response.data;
   },
 
+  // 校验集群连通性和权限
+  validateCluster: async (cluster: Omit<Cluster, 'ID'>): Promise<ClusterValidationReport> => {
+    const response = await axios.post(`${API_BASE_URL}/clusters/validate`, cluster);
+    return response.data;
+  },
+
   // 更新集群
   updateCluster: async (id: number, cluster: Partial<Cluster>): Promise<Cluster> => {
     const response = await axios.put(`${API_BASE_URL}/clusters/${id}`, cluster);
@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/kbsonlong/kaiops/encryption"
+)
+
+// LoadEncryptionKeys 从环境变量 KUBECONFIG_ENCRYPTION_KEYS 加载 kubeconfig 和 Secret 修订历史的加密主密钥
+// 未配置主密钥时拒绝启动，除非通过 ALLOW_PLAINTEXT_STORAGE=true 明确允许明文存储
+func LoadEncryptionKeys() {
+	spec := os.Getenv("KUBECONFIG_ENCRYPTION_KEYS")
+	if spec == "" {
+		allow, _ := strconv.ParseBool(os.Getenv("ALLOW_PLAINTEXT_STORAGE"))
+		if !allow {
+			log.Fatal("未配置 KUBECONFIG_ENCRYPTION_KEYS，如需以明文存储 kubeconfig 和 Secret 修订历史请设置 ALLOW_PLAINTEXT_STORAGE=true")
+		}
+		log.Println("警告: 已设置 ALLOW_PLAINTEXT_STORAGE，kubeconfig 和 Secret 修订历史将以明文存储")
+		return
+	}
+
+	keyring, err := encryption.ParseKeyring(spec)
+	if err != nil {
+		log.Fatalf("加载 kubeconfig 加密主密钥失败: %v", err)
+	}
+	encryption.SetDefault(keyring)
+}
@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth 校验 Authorization 请求头中的管理员令牌，用于保护下载凭据等高权限接口
+// 令牌通过环境变量 ADMIN_TOKEN 配置，未配置时拒绝所有请求
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("ADMIN_TOKEN")
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "需要管理员权限"})
+			return
+		}
+		c.Next()
+	}
+}
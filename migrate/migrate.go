@@ -13,4 +13,5 @@ func init() {
 func main() {
 	initializers.DB.AutoMigrate(&models.Cluster{})
 	initializers.DB.AutoMigrate(&models.Workload{})
+	initializers.DB.AutoMigrate(&models.ConfigRevision{})
 }
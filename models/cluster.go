@@ -4,10 +4,24 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"net/url"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// 集群认证方式
+const (
+	// AuthTypeKubeConfig 使用 kubeconfig 认证
+	AuthTypeKubeConfig = "kubeconfig"
+	// AuthTypeToken 使用 Bearer Token 和 CA 证书认证
+	AuthTypeToken = "token"
+	// AuthTypeCertificate 使用客户端证书认证
+	AuthTypeCertificate = "certificate"
+	// AuthTypeInCluster 使用 kaiops 所在集群的 ServiceAccount 认证
+	AuthTypeInCluster = "in_cluster"
+)
+
 // Cluster 表示一个 Kubernetes 集群
 // @Description Kubernetes 集群信息
 type Cluster struct {
@@ -18,14 +32,46 @@ type Cluster struct {
 	CnName string `json:"cn_name" example:"生产环境集群"`
 	// 集群类型
 	ClusterType string `json:"cluster_type" example:"kubernetes"`
-	// Kubernetes 配置文件内容
-	KubeConfig string `json:"kube_config" example:"apiVersion: v1\nkind: Config\nclusters:\n- cluster:\n    server: https://api.example.com"`
+	// Kubernetes 配置文件内容（base64 编码），加密存储且不会出现在响应中
+	KubeConfig EncryptedString `json:"kube_config" gorm:"type:text" example:"apiVersion: v1\nkind: Config\nclusters:\n- cluster:\n    server: https://api.example.com"`
+	// kubeconfig 中使用的上下文名称，为空时使用 current-context
+	KubeContext string `json:"kube_context" example:"prod-admin@prod-cluster"`
+	// 认证方式: kubeconfig, token, certificate, in_cluster，为空时使用 kubeconfig
+	AuthType string `json:"auth_type" example:"kubeconfig"`
+	// Bearer Token（token 认证），加密存储且不会出现在响应中
+	BearerToken EncryptedString `json:"bearer_token" gorm:"type:text"`
+	// PEM 格式的 CA 证书（token 和 certificate 认证）
+	CAData string `json:"ca_data"`
+	// PEM 格式的客户端证书（certificate 认证）
+	ClientCertData string `json:"client_cert_data"`
+	// PEM 格式的客户端私钥（certificate 认证），加密存储且不会出现在响应中
+	ClientKeyData EncryptedString `json:"client_key_data" gorm:"type:text"`
 	// 集群 API 地址
 	ClusterApi string `json:"cluster_api" example:"https://api.example.com"`
+	// 客户端每秒请求数，为 0 时使用 client-go 默认值
+	QPS float32 `json:"qps" example:"50"`
+	// 客户端突发请求数，为 0 时使用 client-go 默认值
+	Burst int `json:"burst" example:"100"`
+	// 单个请求的超时时间（秒），为 0 时不限制
+	RequestTimeout int `json:"request_timeout" example:"30"`
+	// 访问 API Server 使用的代理地址，支持 http、https 和 socks5，可能包含密码，加密存储
+	ProxyURL EncryptedString `json:"proxy_url" gorm:"type:text" example:"socks5://proxy.example.com:1080"`
+	// 覆盖 TLS 校验使用的服务器名称
+	TLSServerName string `json:"tls_server_name" example:"kubernetes.default.svc"`
+	// 跳过 API Server 证书校验，仅用于测试环境
+	InsecureSkipVerify bool `json:"insecure_skip_verify" example:"false"`
 	// 集群状态
 	ClusterStatus bool `json:"cluster_status" example:"true"`
 	// 集群版本
 	ClusterVersion string `json:"cluster_version" example:"v1.20.0"`
+	// API Server 响应延迟（毫秒）
+	ApiLatency int64 `json:"api_latency" example:"35"`
+	// 最近一次健康检查时间
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	// 最近一次健康检查失败时间
+	LastErrorAt *time.Time `json:"last_error_at"`
+	// 最近一次健康检查失败的原因
+	LastError string `json:"last_error"`
 	// 集群区域
 	ClusterRegion string `json:"cluster_region" example:"cn-east-1"`
 	// 集群可用区
@@ -36,6 +82,112 @@ type Cluster struct {
 	ClusterSubnet JSONArray `json:"cluster_subnet" gorm:"type:json" example:"[\"subnet-1\", \"subnet-2\"]"`
 }
 
+// ClusterResponse 集群信息的 API 响应，不包含 kubeconfig 等凭据
+// @Description Kubernetes 集群信息（不含凭据）
+type ClusterResponse struct {
+	ID        uint       `json:"ID"`
+	CreatedAt time.Time  `json:"CreatedAt"`
+	UpdatedAt time.Time  `json:"UpdatedAt"`
+	DeletedAt *time.Time `json:"DeletedAt"`
+	// 集群名称
+	Name string `json:"name" example:"prod-cluster"`
+	// 集群中文名称
+	CnName string `json:"cn_name" example:"生产环境集群"`
+	// 集群类型
+	ClusterType string `json:"cluster_type" example:"kubernetes"`
+	// 是否已配置 kubeconfig
+	HasKubeConfig bool `json:"has_kube_config" example:"true"`
+	// kubeconfig 中使用的上下文名称
+	KubeContext string `json:"kube_context" example:"prod-admin@prod-cluster"`
+	// 认证方式
+	AuthType string `json:"auth_type" example:"kubeconfig"`
+	// PEM 格式的 CA 证书
+	CAData string `json:"ca_data,omitempty"`
+	// PEM 格式的客户端证书
+	ClientCertData string `json:"client_cert_data,omitempty"`
+	// 集群 API 地址
+	ClusterApi string `json:"cluster_api" example:"https://api.example.com"`
+	// 客户端每秒请求数
+	QPS float32 `json:"qps" example:"50"`
+	// 客户端突发请求数
+	Burst int `json:"burst" example:"100"`
+	// 单个请求的超时时间（秒）
+	RequestTimeout int `json:"request_timeout" example:"30"`
+	// 代理地址，其中的密码会被隐藏
+	ProxyURL string `json:"proxy_url" example:"socks5://proxy.example.com:1080"`
+	// 覆盖 TLS 校验使用的服务器名称
+	TLSServerName string `json:"tls_server_name" example:"kubernetes.default.svc"`
+	// 是否跳过 API Server 证书校验
+	InsecureSkipVerify bool `json:"insecure_skip_verify" example:"false"`
+	// 集群状态
+	ClusterStatus bool `json:"cluster_status" example:"true"`
+	// 集群版本
+	ClusterVersion string `json:"cluster_version" example:"v1.20.0"`
+	// API Server 响应延迟（毫秒）
+	ApiLatency int64 `json:"api_latency" example:"35"`
+	// 最近一次健康检查时间
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	// 最近一次健康检查失败时间
+	LastErrorAt *time.Time `json:"last_error_at"`
+	// 最近一次健康检查失败的原因
+	LastError string `json:"last_error"`
+	// 集群区域
+	ClusterRegion string `json:"cluster_region" example:"cn-east-1"`
+	// 集群可用区
+	ClusterZone JSONArray `json:"cluster_zone" example:"[\"zone-a\", \"zone-b\"]"`
+	// 集群网络配置
+	ClusterNetwork Network `json:"cluster_network"`
+	// 集群子网
+	ClusterSubnet JSONArray `json:"cluster_subnet" example:"[\"subnet-1\", \"subnet-2\"]"`
+}
+
+// ToResponse 转换为不包含凭据的 API 响应
+func (c Cluster) ToResponse() ClusterResponse {
+	response := ClusterResponse{
+		ID:                 c.ID,
+		CreatedAt:          c.CreatedAt,
+		UpdatedAt:          c.UpdatedAt,
+		Name:               c.Name,
+		CnName:             c.CnName,
+		ClusterType:        c.ClusterType,
+		HasKubeConfig:      c.KubeConfig != "",
+		KubeContext:        c.KubeContext,
+		AuthType:           c.AuthType,
+		CAData:             c.CAData,
+		ClientCertData:     c.ClientCertData,
+		ClusterApi:         c.ClusterApi,
+		QPS:                c.QPS,
+		Burst:              c.Burst,
+		RequestTimeout:     c.RequestTimeout,
+		ProxyURL:           redactURL(string(c.ProxyURL)),
+		TLSServerName:      c.TLSServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		ClusterStatus:      c.ClusterStatus,
+		ClusterVersion:     c.ClusterVersion,
+		ApiLatency:         c.ApiLatency,
+		LastCheckedAt:      c.LastCheckedAt,
+		LastErrorAt:        c.LastErrorAt,
+		LastError:          c.LastError,
+		ClusterRegion:      c.ClusterRegion,
+		ClusterZone:        c.ClusterZone,
+		ClusterNetwork:     c.ClusterNetwork,
+		ClusterSubnet:      c.ClusterSubnet,
+	}
+	if c.DeletedAt.Valid {
+		response.DeletedAt = &c.DeletedAt.Time
+	}
+	return response
+}
+
+// redactURL 隐藏 URL 中的密码
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
 // Network 表示集群的网络配置
 // @Description 集群网络配置信息
 type Network struct {
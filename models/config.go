@@ -0,0 +1,65 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 配置修订记录的操作
+const (
+	// ConfigActionUpdate 修改前保存的内容
+	ConfigActionUpdate = "update"
+	// ConfigActionDelete 删除前保存的内容
+	ConfigActionDelete = "delete"
+)
+
+// ConfigObject 表示 ConfigMap 或 Secret 的内容
+// Secret 中可以按 UTF-8 解码的值放在 Data 中，其余放在 BinaryData 中
+type ConfigObject struct {
+	// 类型: ConfigMap, Secret
+	Kind string `json:"kind" example:"ConfigMap"`
+	// 名称
+	Name string `json:"name" example:"nginx-config"`
+	// 命名空间
+	Namespace string `json:"namespace" example:"default"`
+	// Secret 的类型，如 Opaque、kubernetes.io/tls
+	Type string `json:"type,omitempty" example:"Opaque"`
+	// 文本内容
+	Data map[string]string `json:"data"`
+	// 二进制内容，JSON 中为 base64 编码
+	BinaryData map[string][]byte `json:"binary_data,omitempty"`
+	// 标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 注解
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// 是否不可修改
+	Immutable bool `json:"immutable,omitempty"`
+	// Secret 的值是否已隐藏
+	Masked bool `json:"masked,omitempty"`
+	// 对象的 resourceVersion
+	ResourceVersion string `json:"resource_version,omitempty" example:"123456"`
+	// 对象的创建时间
+	CreationTimestamp metav1.Time `json:"created_at"`
+}
+
+// ConfigRevision 表示 ConfigMap 或 Secret 被修改或删除前的内容，同一对象的版本号从 1 开始递增
+// @Description ConfigMap 或 Secret 的历史版本
+type ConfigRevision struct {
+	gorm.Model
+	// 所属集群ID
+	ClusterID uint `json:"cluster_id" gorm:"uniqueIndex:idx_config_revision" example:"1"`
+	// 类型: ConfigMap, Secret
+	Kind string `json:"kind" gorm:"uniqueIndex:idx_config_revision" example:"ConfigMap"`
+	// 命名空间
+	Namespace string `json:"namespace" gorm:"uniqueIndex:idx_config_revision" example:"default"`
+	// 名称
+	Name string `json:"name" gorm:"uniqueIndex:idx_config_revision" example:"nginx-config"`
+	// 版本号
+	Revision int64 `json:"revision" gorm:"uniqueIndex:idx_config_revision" example:"1"`
+	// 操作: update, delete
+	Action string `json:"action" example:"update"`
+	// 保存的内容对应的 resourceVersion
+	ResourceVersion string `json:"resource_version" example:"123456"`
+	// ConfigObject 的 JSON，加密存储且不会出现在响应中
+	Content EncryptedString `json:"-" gorm:"type:text"`
+}
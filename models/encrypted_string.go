@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/kbsonlong/kaiops/encryption"
+)
+
+// EncryptedString 写入数据库时使用信封加密的字符串
+// 未配置主密钥时按明文存储，读取时兼容历史遗留的明文数据
+type EncryptedString string
+
+// Value 实现 driver.Valuer 接口
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	keyring := encryption.Default()
+	if keyring == nil {
+		return string(s), nil
+	}
+	return keyring.Encrypt([]byte(s))
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *EncryptedString) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return errors.New("invalid scan source")
+	}
+
+	if !encryption.IsEncrypted(raw) {
+		*s = EncryptedString(raw)
+		return nil
+	}
+
+	keyring := encryption.Default()
+	if keyring == nil {
+		return encryption.ErrNoKeyring
+	}
+	plaintext, err := keyring.Decrypt(raw)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Containers 是 Container 切片的自定义类型
@@ -89,7 +91,7 @@ type Workload struct {
 	gorm.Model
 	// 工作负载名称
 	Name string `json:"name" example:"nginx-deployment"`
-	// 工作负载类型 (Deployment, StatefulSet, DaemonSet)
+	// 工作负载类型 (Deployment, StatefulSet, DaemonSet, Job, CronJob)
 	Kind string `json:"kind" example:"Deployment"`
 	// 所属命名空间
 	Namespace string `json:"namespace" example:"default"`
@@ -101,10 +103,221 @@ type Workload struct {
 	Containers Containers `json:"containers" gorm:"type:json"`
 	// 标签
 	Labels StringMap `json:"labels" gorm:"type:json"`
+	// 选择器的 matchLabels，Deployment、StatefulSet 和 DaemonSet 创建后不能修改
+	Selector StringMap `json:"selector" gorm:"type:json"`
+	// Pod 模板标签，需要包含选择器
+	TemplateLabels StringMap `json:"template_labels" gorm:"type:json"`
 	// 注解
 	Annotations StringMap `json:"annotations" gorm:"type:json"`
+	// Pod 卷
+	Volumes Volumes `json:"volumes" gorm:"type:json"`
+	// StatefulSet 的持久卷声明模板
+	VolumeClaimTemplates VolumeClaimTemplates `json:"volume_claim_templates" gorm:"type:json"`
+	// Job 和 CronJob 的配置
+	Batch *BatchSpec `json:"batch,omitempty" gorm:"type:json"`
 	// 工作负载状态
 	Status WorkloadStatus `json:"status" gorm:"type:json"`
+	// 与集群中实际对象的差异
+	Drift DriftItems `json:"drift" gorm:"type:json"`
+	// 集群中的对象是否已不存在
+	Missing bool `json:"missing" example:"false"`
+	// 最近一次与集群状态对比的时间
+	ReconciledAt *time.Time `json:"reconciled_at"`
+	// 最近一次回滚记录
+	LastRollback *RollbackRecord `json:"last_rollback,omitempty" gorm:"type:json"`
+	// 选择该工作负载 Pod 的 Service，从集群中读取，不保存到数据库
+	Services []ServiceRef `json:"services,omitempty" gorm:"-"`
+}
+
+// ServiceRef 表示一个 Service 的访问信息
+type ServiceRef struct {
+	// Service 名称
+	Name string `json:"name" example:"nginx"`
+	// Service 类型: ClusterIP, NodePort, LoadBalancer, Headless
+	Type string `json:"type" example:"ClusterIP"`
+	// 集群内访问地址，Headless Service 为 None
+	ClusterIP string `json:"cluster_ip,omitempty" example:"10.96.0.10"`
+	// 负载均衡器分配的外部地址
+	ExternalIPs []string `json:"external_ips,omitempty" example:"203.0.113.10"`
+	// 端口
+	Ports []ServicePort `json:"ports"`
+}
+
+// ServicePort 表示 Service 的端口
+type ServicePort struct {
+	// 端口名称，多个端口时必填
+	Name string `json:"name,omitempty" example:"http"`
+	// 协议: TCP, UDP, SCTP，默认为 TCP
+	Protocol string `json:"protocol,omitempty" example:"TCP"`
+	// Service 端口
+	Port int32 `json:"port" example:"80"`
+	// 容器端口号或端口名称，为空时与 Service 端口相同
+	TargetPort intstr.IntOrString `json:"target_port,omitempty" swaggertype:"string" example:"8080"`
+	// NodePort 和 LoadBalancer 类型在节点上开放的端口，为 0 时自动分配
+	NodePort int32 `json:"node_port,omitempty" example:"30080"`
+}
+
+// RollbackRecord 表示一次回滚操作
+type RollbackRecord struct {
+	// 回滚前的版本号
+	FromRevision int64 `json:"from_revision" example:"4"`
+	// 回滚到的版本号
+	ToRevision int64 `json:"to_revision" example:"3"`
+	// 回滚到的版本中各容器使用的镜像
+	Images map[string]string `json:"images"`
+	// 回滚到的版本的变更原因
+	ChangeCause string `json:"change_cause,omitempty"`
+	// 回滚时间
+	RolledBackAt time.Time `json:"rolled_back_at"`
+}
+
+// Value 实现 driver.Valuer 接口
+func (r RollbackRecord) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (r *RollbackRecord) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	data, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid scan source")
+	}
+	return json.Unmarshal(data, r)
+}
+
+// BatchSpec 表示 Job 和 CronJob 的配置，CronJob 的 Job 模板使用 Schedule 以外的字段
+type BatchSpec struct {
+	// CronJob 的调度规则，Cron 格式
+	Schedule string `json:"schedule,omitempty" example:"*/5 * * * *"`
+	// CronJob 调度使用的时区，为空时使用 kube-controller-manager 的时区
+	TimeZone string `json:"time_zone,omitempty" example:"Asia/Shanghai"`
+	// CronJob 的并发策略: Allow, Forbid, Replace
+	ConcurrencyPolicy string `json:"concurrency_policy,omitempty" example:"Forbid"`
+	// CronJob 保留的成功 Job 数
+	SuccessfulJobsHistoryLimit *int32 `json:"successful_jobs_history_limit,omitempty" example:"3"`
+	// CronJob 保留的失败 Job 数
+	FailedJobsHistoryLimit *int32 `json:"failed_jobs_history_limit,omitempty" example:"1"`
+	// 是否暂停，Job 暂停时终止运行中的 Pod，CronJob 暂停时不再创建新的 Job
+	Suspend bool `json:"suspend" example:"false"`
+	// 标记 Job 失败前的重试次数
+	BackoffLimit *int32 `json:"backoff_limit,omitempty" example:"6"`
+	// Job 需要成功完成的 Pod 数
+	Completions *int32 `json:"completions,omitempty" example:"1"`
+	// Job 同时运行的 Pod 数
+	Parallelism *int32 `json:"parallelism,omitempty" example:"1"`
+	// Job 的最长运行时间（秒）
+	ActiveDeadlineSeconds *int64 `json:"active_deadline_seconds,omitempty" example:"3600"`
+	// Job 完成后自动删除前保留的时间（秒）
+	TTLSecondsAfterFinished *int32 `json:"ttl_seconds_after_finished,omitempty" example:"86400"`
+	// Pod 的重启策略: OnFailure, Never，默认为 OnFailure
+	RestartPolicy string `json:"restart_policy,omitempty" example:"OnFailure"`
+}
+
+// Value 实现 driver.Valuer 接口
+func (b BatchSpec) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (b *BatchSpec) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	data, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid scan source")
+	}
+	return json.Unmarshal(data, b)
+}
+
+// DriftItem 表示工作负载记录与集群中实际对象的一项差异
+type DriftItem struct {
+	// 差异字段: image, replicas, labels, env, container
+	Field string `json:"field" example:"image"`
+	// 差异所在的容器
+	Container string `json:"container,omitempty" example:"nginx"`
+	// 记录中的值
+	Expected string `json:"expected" example:"nginx:1.14.2"`
+	// 集群中的实际值
+	Actual string `json:"actual" example:"nginx:1.25"`
+}
+
+// DriftItems 是 DriftItem 切片的自定义类型
+type DriftItems []DriftItem
+
+// Value 实现 driver.Valuer 接口
+func (d DriftItems) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	return json.Marshal(d)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (d *DriftItems) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid scan source")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// Volumes 是 Volume 切片的自定义类型
+type Volumes []Volume
+
+// Value 实现 driver.Valuer 接口
+func (v Volumes) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (v *Volumes) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid scan source")
+	}
+	return json.Unmarshal(bytes, v)
+}
+
+// VolumeClaimTemplates 是 VolumeClaimTemplate 切片的自定义类型
+type VolumeClaimTemplates []VolumeClaimTemplate
+
+// Value 实现 driver.Valuer 接口
+func (v VolumeClaimTemplates) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (v *VolumeClaimTemplates) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid scan source")
+	}
+	return json.Unmarshal(bytes, v)
 }
 
 // Container 表示容器配置
@@ -119,8 +332,84 @@ type Container struct {
 	Limits ResourceList `json:"limits"`
 	// 环境变量
 	Env []EnvVar `json:"env"`
+	// 从 ConfigMap 或 Secret 批量导入的环境变量
+	EnvFrom []EnvFromSource `json:"env_from,omitempty"`
 	// 端口配置
 	Ports []ContainerPort `json:"ports"`
+	// 卷挂载
+	VolumeMounts []VolumeMount `json:"volume_mounts,omitempty"`
+	// 存活探针
+	LivenessProbe *Probe `json:"liveness_probe,omitempty"`
+	// 就绪探针
+	ReadinessProbe *Probe `json:"readiness_probe,omitempty"`
+	// 启动探针
+	StartupProbe *Probe `json:"startup_probe,omitempty"`
+}
+
+// Probe 表示容器健康检查探针，HTTPGet、TCPSocket、Exec 和 GRPC 只能设置一个
+type Probe struct {
+	// HTTP GET 检查
+	HTTPGet *HTTPGetAction `json:"http_get,omitempty"`
+	// TCP 端口检查
+	TCPSocket *TCPSocketAction `json:"tcp_socket,omitempty"`
+	// 执行命令检查
+	Exec *ExecAction `json:"exec,omitempty"`
+	// gRPC 健康检查
+	GRPC *GRPCAction `json:"grpc,omitempty"`
+	// 容器启动后首次检查前的等待时间（秒）
+	InitialDelaySeconds int32 `json:"initial_delay_seconds,omitempty" example:"10"`
+	// 单次检查的超时时间（秒）
+	TimeoutSeconds int32 `json:"timeout_seconds,omitempty" example:"1"`
+	// 检查间隔（秒）
+	PeriodSeconds int32 `json:"period_seconds,omitempty" example:"10"`
+	// 失败后连续成功多少次视为成功，存活和启动探针只能为 1
+	SuccessThreshold int32 `json:"success_threshold,omitempty" example:"1"`
+	// 连续失败多少次视为失败
+	FailureThreshold int32 `json:"failure_threshold,omitempty" example:"3"`
+}
+
+// HTTPGetAction 表示 HTTP GET 检查
+type HTTPGetAction struct {
+	// 请求路径
+	Path string `json:"path,omitempty" example:"/healthz"`
+	// 端口号或端口名称
+	Port intstr.IntOrString `json:"port" swaggertype:"string" example:"8080"`
+	// 请求的主机名，默认为 Pod IP
+	Host string `json:"host,omitempty"`
+	// 协议: HTTP, HTTPS
+	Scheme string `json:"scheme,omitempty" example:"HTTP"`
+	// 自定义请求头
+	HTTPHeaders []HTTPHeader `json:"http_headers,omitempty"`
+}
+
+// HTTPHeader 表示 HTTP 请求头
+type HTTPHeader struct {
+	// 请求头名称
+	Name string `json:"name" example:"X-Probe"`
+	// 请求头的值
+	Value string `json:"value" example:"kaiops"`
+}
+
+// TCPSocketAction 表示 TCP 端口检查
+type TCPSocketAction struct {
+	// 端口号或端口名称
+	Port intstr.IntOrString `json:"port" swaggertype:"string" example:"3306"`
+	// 主机名，默认为 Pod IP
+	Host string `json:"host,omitempty"`
+}
+
+// ExecAction 表示在容器内执行命令的检查
+type ExecAction struct {
+	// 执行的命令，退出码为 0 视为成功
+	Command []string `json:"command" example:"cat,/tmp/healthy"`
+}
+
+// GRPCAction 表示 gRPC 健康检查
+type GRPCAction struct {
+	// gRPC 端口
+	Port int32 `json:"port" example:"9090"`
+	// gRPC 健康检查的服务名称
+	Service string `json:"service,omitempty"`
 }
 
 // ResourceList 表示资源配置
@@ -129,14 +418,78 @@ type ResourceList struct {
 	CPU string `json:"cpu" example:"100m"`
 	// 内存请求/限制
 	Memory string `json:"memory" example:"128Mi"`
+	// 临时存储请求/限制
+	EphemeralStorage string `json:"ephemeral_storage,omitempty" example:"1Gi"`
+	// 扩展资源请求/限制，键为资源名称，如 nvidia.com/gpu
+	Extended map[string]string `json:"extended,omitempty"`
 }
 
-// EnvVar 表示环境变量
+// EnvVar 表示环境变量，Value 和 ValueFrom 只能设置一个
 type EnvVar struct {
 	// 环境变量名称
 	Name string `json:"name" example:"NGINX_PORT"`
 	// 环境变量值
 	Value string `json:"value" example:"80"`
+	// 从 ConfigMap、Secret、Pod 字段或容器资源读取的值
+	ValueFrom *EnvVarSource `json:"value_from,omitempty"`
+}
+
+// EnvVarSource 表示环境变量值的来源，ConfigMapKeyRef、SecretKeyRef、FieldRef 和 ResourceFieldRef 只能设置一个
+type EnvVarSource struct {
+	// 读取 ConfigMap 的键
+	ConfigMapKeyRef *KeySelector `json:"config_map_key_ref,omitempty"`
+	// 读取 Secret 的键
+	SecretKeyRef *KeySelector `json:"secret_key_ref,omitempty"`
+	// 读取 Pod 的字段
+	FieldRef *ObjectFieldSelector `json:"field_ref,omitempty"`
+	// 读取容器的资源请求或限制
+	ResourceFieldRef *ResourceFieldSelector `json:"resource_field_ref,omitempty"`
+}
+
+// KeySelector 表示 ConfigMap 或 Secret 中的一个键
+type KeySelector struct {
+	// ConfigMap 或 Secret 名称
+	Name string `json:"name" example:"nginx-config"`
+	// 键名
+	Key string `json:"key" example:"port"`
+	// ConfigMap、Secret 或键不存在时是否允许启动
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ObjectFieldSelector 表示 Pod 的字段
+type ObjectFieldSelector struct {
+	// 字段路径，如 metadata.name、status.podIP
+	FieldPath string `json:"field_path" example:"status.podIP"`
+	// 字段路径所属的 API 版本，默认为 v1
+	APIVersion string `json:"api_version,omitempty" example:"v1"`
+}
+
+// ResourceFieldSelector 表示容器的资源请求或限制
+type ResourceFieldSelector struct {
+	// 容器名称，为空时使用当前容器
+	ContainerName string `json:"container_name,omitempty" example:"nginx"`
+	// 资源，如 limits.cpu、requests.memory
+	Resource string `json:"resource" example:"limits.memory"`
+	// 输出值的单位，默认为 1
+	Divisor string `json:"divisor,omitempty" example:"1Mi"`
+}
+
+// EnvFromSource 表示将 ConfigMap 或 Secret 的所有键导入为环境变量，ConfigMapRef 和 SecretRef 只能设置一个
+type EnvFromSource struct {
+	// 添加到每个键前的前缀
+	Prefix string `json:"prefix,omitempty" example:"NGINX_"`
+	// 导入 ConfigMap
+	ConfigMapRef *EnvFromRef `json:"config_map_ref,omitempty"`
+	// 导入 Secret
+	SecretRef *EnvFromRef `json:"secret_ref,omitempty"`
+}
+
+// EnvFromRef 表示被导入的 ConfigMap 或 Secret
+type EnvFromRef struct {
+	// ConfigMap 或 Secret 名称
+	Name string `json:"name" example:"nginx-config"`
+	// ConfigMap 或 Secret 不存在时是否允许启动
+	Optional bool `json:"optional,omitempty"`
 }
 
 // ContainerPort 表示容器端口配置
@@ -149,6 +502,98 @@ type ContainerPort struct {
 	Protocol string `json:"protocol" example:"TCP"`
 }
 
+// VolumeMount 表示容器的卷挂载
+type VolumeMount struct {
+	// 卷名称，对应 Pod 卷或 StatefulSet 的持久卷声明模板
+	Name string `json:"name" example:"config"`
+	// 容器内的挂载路径
+	MountPath string `json:"mount_path" example:"/etc/nginx/conf.d"`
+	// 只挂载卷中的子路径
+	SubPath string `json:"sub_path,omitempty" example:"default.conf"`
+	// 是否只读
+	ReadOnly bool `json:"read_only,omitempty" example:"true"`
+}
+
+// Volume 表示 Pod 卷，ConfigMap、Secret、PersistentVolumeClaim、EmptyDir 和 HostPath 只能设置一个
+type Volume struct {
+	// 卷名称
+	Name string `json:"name" example:"config"`
+	// 挂载 ConfigMap
+	ConfigMap *ConfigMapVolumeSource `json:"config_map,omitempty"`
+	// 挂载 Secret
+	Secret *SecretVolumeSource `json:"secret,omitempty"`
+	// 挂载已有的持久卷声明
+	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `json:"persistent_volume_claim,omitempty"`
+	// 临时目录
+	EmptyDir *EmptyDirVolumeSource `json:"empty_dir,omitempty"`
+	// 节点上的目录
+	HostPath *HostPathVolumeSource `json:"host_path,omitempty"`
+}
+
+// KeyToPath 表示将 ConfigMap 或 Secret 的键挂载为文件
+type KeyToPath struct {
+	// 键名
+	Key string `json:"key" example:"nginx.conf"`
+	// 卷内的相对路径
+	Path string `json:"path" example:"nginx.conf"`
+}
+
+// ConfigMapVolumeSource 表示 ConfigMap 卷
+type ConfigMapVolumeSource struct {
+	// ConfigMap 名称
+	Name string `json:"name" example:"nginx-config"`
+	// 只挂载指定的键，为空时挂载所有键
+	Items []KeyToPath `json:"items,omitempty"`
+	// ConfigMap 不存在时是否允许启动
+	Optional bool `json:"optional,omitempty"`
+}
+
+// SecretVolumeSource 表示 Secret 卷
+type SecretVolumeSource struct {
+	// Secret 名称
+	SecretName string `json:"secret_name" example:"nginx-tls"`
+	// 只挂载指定的键，为空时挂载所有键
+	Items []KeyToPath `json:"items,omitempty"`
+	// Secret 不存在时是否允许启动
+	Optional bool `json:"optional,omitempty"`
+}
+
+// PersistentVolumeClaimVolumeSource 表示已有的持久卷声明
+type PersistentVolumeClaimVolumeSource struct {
+	// 持久卷声明名称
+	ClaimName string `json:"claim_name" example:"data"`
+	// 是否只读
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// EmptyDirVolumeSource 表示临时目录
+type EmptyDirVolumeSource struct {
+	// 存储介质，为空时使用节点磁盘，Memory 表示使用内存
+	Medium string `json:"medium,omitempty" example:"Memory"`
+	// 容量上限
+	SizeLimit string `json:"size_limit,omitempty" example:"1Gi"`
+}
+
+// HostPathVolumeSource 表示节点上的目录
+type HostPathVolumeSource struct {
+	// 节点上的路径
+	Path string `json:"path" example:"/var/log"`
+	// 路径类型: Directory, DirectoryOrCreate, File, FileOrCreate, Socket 等
+	Type string `json:"type,omitempty" example:"Directory"`
+}
+
+// VolumeClaimTemplate 表示 StatefulSet 为每个副本创建的持久卷声明
+type VolumeClaimTemplate struct {
+	// 声明名称，容器通过该名称挂载
+	Name string `json:"name" example:"data"`
+	// 存储类，为空时使用集群默认存储类
+	StorageClassName string `json:"storage_class_name,omitempty" example:"standard"`
+	// 存储容量
+	Size string `json:"size" example:"10Gi"`
+	// 访问模式，为空时为 ReadWriteOnce
+	AccessModes []string `json:"access_modes,omitempty" example:"ReadWriteOnce"`
+}
+
 // WorkloadStatus 表示工作负载状态
 type WorkloadStatus struct {
 	// 期望副本数
@@ -157,6 +602,16 @@ type WorkloadStatus struct {
 	CurrentReplicas int32 `json:"current_replicas" example:"2"`
 	// 就绪副本数
 	ReadyReplicas int32 `json:"ready_replicas" example:"2"`
+	// Job 运行中的 Pod 数，CronJob 运行中的 Job 数
+	Active int32 `json:"active,omitempty" example:"1"`
+	// Job 成功完成的 Pod 数
+	Succeeded int32 `json:"succeeded,omitempty" example:"1"`
+	// Job 失败的 Pod 数
+	Failed int32 `json:"failed,omitempty" example:"0"`
+	// CronJob 最近一次调度的时间
+	LastScheduleTime *metav1.Time `json:"last_schedule_time,omitempty"`
+	// CronJob 最近一次成功完成的时间
+	LastSuccessfulTime *metav1.Time `json:"last_successful_time,omitempty"`
 	// 更新状态
 	Conditions []WorkloadCondition `json:"conditions"`
 }
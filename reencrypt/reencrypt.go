@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+
+	"github.com/kbsonlong/kaiops/encryption"
+	"github.com/kbsonlong/kaiops/initializers"
+	"github.com/kbsonlong/kaiops/utils"
+)
+
+func init() {
+	initializers.LoadEnvVariables()
+	initializers.LoadEncryptionKeys()
+	initializers.ConnectDB()
+}
+
+// 使用当前主密钥重新加密所有加密存储的字段，包括集群凭据和 Secret 的修订历史
+// 轮换主密钥时，将新主密钥放在 KUBECONFIG_ENCRYPTION_KEYS 的第一位并保留旧主密钥，
+// 执行本命令后即可从配置中移除旧主密钥
+func main() {
+	keyring := encryption.Default()
+	if keyring == nil {
+		log.Fatal("未配置 KUBECONFIG_ENCRYPTION_KEYS")
+	}
+
+	counts, err := utils.ReencryptAll(initializers.DB)
+	if err != nil {
+		log.Fatalf("重新加密失败: %v", err)
+	}
+	for table, count := range counts {
+		log.Printf("已使用主密钥 %s 重新加密 %s 中的 %d 行", keyring.PrimaryKeyID(), table, count)
+	}
+}
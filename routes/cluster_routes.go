@@ -2,13 +2,15 @@ package routes
 
 import (
 	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/middlewares"
+	"github.com/kbsonlong/kaiops/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupClusterRoutes(r *gin.Engine, db *gorm.DB) {
-	clusterController := &controllers.ClusterController{DB: db}
+func SetupClusterRoutes(r *gin.Engine, db *gorm.DB, clients utils.ClientProvider) {
+	clusterController := &controllers.ClusterController{DB: db, Clients: clients}
 
 	// 集群管理路由组
 	clusterGroup := r.Group("/api/v1/clusters")
@@ -16,6 +18,12 @@ func SetupClusterRoutes(r *gin.Engine, db *gorm.DB) {
 		// 创建集群
 		clusterGroup.POST("", clusterController.CreateCluster)
 
+		// 校验集群
+		clusterGroup.POST("/validate", clusterController.ValidateCluster)
+
+		// 从 kubeconfig 导入集群
+		clusterGroup.POST("/import", clusterController.ImportClusters)
+
 		// 获取集群列表
 		clusterGroup.GET("", clusterController.ListClusters)
 
@@ -28,9 +36,18 @@ func SetupClusterRoutes(r *gin.Engine, db *gorm.DB) {
 		// 删除集群
 		clusterGroup.DELETE("/:id", clusterController.DeleteCluster)
 
+		// 下载集群 kubeconfig，需要管理员令牌
+		clusterGroup.GET("/:id/kubeconfig", middlewares.AdminAuth(), clusterController.DownloadKubeConfig)
+
 		// 获取集群节点状态
 		clusterGroup.GET("/:id/nodes", clusterController.GetClusterNodes)
 
+		// 应用资源清单
+		clusterGroup.POST("/:id/apply", clusterController.ApplyManifest)
+
+		// 获取集群资源缓存状态
+		clusterGroup.GET("/:id/cache", clusterController.GetClusterCacheStatus)
+
 		clusterGroup.PATCH(":id/nodes/:nodeName/labels", clusterController.UpdateNodeLabels)
 		clusterGroup.DELETE(":id/nodes/:nodeName/labels/:labelKey", clusterController.DeleteNodeLabel)
 		clusterGroup.PATCH(":id/nodes/:nodeName/taints", clusterController.UpdateNodeTaints)
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/middlewares"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+)
+
+// SetupConfigRoutes 设置 ConfigMap 和 Secret 相关的路由
+func SetupConfigRoutes(r *gin.Engine, db *gorm.DB, clients utils.ClientProvider) {
+	configController := &controllers.ConfigController{DB: db, Clients: clients}
+
+	clusterGroup := r.Group("/api/v1/clusters/:id")
+	{
+		// ConfigMap
+		clusterGroup.GET("/configmaps", configController.ListConfigMaps)
+		clusterGroup.POST("/configmaps/:namespace", configController.CreateConfigMap)
+		clusterGroup.GET("/configmaps/:namespace/:name", configController.GetConfigMap)
+		clusterGroup.PUT("/configmaps/:namespace/:name", configController.UpdateConfigMap)
+		clusterGroup.DELETE("/configmaps/:namespace/:name", configController.DeleteConfigMap)
+		clusterGroup.GET("/configmaps/:namespace/:name/revisions", configController.GetConfigMapRevisions)
+
+		// Secret，查看明文需要管理员令牌
+		clusterGroup.GET("/secrets", configController.ListSecrets)
+		clusterGroup.POST("/secrets/:namespace", configController.CreateSecret)
+		clusterGroup.GET("/secrets/:namespace/:name", configController.GetSecret)
+		clusterGroup.GET("/secrets/:namespace/:name/reveal", middlewares.AdminAuth(), configController.RevealSecret)
+		clusterGroup.PUT("/secrets/:namespace/:name", configController.UpdateSecret)
+		clusterGroup.DELETE("/secrets/:namespace/:name", configController.DeleteSecret)
+		clusterGroup.GET("/secrets/:namespace/:name/revisions", configController.GetSecretRevisions)
+		clusterGroup.GET("/secrets/:namespace/:name/revisions/reveal", middlewares.AdminAuth(), configController.RevealSecretRevisions)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+)
+
+// SetupNamespaceRoutes 设置命名空间相关的路由
+func SetupNamespaceRoutes(r *gin.Engine, db *gorm.DB, clients utils.ClientProvider) {
+	namespaceController := &controllers.NamespaceController{DB: db, Clients: clients}
+
+	clusterGroup := r.Group("/api/v1/clusters/:id")
+	{
+		clusterGroup.GET("/namespaces", namespaceController.ListNamespaces)
+		clusterGroup.POST("/namespaces", namespaceController.CreateNamespace)
+		clusterGroup.POST("/namespaces/bulk-delete", namespaceController.BulkDeleteNamespaces)
+		clusterGroup.DELETE("/namespaces/:name", namespaceController.DeleteNamespace)
+	}
+}
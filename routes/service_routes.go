@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/utils"
+	"gorm.io/gorm"
+)
+
+// SetupServiceRoutes 设置 Service 和 Ingress 相关的路由
+func SetupServiceRoutes(r *gin.Engine, db *gorm.DB, clients utils.ClientProvider) {
+	serviceController := &controllers.ServiceController{DB: db, Clients: clients}
+
+	clusterGroup := r.Group("/api/v1/clusters/:id")
+	{
+		// Service
+		clusterGroup.GET("/services", serviceController.ListServices)
+		clusterGroup.POST("/services/:namespace", serviceController.CreateService)
+		clusterGroup.GET("/services/:namespace/:name", serviceController.GetService)
+		clusterGroup.PUT("/services/:namespace/:name", serviceController.UpdateService)
+		clusterGroup.DELETE("/services/:namespace/:name", serviceController.DeleteService)
+
+		// Ingress
+		clusterGroup.GET("/ingresses", serviceController.ListIngresses)
+		clusterGroup.POST("/ingresses/:namespace", serviceController.CreateIngress)
+		clusterGroup.GET("/ingresses/:namespace/:name", serviceController.GetIngress)
+		clusterGroup.PUT("/ingresses/:namespace/:name", serviceController.UpdateIngress)
+		clusterGroup.DELETE("/ingresses/:namespace/:name", serviceController.DeleteIngress)
+	}
+}
@@ -3,21 +3,28 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/kbsonlong/kaiops/controllers"
+	"github.com/kbsonlong/kaiops/utils"
 	"gorm.io/gorm"
 )
 
 // SetupWorkloadRoutes 设置工作负载相关的路由
-func SetupWorkloadRoutes(r *gin.Engine, db *gorm.DB) {
-	workloadController := &controllers.WorkloadController{DB: db}
+func SetupWorkloadRoutes(r *gin.Engine, db *gorm.DB, clients utils.ClientProvider) {
+	workloadController := &controllers.WorkloadController{DB: db, Clients: clients}
 
 	// 工作负载API路由组
 	workloadGroup := r.Group("/api/v1/clusters/:id/workloads")
 	{
 		// 获取工作负载列表
 		workloadGroup.GET("", workloadController.ListWorkloads)
+		// 获取与集群实际状态不一致的工作负载
+		workloadGroup.GET("/drift", workloadController.GetWorkloadDrift)
+		// 从集群导入工作负载
+		workloadGroup.POST("/sync", workloadController.SyncWorkloads)
 
 		// 获取特定工作负载详情
 		workloadGroup.GET("/:kind/:namespace/:name", workloadController.GetWorkload)
+		// 获取工作负载的 Pod 列表
+		workloadGroup.GET("/:kind/:namespace/:name/pods", workloadController.GetWorkloadPods)
 
 		// 创建Deployment
 		workloadGroup.POST("/deployments/:namespace", workloadController.CreateDeployment)
@@ -25,6 +32,10 @@ func SetupWorkloadRoutes(r *gin.Engine, db *gorm.DB) {
 		workloadGroup.POST("/statefulsets/:namespace", workloadController.CreateStatefulSet)
 		// 创建DaemonSet
 		workloadGroup.POST("/daemonsets/:namespace", workloadController.CreateDaemonSet)
+		// 创建Job
+		workloadGroup.POST("/jobs/:namespace", workloadController.CreateJob)
+		// 创建CronJob
+		workloadGroup.POST("/cronjobs/:namespace", workloadController.CreateCronJob)
 
 		// 更新工作负载
 		workloadGroup.PUT("/:kind/:namespace/:name", workloadController.UpdateWorkload)
@@ -32,5 +43,20 @@ func SetupWorkloadRoutes(r *gin.Engine, db *gorm.DB) {
 		workloadGroup.DELETE("/:kind/:namespace/:name", workloadController.DeleteWorkload)
 		// 扩缩容
 		workloadGroup.PUT("/:kind/:namespace/:name/scale", workloadController.ScaleWorkload)
+		// 获取版本历史
+		workloadGroup.GET("/:kind/:namespace/:name/revisions", workloadController.GetWorkloadRevisions)
+		// 回滚到指定版本
+		workloadGroup.POST("/:kind/:namespace/:name/rollback", workloadController.RollbackWorkload)
+		// 跟踪滚动更新进度
+		workloadGroup.GET("/:kind/:namespace/:name/rollout-status", workloadController.GetRolloutStatus)
+		// 滚动重启
+		workloadGroup.POST("/:kind/:namespace/:name/restart", workloadController.RestartWorkload)
+		// 暂停和恢复 Deployment 的滚动更新，挂起和恢复 Job、CronJob 的执行
+		workloadGroup.POST("/:kind/:namespace/:name/pause", workloadController.PauseWorkload)
+		workloadGroup.POST("/:kind/:namespace/:name/resume", workloadController.ResumeWorkload)
+		// 立即运行 CronJob
+		workloadGroup.POST("/:kind/:namespace/:name/trigger", workloadController.TriggerCronJob)
+		// 获取 Job 和 CronJob 的执行历史
+		workloadGroup.GET("/:kind/:namespace/:name/jobs", workloadController.GetJobHistory)
 	}
 }
@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// 连通性检测的超时时间
+const connectivityTimeout = 10 * time.Second
+
+var (
+	// ErrKubeConfigDecode kubeconfig 不是合法的 base64 编码
+	ErrKubeConfigDecode = errors.New("kubeconfig base64 解码失败")
+	// ErrInvalidKubeConfig kubeconfig 内容无法解析
+	ErrInvalidKubeConfig = errors.New("kubeconfig 格式错误")
+	// ErrNoCurrentContext kubeconfig 未设置 current-context 且未指定上下文
+	ErrNoCurrentContext = errors.New("kubeconfig 未指定当前上下文")
+	// ErrContextNotFound 指定的上下文在 kubeconfig 中不存在
+	ErrContextNotFound = errors.New("kubeconfig 中不存在指定的上下文")
+	// ErrUnsupportedAuthType 不支持的认证方式
+	ErrUnsupportedAuthType = errors.New("不支持的认证方式")
+	// ErrInvalidAuthConfig 认证配置不完整或格式错误
+	ErrInvalidAuthConfig = errors.New("认证配置错误")
+	// ErrInvalidConnectionSettings 客户端连接参数错误
+	ErrInvalidConnectionSettings = errors.New("连接参数错误")
+	// ErrAPIServerUnreachable 无法访问集群 API Server
+	ErrAPIServerUnreachable = errors.New("无法连接集群 API Server")
+)
+
+// DecodeKubeConfig 解码 base64 编码的 kubeconfig
+func DecodeKubeConfig(encoded string) ([]byte, error) {
+	kubeconfigBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKubeConfigDecode, err)
+	}
+	return kubeconfigBytes, nil
+}
+
+// BuildRestConfig 直接从内存中的 kubeconfig 构建 rest.Config
+// contextName 为空时使用 kubeconfig 中的 current-context
+func BuildRestConfig(kubeconfig []byte, contextName string) (*rest.Config, error) {
+	rawConfig, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKubeConfig, err)
+	}
+
+	if contextName == "" {
+		contextName = rawConfig.CurrentContext
+	}
+	if contextName == "" {
+		return nil, ErrNoCurrentContext
+	}
+	if _, exists := rawConfig.Contexts[contextName]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrContextNotFound, contextName)
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	config, err := clientcmd.NewNonInteractiveClientConfig(*rawConfig, contextName, overrides, nil).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKubeConfig, err)
+	}
+	return config, nil
+}
+
+// NewRestConfig 根据集群的认证方式和连接参数构建 rest.Config
+func NewRestConfig(cluster models.Cluster) (*rest.Config, error) {
+	config, err := newAuthRestConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyConnectionSettings(config, cluster); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// newAuthRestConfig 根据集群的认证方式构建 rest.Config
+func newAuthRestConfig(cluster models.Cluster) (*rest.Config, error) {
+	switch cluster.AuthType {
+	case "", models.AuthTypeKubeConfig:
+		kubeconfigBytes, err := DecodeKubeConfig(string(cluster.KubeConfig))
+		if err != nil {
+			return nil, err
+		}
+		return BuildRestConfig(kubeconfigBytes, cluster.KubeContext)
+	case models.AuthTypeToken:
+		return &rest.Config{
+			Host:            cluster.ClusterApi,
+			BearerToken:     string(cluster.BearerToken),
+			TLSClientConfig: rest.TLSClientConfig{CAData: []byte(cluster.CAData)},
+		}, nil
+	case models.AuthTypeCertificate:
+		return &rest.Config{
+			Host: cluster.ClusterApi,
+			TLSClientConfig: rest.TLSClientConfig{
+				CAData:   []byte(cluster.CAData),
+				CertData: []byte(cluster.ClientCertData),
+				KeyData:  []byte(cluster.ClientKeyData),
+			},
+		}, nil
+	case models.AuthTypeInCluster:
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAuthConfig, err)
+		}
+		return config, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAuthType, cluster.AuthType)
+	}
+}
+
+// applyConnectionSettings 将集群的 QPS、超时、代理和 TLS 设置应用到 rest.Config，未设置的项保留原值
+func applyConnectionSettings(config *rest.Config, cluster models.Cluster) error {
+	if cluster.QPS > 0 {
+		config.QPS = cluster.QPS
+	}
+	if cluster.Burst > 0 {
+		config.Burst = cluster.Burst
+	}
+	if cluster.RequestTimeout > 0 {
+		config.Timeout = time.Duration(cluster.RequestTimeout) * time.Second
+	}
+	if cluster.ProxyURL != "" {
+		proxyURL, err := parseProxyURL(string(cluster.ProxyURL))
+		if err != nil {
+			return err
+		}
+		config.Proxy = http.ProxyURL(proxyURL)
+	}
+	if cluster.TLSServerName != "" {
+		config.ServerName = cluster.TLSServerName
+	}
+	if cluster.InsecureSkipVerify {
+		// client-go 不允许同时配置 CA 和跳过证书校验
+		config.Insecure = true
+		config.CAData = nil
+		config.CAFile = ""
+	}
+	return nil
+}
+
+// ValidateConnectionSettings 校验集群的客户端连接参数
+func ValidateConnectionSettings(cluster models.Cluster) error {
+	if cluster.QPS < 0 || cluster.Burst < 0 || cluster.RequestTimeout < 0 {
+		return fmt.Errorf("%w: qps、burst 和 request_timeout 不能为负数", ErrInvalidConnectionSettings)
+	}
+	if cluster.ProxyURL != "" {
+		if _, err := parseProxyURL(string(cluster.ProxyURL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProxyURL 解析代理地址，只支持 http、https 和 socks5
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("%w: proxy_url %q 不是有效的地址", ErrInvalidConnectionSettings, rawURL)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+		return proxyURL, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的代理协议 %s", ErrInvalidConnectionSettings, proxyURL.Scheme)
+	}
+}
+
+// ValidateAuthConfig 校验集群认证方式所需的字段是否完整且格式正确
+// kubeconfig 的内容在创建客户端时解析，这里只检查是否提供
+func ValidateAuthConfig(cluster models.Cluster) error {
+	switch cluster.AuthType {
+	case "", models.AuthTypeKubeConfig:
+		if cluster.KubeConfig == "" {
+			return fmt.Errorf("%w: kubeconfig 不能为空", ErrInvalidAuthConfig)
+		}
+		return nil
+	case models.AuthTypeToken:
+		if err := validateServerURL(cluster.ClusterApi); err != nil {
+			return err
+		}
+		if cluster.BearerToken == "" {
+			return fmt.Errorf("%w: bearer_token 不能为空", ErrInvalidAuthConfig)
+		}
+		return validateCAData(cluster.CAData)
+	case models.AuthTypeCertificate:
+		if err := validateServerURL(cluster.ClusterApi); err != nil {
+			return err
+		}
+		if cluster.ClientCertData == "" || cluster.ClientKeyData == "" {
+			return fmt.Errorf("%w: client_cert_data 和 client_key_data 不能为空", ErrInvalidAuthConfig)
+		}
+		if _, err := tls.X509KeyPair([]byte(cluster.ClientCertData), []byte(cluster.ClientKeyData)); err != nil {
+			return fmt.Errorf("%w: 客户端证书与私钥无效: %v", ErrInvalidAuthConfig, err)
+		}
+		return validateCAData(cluster.CAData)
+	case models.AuthTypeInCluster:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedAuthType, cluster.AuthType)
+	}
+}
+
+// validateServerURL 校验 API Server 地址
+func validateServerURL(server string) error {
+	if server == "" {
+		return fmt.Errorf("%w: cluster_api 不能为空", ErrInvalidAuthConfig)
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("%w: cluster_api %q 不是有效的地址", ErrInvalidAuthConfig, server)
+	}
+	return nil
+}
+
+// validateCAData 校验 PEM 格式的 CA 证书，未配置时使用系统证书
+func validateCAData(caData string) error {
+	if caData == "" {
+		return nil
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(caData)) {
+		return fmt.Errorf("%w: ca_data 不是有效的 PEM 证书", ErrInvalidAuthConfig)
+	}
+	return nil
+}
+
+// NewClientset 根据集群信息创建 Kubernetes 客户端，并确认 API Server 可以访问
+func NewClientset(cluster models.Cluster) (*kubernetes.Clientset, error) {
+	config, err := NewRestConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckAPIServer(config); err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建 kubernetes 客户端失败: %v", err)
+	}
+	return clientset, nil
+}
+
+// CheckAPIServer 通过 /version 接口检查 API Server 是否可以访问
+func CheckAPIServer(config *rest.Config) error {
+	probeConfig := rest.CopyConfig(config)
+	probeConfig.Timeout = connectivityTimeout
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(probeConfig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAPIServerUnreachable, err)
+	}
+	if _, err := discoveryClient.ServerVersion(); err != nil {
+		return fmt.Errorf("%w: %v", ErrAPIServerUnreachable, err)
+	}
+	return nil
+}
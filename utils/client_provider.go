@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+)
+
+// ClientProvider 为控制器提供集群的 Kubernetes 客户端
+type ClientProvider interface {
+	// GetClient 获取集群的客户端，未初始化或凭据已变化时重新创建
+	GetClient(cluster models.Cluster) (kubernetes.Interface, error)
+	// NewClient 创建不写入缓存的客户端，用于校验尚未保存的集群
+	NewClient(cluster models.Cluster) (kubernetes.Interface, error)
+	// GetDynamicClient 获取集群的动态客户端和基于 discovery 的 RESTMapper，用于操作任意类型的资源
+	GetDynamicClient(cluster models.Cluster) (dynamic.Interface, meta.RESTMapper, error)
+	// GetCache 获取集群基于 informer 的资源缓存，首次访问时随客户端一起启动
+	GetCache(cluster models.Cluster) (*ClusterCache, error)
+	// LookupCache 返回集群正在运行的资源缓存，不会启动缓存，也不会刷新访问时间
+	LookupCache(clusterID uint) (*ClusterCache, bool)
+	// CacheStatus 返回集群资源缓存的同步状态
+	CacheStatus(clusterID uint) CacheStatus
+	// RemoveClient 移除集群的客户端并停止资源缓存
+	RemoveClient(clusterID uint)
+}
+
+// KubeClientProvider 基于真实 API Server 连接的 ClientProvider 实现
+type KubeClientProvider struct {
+	// Informers 集群资源缓存管理器
+	Informers *InformerManager
+}
+
+// NewClientProvider 创建默认的 ClientProvider，资源缓存空闲超过 idleTimeout 后停止
+func NewClientProvider(idleTimeout time.Duration) *KubeClientProvider {
+	return &KubeClientProvider{Informers: NewInformerManager(idleTimeout)}
+}
+
+// GetClient 获取集群的客户端，未初始化或凭据已变化时重新创建
+func (p *KubeClientProvider) GetClient(cluster models.Cluster) (kubernetes.Interface, error) {
+	clientset, err := GetOrInitKubernetesClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clientset, nil
+}
+
+// NewClient 创建不写入缓存的客户端，用于校验尚未保存的集群
+func (p *KubeClientProvider) NewClient(cluster models.Cluster) (kubernetes.Interface, error) {
+	clientset, err := NewClientset(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return clientset, nil
+}
+
+// GetDynamicClient 获取集群的动态客户端和基于 discovery 的 RESTMapper，与客户端一起按凭据指纹缓存
+func (p *KubeClientProvider) GetDynamicClient(cluster models.Cluster) (dynamic.Interface, meta.RESTMapper, error) {
+	return GetOrInitDynamicClient(cluster)
+}
+
+// newDiscoveryRESTMapper 创建按需读取 API 资源列表的 RESTMapper，找不到类型时会刷新一次缓存
+func newDiscoveryRESTMapper(client discovery.DiscoveryInterface) meta.RESTMapper {
+	return restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client))
+}
+
+// GetCache 获取集群的资源缓存，首次访问时随客户端一起启动
+func (p *KubeClientProvider) GetCache(cluster models.Cluster) (*ClusterCache, error) {
+	client, err := p.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return p.Informers.GetCache(cluster, client), nil
+}
+
+// LookupCache 返回集群正在运行的资源缓存
+func (p *KubeClientProvider) LookupCache(clusterID uint) (*ClusterCache, bool) {
+	return p.Informers.Lookup(clusterID)
+}
+
+// CacheStatus 返回集群资源缓存的同步状态
+func (p *KubeClientProvider) CacheStatus(clusterID uint) CacheStatus {
+	return p.Informers.Status(clusterID)
+}
+
+// RemoveClient 移除集群的客户端并停止资源缓存
+func (p *KubeClientProvider) RemoveClient(clusterID uint) {
+	p.Informers.Remove(clusterID)
+	RemoveKubernetesClient(clusterID)
+}
@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// 默认健康检查间隔
+	defaultProbeInterval = time.Minute
+	// 单个集群健康检查的默认超时时间
+	defaultProbeTimeout = 10 * time.Second
+	// 默认同时检查的集群数量
+	defaultProbeConcurrency = 5
+)
+
+// ProbeResult 单次集群健康检查的结果
+type ProbeResult struct {
+	// API Server 是否可以访问且已就绪
+	Reachable bool
+	// 集群版本
+	Version string
+	// API Server 响应延迟
+	Latency time.Duration
+	// 检查失败的原因
+	Err error
+}
+
+// ProbeClusterHealth 调用 /version 和 /readyz 接口检查集群健康状态
+func ProbeClusterHealth(ctx context.Context, client kubernetes.Interface) ProbeResult {
+	start := time.Now()
+
+	var info *version.Info
+	restClient := client.Discovery().RESTClient()
+	if restClient != nil {
+		body, err := restClient.Get().AbsPath("/version").Do(ctx).Raw()
+		if err != nil {
+			return ProbeResult{Err: fmt.Errorf("%w: %v", ErrAPIServerUnreachable, err)}
+		}
+		info = &version.Info{}
+		if err := json.Unmarshal(body, info); err != nil {
+			return ProbeResult{Err: fmt.Errorf("解析集群版本失败: %v", err)}
+		}
+	} else {
+		var err error
+		if info, err = client.Discovery().ServerVersion(); err != nil {
+			return ProbeResult{Err: fmt.Errorf("%w: %v", ErrAPIServerUnreachable, err)}
+		}
+	}
+	result := ProbeResult{Version: info.GitVersion, Latency: time.Since(start)}
+
+	if restClient != nil {
+		if _, err := restClient.Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+			result.Err = fmt.Errorf("API Server 未就绪: %v", err)
+			return result
+		}
+	}
+
+	result.Reachable = true
+	return result
+}
+
+// ProbeIntervalFromEnv 从环境变量 CLUSTER_PROBE_INTERVAL 读取健康检查间隔
+func ProbeIntervalFromEnv() time.Duration {
+	value := os.Getenv("CLUSTER_PROBE_INTERVAL")
+	if value == "" {
+		return defaultProbeInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("CLUSTER_PROBE_INTERVAL 格式错误，使用默认值 %s: %v", defaultProbeInterval, err)
+		return defaultProbeInterval
+	}
+	return interval
+}
+
+// ClusterProber 定期检查所有集群的健康状态，并将结果回写到数据库
+type ClusterProber struct {
+	DB      *gorm.DB
+	Clients ClientProvider
+	// 检查间隔，小于等于 0 时不启动定时检查
+	Interval time.Duration
+	// 单个集群的检查超时时间
+	Timeout time.Duration
+	// 同时检查的集群数量
+	Concurrency int
+}
+
+// NewClusterProber 创建集群健康检查器
+func NewClusterProber(db *gorm.DB, clients ClientProvider, interval time.Duration) *ClusterProber {
+	return &ClusterProber{
+		DB:          db,
+		Clients:     clients,
+		Interval:    interval,
+		Timeout:     defaultProbeTimeout,
+		Concurrency: defaultProbeConcurrency,
+	}
+}
+
+// Run 立即执行一次检查，之后按间隔定期检查，直到 ctx 结束
+func (p *ClusterProber) Run(ctx context.Context) {
+	if p.Interval <= 0 {
+		log.Println("集群健康检查已禁用")
+		return
+	}
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.ProbeAll(ctx); err != nil {
+			log.Printf("集群健康检查失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll 检查所有已注册的集群
+func (p *ClusterProber) ProbeAll(ctx context.Context) error {
+	var clusters []models.Cluster
+	if err := p.DB.WithContext(ctx).Find(&clusters).Error; err != nil {
+		return err
+	}
+
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultProbeConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for _, cluster := range clusters {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(cluster models.Cluster) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			p.ProbeCluster(ctx, cluster)
+		}(cluster)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// ProbeCluster 检查单个集群并回写健康状态
+func (p *ClusterProber) ProbeCluster(ctx context.Context, cluster models.Cluster) ProbeResult {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result ProbeResult
+	client, err := p.Clients.GetClient(cluster)
+	if err != nil {
+		result.Err = err
+	} else {
+		result = ProbeClusterHealth(probeCtx, client)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"cluster_status":  result.Reachable,
+		"last_checked_at": now,
+	}
+	if result.Version != "" {
+		updates["cluster_version"] = result.Version
+		updates["api_latency"] = result.Latency.Milliseconds()
+	}
+	if result.Err != nil {
+		updates["last_error"] = result.Err.Error()
+		updates["last_error_at"] = now
+	} else {
+		// 恢复后清除上一次失败的记录
+		updates["last_error"] = nil
+		updates["last_error_at"] = nil
+	}
+
+	// 只更新健康检查相关的字段，避免覆盖用户同时进行的修改
+	if err := p.DB.WithContext(ctx).Model(&models.Cluster{}).Where("id = ?", cluster.ID).UpdateColumns(updates).Error; err != nil {
+		log.Printf("保存集群 %d 健康状态失败: %v", cluster.ID, err)
+	}
+	return result
+}
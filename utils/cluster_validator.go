@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kbsonlong/kaiops/models"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 集群校验项名称
+const (
+	CheckKubeConfig   = "kubeconfig"
+	CheckConnectivity = "connectivity"
+	CheckPermissions  = "permissions"
+)
+
+// requiredPermissions kaiops 管理集群所需的权限
+var requiredPermissions = []PermissionCheck{
+	{Resource: "nodes", Verb: "list"},
+	{Resource: "nodes", Verb: "watch"},
+	{Resource: "nodes", Verb: "update"},
+	{Resource: "namespaces", Verb: "list"},
+	{Resource: "namespaces", Verb: "create"},
+	{Resource: "namespaces", Verb: "delete"},
+	{Resource: "pods", Verb: "list"},
+	{Resource: "pods", Verb: "watch"},
+	{Resource: "services", Verb: "list"},
+	{Resource: "services", Verb: "watch"},
+	{Resource: "services", Verb: "create"},
+	{Resource: "services", Verb: "update"},
+	{Resource: "services", Verb: "delete"},
+	{Resource: "configmaps", Verb: "list"},
+	{Resource: "configmaps", Verb: "create"},
+	{Resource: "configmaps", Verb: "update"},
+	{Resource: "configmaps", Verb: "delete"},
+	{Resource: "secrets", Verb: "list"},
+	{Resource: "secrets", Verb: "create"},
+	{Resource: "secrets", Verb: "update"},
+	{Resource: "secrets", Verb: "delete"},
+	{Group: "apps", Resource: "deployments", Verb: "list"},
+	{Group: "apps", Resource: "deployments", Verb: "watch"},
+	{Group: "apps", Resource: "deployments", Verb: "create"},
+	{Group: "apps", Resource: "deployments", Verb: "update"},
+	{Group: "apps", Resource: "deployments", Verb: "patch"},
+	{Group: "apps", Resource: "deployments", Verb: "delete"},
+	{Group: "apps", Resource: "deployments/scale", Verb: "update"},
+	{Group: "apps", Resource: "statefulsets", Verb: "list"},
+	{Group: "apps", Resource: "statefulsets", Verb: "watch"},
+	{Group: "apps", Resource: "statefulsets", Verb: "create"},
+	{Group: "apps", Resource: "statefulsets", Verb: "update"},
+	{Group: "apps", Resource: "statefulsets", Verb: "patch"},
+	{Group: "apps", Resource: "statefulsets", Verb: "delete"},
+	{Group: "apps", Resource: "statefulsets/scale", Verb: "update"},
+	{Group: "apps", Resource: "daemonsets", Verb: "list"},
+	{Group: "apps", Resource: "daemonsets", Verb: "watch"},
+	{Group: "apps", Resource: "daemonsets", Verb: "create"},
+	{Group: "apps", Resource: "daemonsets", Verb: "update"},
+	{Group: "apps", Resource: "daemonsets", Verb: "patch"},
+	{Group: "apps", Resource: "daemonsets", Verb: "delete"},
+	{Group: "apps", Resource: "replicasets", Verb: "list"},
+	{Group: "apps", Resource: "controllerrevisions", Verb: "list"},
+	{Group: "batch", Resource: "jobs", Verb: "list"},
+	{Group: "batch", Resource: "jobs", Verb: "watch"},
+	{Group: "batch", Resource: "jobs", Verb: "create"},
+	{Group: "batch", Resource: "jobs", Verb: "update"},
+	{Group: "batch", Resource: "jobs", Verb: "patch"},
+	{Group: "batch", Resource: "jobs", Verb: "delete"},
+	{Group: "batch", Resource: "cronjobs", Verb: "list"},
+	{Group: "batch", Resource: "cronjobs", Verb: "watch"},
+	{Group: "batch", Resource: "cronjobs", Verb: "create"},
+	{Group: "batch", Resource: "cronjobs", Verb: "update"},
+	{Group: "batch", Resource: "cronjobs", Verb: "patch"},
+	{Group: "batch", Resource: "cronjobs", Verb: "delete"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "list"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "create"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "update"},
+	{Group: "networking.k8s.io", Resource: "ingresses", Verb: "delete"},
+}
+
+// ValidationCheck 单项校验结果
+type ValidationCheck struct {
+	// 校验项名称
+	Name string `json:"name" example:"connectivity"`
+	// 是否通过
+	Passed bool `json:"passed" example:"true"`
+	// 校验说明或失败原因
+	Message string `json:"message,omitempty"`
+}
+
+// PermissionCheck 单个权限的校验结果
+type PermissionCheck struct {
+	// API 组，核心组为空
+	Group string `json:"group" example:"apps"`
+	// 资源名称
+	Resource string `json:"resource" example:"deployments"`
+	// 操作
+	Verb string `json:"verb" example:"list"`
+	// 是否允许
+	Allowed bool `json:"allowed" example:"true"`
+}
+
+// ClusterValidationReport 集群注册前的校验报告
+type ClusterValidationReport struct {
+	// kubeconfig 有效且 API Server 可以访问
+	Valid bool `json:"valid" example:"true"`
+	// API Server 地址
+	ServerAddress string `json:"server_address,omitempty" example:"https://api.example.com"`
+	// 集群版本
+	ServerVersion string `json:"server_version,omitempty" example:"v1.30.2"`
+	// 各项校验结果
+	Checks []ValidationCheck `json:"checks"`
+	// 权限校验明细
+	Permissions []PermissionCheck `json:"permissions,omitempty"`
+}
+
+// ValidateCluster 校验集群的 kubeconfig、连通性和权限，不会写入客户端缓存
+// 权限不足只记录在报告中，不影响 Valid
+func ValidateCluster(ctx context.Context, clients ClientProvider, cluster models.Cluster) ClusterValidationReport {
+	var report ClusterValidationReport
+	if config, err := NewRestConfig(cluster); err == nil {
+		report.ServerAddress = config.Host
+	}
+
+	client, err := clients.NewClient(cluster)
+	if err != nil {
+		if errors.Is(err, ErrAPIServerUnreachable) {
+			report.addCheck(CheckKubeConfig, nil)
+			report.addCheck(CheckConnectivity, err)
+		} else {
+			report.addCheck(CheckKubeConfig, err)
+		}
+		return report
+	}
+	report.addCheck(CheckKubeConfig, nil)
+
+	health := ProbeClusterHealth(ctx, client)
+	report.ServerVersion = health.Version
+	if !health.Reachable {
+		report.addCheck(CheckConnectivity, health.Err)
+		return report
+	}
+	report.addCheck(CheckConnectivity, nil)
+	report.Valid = true
+
+	report.Permissions, err = checkPermissions(ctx, client)
+	if err == nil {
+		for _, permission := range report.Permissions {
+			if !permission.Allowed {
+				err = errors.New("缺少部分管理权限")
+				break
+			}
+		}
+	}
+	report.addCheck(CheckPermissions, err)
+
+	return report
+}
+
+// addCheck 记录一项校验结果
+func (r *ClusterValidationReport) addCheck(name string, err error) {
+	check := ValidationCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// checkPermissions 通过 SelfSubjectRulesReview 检查当前凭据是否具备所需权限
+func checkPermissions(ctx context.Context, client kubernetes.Interface) ([]PermissionCheck, error) {
+	review := &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: metav1.NamespaceDefault},
+	}
+	result, err := client.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("查询权限失败: %v", err)
+	}
+
+	permissions := make([]PermissionCheck, 0, len(requiredPermissions))
+	for _, permission := range requiredPermissions {
+		permission.Allowed = rulesAllow(result.Status.ResourceRules, permission)
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+// rulesAllow 判断规则列表是否允许对资源执行指定操作
+func rulesAllow(rules []authorizationv1.ResourceRule, permission PermissionCheck) bool {
+	for _, rule := range rules {
+		// 限定了资源名称的规则不代表拥有该类资源的完整权限
+		if len(rule.ResourceNames) > 0 {
+			continue
+		}
+		if matchRule(rule.APIGroups, permission.Group) &&
+			matchRule(rule.Resources, permission.Resource) &&
+			matchRule(rule.Verbs, permission.Verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRule 判断规则项是否包含指定值或通配符
+func matchRule(values []string, value string) bool {
+	return slices.Contains(values, value) || slices.Contains(values, "*")
+}
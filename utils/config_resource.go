@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MaskedValue 隐藏后的 Secret 值，更新 Secret 时值为 MaskedValue 的键保留原值
+	MaskedValue = "******"
+	// maskedChangedValue 试运行预览中被修改的 Secret 值
+	maskedChangedValue = "****** (changed)"
+	// kubectl apply 保存上次应用配置的注解，Secret 的注解中包含明文
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// ConfigRevisionRecord 配置的一个历史版本
+type ConfigRevisionRecord struct {
+	// 版本号
+	Revision int64 `json:"revision" example:"1"`
+	// 操作: update, delete
+	Action string `json:"action" example:"update"`
+	// 保存的时间
+	CreatedAt time.Time `json:"created_at"`
+	// 修改或删除前的内容
+	Object models.ConfigObject `json:"object"`
+}
+
+// ConfigMapObject 读取 ConfigMap 的内容
+func ConfigMapObject(configMap *corev1.ConfigMap) models.ConfigObject {
+	object := models.ConfigObject{
+		Kind:              "ConfigMap",
+		Name:              configMap.Name,
+		Namespace:         configMap.Namespace,
+		Data:              maps.Clone(configMap.Data),
+		BinaryData:        maps.Clone(configMap.BinaryData),
+		Labels:            configMap.Labels,
+		Annotations:       configMap.Annotations,
+		Immutable:         configMap.Immutable != nil && *configMap.Immutable,
+		ResourceVersion:   configMap.ResourceVersion,
+		CreationTimestamp: configMap.CreationTimestamp,
+	}
+	if object.Data == nil {
+		object.Data = map[string]string{}
+	}
+	return object
+}
+
+// SecretObject 读取 Secret 的明文内容，不是合法 UTF-8 的值放在 BinaryData 中
+func SecretObject(secret *corev1.Secret) models.ConfigObject {
+	object := models.ConfigObject{
+		Kind:              "Secret",
+		Name:              secret.Name,
+		Namespace:         secret.Namespace,
+		Type:              string(secret.Type),
+		Data:              map[string]string{},
+		Labels:            secret.Labels,
+		Annotations:       secret.Annotations,
+		Immutable:         secret.Immutable != nil && *secret.Immutable,
+		ResourceVersion:   secret.ResourceVersion,
+		CreationTimestamp: secret.CreationTimestamp,
+	}
+	for key, value := range secret.Data {
+		if utf8.Valid(value) {
+			object.Data[key] = string(value)
+			continue
+		}
+		if object.BinaryData == nil {
+			object.BinaryData = map[string][]byte{}
+		}
+		object.BinaryData[key] = value
+	}
+	return object
+}
+
+// MaskConfigObject 隐藏 Secret 的值，只保留键名，ConfigMap 原样返回
+func MaskConfigObject(object models.ConfigObject) models.ConfigObject {
+	if object.Kind != "Secret" {
+		return object
+	}
+	data := make(map[string]string, len(object.Data)+len(object.BinaryData))
+	for key := range object.Data {
+		data[key] = MaskedValue
+	}
+	for key := range object.BinaryData {
+		data[key] = MaskedValue
+	}
+	object.Data = data
+	object.BinaryData = nil
+	object.Annotations = maskAnnotations(object.Annotations)
+	object.Masked = true
+	return object
+}
+
+// MaskSecretPreview 隐藏试运行前后 Secret 的值，被修改的键显示为已修改，不暴露明文
+// 隐藏后的值放在 stringData 中，使差异中的值可读
+func MaskSecretPreview(live, admitted *corev1.Secret) (*corev1.Secret, *corev1.Secret) {
+	maskSecret := func(secret *corev1.Secret, changed func(key string, value []byte) bool) *corev1.Secret {
+		if secret == nil {
+			return nil
+		}
+		masked := secret.DeepCopy()
+		masked.Data = nil
+		masked.StringData = make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			masked.StringData[key] = MaskedValue
+			if changed(key, value) {
+				masked.StringData[key] = maskedChangedValue
+			}
+		}
+		masked.Annotations = maskAnnotations(masked.Annotations)
+		return masked
+	}
+
+	maskedLive := maskSecret(live, func(string, []byte) bool { return false })
+	maskedAdmitted := maskSecret(admitted, func(key string, value []byte) bool {
+		if live == nil {
+			return false
+		}
+		previous, ok := live.Data[key]
+		return ok && string(previous) != string(value)
+	})
+	return maskedLive, maskedAdmitted
+}
+
+// maskAnnotations 隐藏 kubectl apply 保存的上次应用配置
+func maskAnnotations(annotations map[string]string) map[string]string {
+	if _, ok := annotations[lastAppliedAnnotation]; !ok {
+		return annotations
+	}
+	masked := maps.Clone(annotations)
+	masked[lastAppliedAnnotation] = MaskedValue
+	return masked
+}
+
+// RecordConfigRevision 保存 ConfigMap 或 Secret 修改或删除前的内容，版本号为该对象已有的最大版本号加 1
+// 应与集群中的修改在同一个事务中调用，修改失败时回滚
+func RecordConfigRevision(tx *gorm.DB, clusterID uint, action string, object models.ConfigObject) error {
+	var latest int64
+	err := tx.Model(&models.ConfigRevision{}).
+		Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterID, object.Kind, object.Namespace, object.Name).
+		Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.ConfigRevision{
+		ClusterID:       clusterID,
+		Kind:            object.Kind,
+		Namespace:       object.Namespace,
+		Name:            object.Name,
+		Revision:        latest + 1,
+		Action:          action,
+		ResourceVersion: object.ResourceVersion,
+		Content:         models.EncryptedString(content),
+	}).Error
+}
+
+// ListConfigRevisions 读取 ConfigMap 或 Secret 的历史版本，按版本号从新到旧排序。reveal 为 false 时隐藏 Secret 的值
+func ListConfigRevisions(db *gorm.DB, clusterID uint, kind, namespace, name string, reveal bool) ([]ConfigRevisionRecord, error) {
+	var revisions []models.ConfigRevision
+	err := db.Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", clusterID, kind, namespace, name).
+		Order("revision DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]ConfigRevisionRecord, 0, len(revisions))
+	for _, revision := range revisions {
+		var object models.ConfigObject
+		if err := json.Unmarshal([]byte(revision.Content), &object); err != nil {
+			return nil, err
+		}
+		if !reveal {
+			object = MaskConfigObject(object)
+		}
+		records = append(records, ConfigRevisionRecord{
+			Revision:  revision.Revision,
+			Action:    revision.Action,
+			CreatedAt: revision.CreatedAt,
+			Object:    object,
+		})
+	}
+	return records, nil
+}
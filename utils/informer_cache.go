@@ -0,0 +1,448 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 默认的缓存空闲回收时间
+const defaultInformerIdleTimeout = 10 * time.Minute
+
+// ErrCacheNotSynced 集群资源缓存尚未完成首次同步
+var ErrCacheNotSynced = errors.New("集群资源缓存同步中，请稍后重试")
+
+// 缓存的资源名称
+const (
+	ResourceNodes        = "nodes"
+	ResourceDeployments  = "deployments"
+	ResourceStatefulSets = "statefulsets"
+	ResourceDaemonSets   = "daemonsets"
+	ResourceJobs         = "jobs"
+	ResourceCronJobs     = "cronjobs"
+	ResourcePods         = "pods"
+	ResourceServices     = "services"
+)
+
+// workloadResources 工作负载类型对应的缓存资源
+var workloadResources = map[string]string{
+	"Deployment":  ResourceDeployments,
+	"StatefulSet": ResourceStatefulSets,
+	"DaemonSet":   ResourceDaemonSets,
+	"Job":         ResourceJobs,
+	"CronJob":     ResourceCronJobs,
+}
+
+// WorkloadResource 返回工作负载类型对应的缓存资源名称，不支持的类型返回空字符串
+func WorkloadResource(kind string) string {
+	return workloadResources[kind]
+}
+
+// WorkloadResources 返回所有工作负载类型的缓存资源名称
+func WorkloadResources() []string {
+	return []string{ResourceDeployments, ResourceStatefulSets, ResourceDaemonSets, ResourceJobs, ResourceCronJobs}
+}
+
+// CacheStatus 集群缓存的同步状态
+type CacheStatus struct {
+	// 缓存是否已启动
+	Started bool `json:"started" example:"true"`
+	// 所有资源是否已完成首次同步
+	Synced bool `json:"synced" example:"true"`
+	// 各资源的同步状态
+	Resources map[string]bool `json:"resources,omitempty"`
+	// 尚未完成同步的资源最近一次 list/watch 失败的原因，例如缺少权限
+	Errors map[string]string `json:"errors,omitempty"`
+	// 缓存启动时间
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// 最近一次访问时间
+	LastAccessAt *time.Time `json:"last_access_at,omitempty"`
+}
+
+// ClusterCache 基于 SharedInformer 的集群资源只读缓存
+type ClusterCache struct {
+	client    kubernetes.Interface
+	factory   informers.SharedInformerFactory
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	startedAt time.Time
+	synced    map[string]cache.InformerSynced
+
+	nodes        corelisters.NodeLister
+	deployments  appslisters.DeploymentLister
+	statefulSets appslisters.StatefulSetLister
+	daemonSets   appslisters.DaemonSetLister
+	jobs         batchlisters.JobLister
+	cronJobs     batchlisters.CronJobLister
+	pods         corelisters.PodLister
+	services     corelisters.ServiceLister
+
+	mu          sync.Mutex
+	lastAccess  time.Time
+	watchErrors map[string]string
+}
+
+// NewClusterCache 创建并启动集群资源缓存
+func NewClusterCache(client kubernetes.Interface) *ClusterCache {
+	factory := informers.NewSharedInformerFactory(client, 0)
+	c := &ClusterCache{
+		client:       client,
+		factory:      factory,
+		stopCh:       make(chan struct{}),
+		startedAt:    time.Now(),
+		nodes:        factory.Core().V1().Nodes().Lister(),
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+		cronJobs:     factory.Batch().V1().CronJobs().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+		services:     factory.Core().V1().Services().Lister(),
+		lastAccess:   time.Now(),
+		watchErrors:  make(map[string]string),
+	}
+	resourceInformers := map[string]cache.SharedIndexInformer{
+		ResourceNodes:        factory.Core().V1().Nodes().Informer(),
+		ResourceDeployments:  factory.Apps().V1().Deployments().Informer(),
+		ResourceStatefulSets: factory.Apps().V1().StatefulSets().Informer(),
+		ResourceDaemonSets:   factory.Apps().V1().DaemonSets().Informer(),
+		ResourceJobs:         factory.Batch().V1().Jobs().Informer(),
+		ResourceCronJobs:     factory.Batch().V1().CronJobs().Informer(),
+		ResourcePods:         factory.Core().V1().Pods().Informer(),
+		ResourceServices:     factory.Core().V1().Services().Informer(),
+	}
+	c.synced = make(map[string]cache.InformerSynced, len(resourceInformers))
+	for resource, informer := range resourceInformers {
+		c.synced[resource] = informer.HasSynced
+		// 记录 list/watch 失败的原因，缺少某个资源的权限时只影响读取该资源的接口
+		_ = informer.SetWatchErrorHandler(c.watchErrorHandler(resource))
+	}
+	factory.Start(c.stopCh)
+	return c
+}
+
+// Nodes 返回节点 Lister
+func (c *ClusterCache) Nodes() corelisters.NodeLister {
+	return c.nodes
+}
+
+// Deployments 返回 Deployment Lister
+func (c *ClusterCache) Deployments() appslisters.DeploymentLister {
+	return c.deployments
+}
+
+// StatefulSets 返回 StatefulSet Lister
+func (c *ClusterCache) StatefulSets() appslisters.StatefulSetLister {
+	return c.statefulSets
+}
+
+// DaemonSets 返回 DaemonSet Lister
+func (c *ClusterCache) DaemonSets() appslisters.DaemonSetLister {
+	return c.daemonSets
+}
+
+// Jobs 返回 Job Lister
+func (c *ClusterCache) Jobs() batchlisters.JobLister {
+	return c.jobs
+}
+
+// CronJobs 返回 CronJob Lister
+func (c *ClusterCache) CronJobs() batchlisters.CronJobLister {
+	return c.cronJobs
+}
+
+// Pods 返回 Pod Lister
+func (c *ClusterCache) Pods() corelisters.PodLister {
+	return c.pods
+}
+
+// Services 返回 Service Lister
+func (c *ClusterCache) Services() corelisters.ServiceLister {
+	return c.services
+}
+
+// HasSynced 指定的资源是否已完成首次同步，未指定资源时检查所有资源
+func (c *ClusterCache) HasSynced(resources ...string) bool {
+	for _, synced := range c.syncedFuncs(resources) {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForSync 等待指定的资源完成首次同步，未指定资源时等待所有资源，超时或 ctx 结束时返回 false
+func (c *ClusterCache) WaitForSync(ctx context.Context, timeout time.Duration, resources ...string) bool {
+	if c.HasSynced(resources...) {
+		return true
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stopCh := make(chan struct{})
+	go func() {
+		select {
+		case <-waitCtx.Done():
+		case <-c.stopCh:
+		}
+		close(stopCh)
+	}()
+
+	return cache.WaitForCacheSync(stopCh, c.syncedFuncs(resources)...)
+}
+
+// syncedFuncs 返回指定资源的同步检查函数，未指定资源时返回所有资源的，忽略未缓存的资源
+func (c *ClusterCache) syncedFuncs(resources []string) []cache.InformerSynced {
+	if len(resources) == 0 {
+		resources = make([]string, 0, len(c.synced))
+		for resource := range c.synced {
+			resources = append(resources, resource)
+		}
+	}
+	synced := make([]cache.InformerSynced, 0, len(resources))
+	for _, resource := range resources {
+		if fn, ok := c.synced[resource]; ok {
+			synced = append(synced, fn)
+		}
+	}
+	return synced
+}
+
+// watchErrorHandler 记录资源 list/watch 失败的原因，并保留默认的日志输出
+func (c *ClusterCache) watchErrorHandler(resource string) cache.WatchErrorHandler {
+	return func(r *cache.Reflector, err error) {
+		c.mu.Lock()
+		c.watchErrors[resource] = err.Error()
+		c.mu.Unlock()
+		cache.DefaultWatchErrorHandler(r, err)
+	}
+}
+
+// notSyncedError 返回指定资源中尚未同步的资源及其失败原因
+func (c *ClusterCache) notSyncedError(resources []string) error {
+	status := c.Status()
+	var details []string
+	for resource, synced := range status.Resources {
+		if synced || (len(resources) > 0 && !slices.Contains(resources, resource)) {
+			continue
+		}
+		if reason, ok := status.Errors[resource]; ok {
+			details = append(details, fmt.Sprintf("%s: %s", resource, reason))
+		} else {
+			details = append(details, resource)
+		}
+	}
+	if len(details) == 0 {
+		return ErrCacheNotSynced
+	}
+	sort.Strings(details)
+	return fmt.Errorf("%w: %s", ErrCacheNotSynced, strings.Join(details, "; "))
+}
+
+// Status 返回缓存的同步状态
+func (c *ClusterCache) Status() CacheStatus {
+	status := CacheStatus{
+		Started:   true,
+		Synced:    true,
+		Resources: make(map[string]bool, len(c.synced)),
+		StartedAt: &c.startedAt,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for resource, synced := range c.synced {
+		status.Resources[resource] = synced()
+		status.Synced = status.Synced && status.Resources[resource]
+		// 已同步的资源之后的 watch 中断会自动恢复，只报告尚未同步的资源的失败原因
+		if reason, ok := c.watchErrors[resource]; ok && !status.Resources[resource] {
+			if status.Errors == nil {
+				status.Errors = make(map[string]string)
+			}
+			status.Errors[resource] = reason
+		}
+	}
+	lastAccess := c.lastAccess
+	status.LastAccessAt = &lastAccess
+	return status
+}
+
+// Stop 停止所有 informer
+func (c *ClusterCache) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+		c.factory.Shutdown()
+	})
+}
+
+// LastAccess 返回最近一次访问时间
+func (c *ClusterCache) LastAccess() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastAccess
+}
+
+// touch 记录一次访问
+func (c *ClusterCache) touch() {
+	c.mu.Lock()
+	c.lastAccess = time.Now()
+	c.mu.Unlock()
+}
+
+// SyncedCache 获取集群资源缓存并等待指定的资源完成首次同步，未指定资源时等待所有资源
+// 超时返回包装了 ErrCacheNotSynced 的错误，其中列出未同步的资源及失败原因
+func SyncedCache(ctx context.Context, clients ClientProvider, cluster models.Cluster, timeout time.Duration, resources ...string) (*ClusterCache, error) {
+	clusterCache, err := clients.GetCache(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !clusterCache.WaitForSync(ctx, timeout, resources...) {
+		return nil, clusterCache.notSyncedError(resources)
+	}
+	return clusterCache, nil
+}
+
+// InformerIdleTimeoutFromEnv 从环境变量 INFORMER_IDLE_TIMEOUT 读取缓存空闲回收时间
+func InformerIdleTimeoutFromEnv() time.Duration {
+	value := os.Getenv("INFORMER_IDLE_TIMEOUT")
+	if value == "" {
+		return defaultInformerIdleTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("INFORMER_IDLE_TIMEOUT 格式错误，使用默认值 %s: %v", defaultInformerIdleTimeout, err)
+		return defaultInformerIdleTimeout
+	}
+	return timeout
+}
+
+// InformerManager 按集群懒加载资源缓存，并回收长时间未访问的缓存
+type InformerManager struct {
+	// 缓存空闲超过该时间后停止，小于等于 0 时不回收
+	IdleTimeout time.Duration
+
+	mu     sync.Mutex
+	caches map[uint]*ClusterCache
+}
+
+// NewInformerManager 创建缓存管理器
+func NewInformerManager(idleTimeout time.Duration) *InformerManager {
+	return &InformerManager{IdleTimeout: idleTimeout, caches: make(map[uint]*ClusterCache)}
+}
+
+// GetCache 获取集群的资源缓存，未启动或客户端已重建时使用 client 重新启动
+func (m *InformerManager) GetCache(cluster models.Cluster, client kubernetes.Interface) *ClusterCache {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, exists := m.caches[cluster.ID]
+	if exists && c.client != client {
+		c.Stop()
+		exists = false
+	}
+	if !exists {
+		c = NewClusterCache(client)
+		m.caches[cluster.ID] = c
+	}
+	c.touch()
+	return c
+}
+
+// Lookup 返回集群正在运行的资源缓存，不会启动缓存，也不会刷新访问时间
+func (m *InformerManager) Lookup(clusterID uint) (*ClusterCache, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, exists := m.caches[clusterID]
+	return c, exists
+}
+
+// Status 返回集群缓存的同步状态，缓存未启动时 Started 为 false
+func (m *InformerManager) Status(clusterID uint) CacheStatus {
+	m.mu.Lock()
+	c, exists := m.caches[clusterID]
+	m.mu.Unlock()
+
+	if !exists {
+		return CacheStatus{}
+	}
+	return c.Status()
+}
+
+// Remove 停止并移除集群的资源缓存
+func (m *InformerManager) Remove(clusterID uint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, exists := m.caches[clusterID]; exists {
+		c.Stop()
+		delete(m.caches, clusterID)
+	}
+}
+
+// StopIdle 停止空闲时间超过 IdleTimeout 的缓存，返回停止的数量
+func (m *InformerManager) StopIdle() int {
+	if m.IdleTimeout <= 0 {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stopped := 0
+	for clusterID, c := range m.caches {
+		if time.Since(c.LastAccess()) > m.IdleTimeout {
+			c.Stop()
+			delete(m.caches, clusterID)
+			stopped++
+		}
+	}
+	return stopped
+}
+
+// StopAll 停止所有缓存
+func (m *InformerManager) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for clusterID, c := range m.caches {
+		c.Stop()
+		delete(m.caches, clusterID)
+	}
+}
+
+// Run 定期回收空闲的缓存，直到 ctx 结束
+func (m *InformerManager) Run(ctx context.Context) {
+	if m.IdleTimeout <= 0 {
+		log.Println("集群缓存空闲回收已禁用")
+		return
+	}
+
+	ticker := time.NewTicker(m.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.StopAll()
+			return
+		case <-ticker.C:
+			if stopped := m.StopIdle(); stopped > 0 {
+				log.Printf("已停止 %d 个空闲的集群缓存", stopped)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package utils_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInformerManager(t *testing.T) {
+	manager := utils.NewInformerManager(time.Hour)
+	t.Cleanup(manager.StopAll)
+
+	cluster := models.Cluster{}
+	cluster.ID = 1
+	client := fake.NewClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}})
+
+	clusterCache := manager.GetCache(cluster, client)
+	if !clusterCache.WaitForSync(context.Background(), 5*time.Second) {
+		t.Fatalf("缓存未完成同步")
+	}
+	if _, err := clusterCache.Deployments().Deployments("default").Get("web"); err != nil {
+		t.Fatalf("缓存中应存在 Deployment: %v", err)
+	}
+	if manager.GetCache(cluster, client) != clusterCache {
+		t.Fatalf("客户端未变化时应复用缓存")
+	}
+	if manager.GetCache(cluster, fake.NewClientset()) == clusterCache {
+		t.Fatalf("客户端重建后应重新创建缓存")
+	}
+
+	if stopped := manager.StopIdle(); stopped != 0 {
+		t.Fatalf("未空闲的缓存不应被停止，实际停止 %d 个", stopped)
+	}
+	manager.IdleTimeout = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if stopped := manager.StopIdle(); stopped != 1 {
+		t.Fatalf("期望停止 1 个空闲缓存，实际 %d 个", stopped)
+	}
+	if manager.Status(cluster.ID).Started {
+		t.Fatalf("空闲缓存停止后状态应为未启动")
+	}
+}
+
+func TestClusterCachePartialSync(t *testing.T) {
+	client := fake.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("pods"), "", fmt.Errorf("缺少权限"))
+	})
+	clusterCache := utils.NewClusterCache(client)
+	t.Cleanup(clusterCache.Stop)
+
+	// 缺少 Pod 权限不影响只读取节点的调用方
+	if !clusterCache.WaitForSync(context.Background(), 5*time.Second, utils.ResourceNodes) {
+		t.Fatalf("节点缓存未完成同步")
+	}
+	if _, err := clusterCache.Nodes().Get("node-1"); err != nil {
+		t.Fatalf("缓存中应存在节点: %v", err)
+	}
+	if clusterCache.WaitForSync(context.Background(), 200*time.Millisecond, utils.ResourcePods) {
+		t.Fatalf("缺少权限时 Pod 缓存不应完成同步")
+	}
+
+	status := clusterCache.Status()
+	if status.Synced || !status.Resources[utils.ResourceNodes] || status.Resources[utils.ResourcePods] {
+		t.Fatalf("缓存状态不符合预期: %+v", status)
+	}
+	if !strings.Contains(status.Errors[utils.ResourcePods], "缺少权限") {
+		t.Fatalf("应报告 Pod 缓存同步失败的原因: %+v", status.Errors)
+	}
+	if _, ok := status.Errors[utils.ResourceNodes]; ok {
+		t.Fatalf("已同步的资源不应报告失败原因: %+v", status.Errors)
+	}
+}
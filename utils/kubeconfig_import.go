@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// KubeContextInfo kubeconfig 中单个上下文的信息
+type KubeContextInfo struct {
+	// 上下文名称
+	Name string `json:"name" example:"prod-admin@prod"`
+	// 上下文引用的集群名称
+	Cluster string `json:"cluster" example:"prod"`
+	// 上下文引用的用户名称
+	User string `json:"user" example:"prod-admin"`
+	// API Server 地址
+	Server string `json:"server" example:"https://api.example.com"`
+	// 是否为 current-context
+	Current bool `json:"current" example:"true"`
+}
+
+// LoadKubeConfig 解析原始或 base64 编码的 kubeconfig
+func LoadKubeConfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	data := []byte(kubeconfig)
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(kubeconfig)); err == nil {
+		data = decoded
+	}
+
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKubeConfig, err)
+	}
+	return config, nil
+}
+
+// ListKubeContexts 列出 kubeconfig 中的所有上下文，按名称排序
+func ListKubeContexts(config *clientcmdapi.Config) []KubeContextInfo {
+	contexts := make([]KubeContextInfo, 0, len(config.Contexts))
+	for name, context := range config.Contexts {
+		info := KubeContextInfo{
+			Name:    name,
+			Cluster: context.Cluster,
+			User:    context.AuthInfo,
+			Current: name == config.CurrentContext,
+		}
+		if cluster, exists := config.Clusters[context.Cluster]; exists {
+			info.Server = cluster.Server
+		}
+		contexts = append(contexts, info)
+	}
+
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts
+}
+
+// ExtractKubeContext 生成只包含指定上下文及其集群、用户的 base64 编码 kubeconfig
+func ExtractKubeContext(config *clientcmdapi.Config, contextName string) (string, error) {
+	context, exists := config.Contexts[contextName]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrContextNotFound, contextName)
+	}
+	cluster, exists := config.Clusters[context.Cluster]
+	if !exists {
+		return "", fmt.Errorf("%w: 上下文 %s 引用的集群 %s 不存在", ErrInvalidKubeConfig, contextName, context.Cluster)
+	}
+
+	extracted := clientcmdapi.NewConfig()
+	extracted.Clusters[context.Cluster] = cluster
+	extracted.Contexts[contextName] = context
+	extracted.CurrentContext = contextName
+	if authInfo, exists := config.AuthInfos[context.AuthInfo]; exists {
+		extracted.AuthInfos[context.AuthInfo] = authInfo
+	}
+
+	data, err := clientcmd.Write(*extracted)
+	if err != nil {
+		return "", fmt.Errorf("生成 kubeconfig 失败: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// NormalizeServerURL 规范化 API Server 地址，用于判断集群是否重复
+func NormalizeServerURL(server string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(server), "/"))
+}
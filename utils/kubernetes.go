@@ -1,75 +1,172 @@
 package utils
 
 import (
-	"encoding/base64"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"os"
 	"sync"
 
 	"github.com/kbsonlong/kaiops/models"
+	"golang.org/x/sync/singleflight"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
+// clientEntry 缓存的集群客户端及创建它时使用的凭据指纹
+type clientEntry struct {
+	fingerprint string
+	clientset   *kubernetes.Clientset
+	// dynamicClient 和 mapper 首次使用时创建，与 clientset 共用同一凭据指纹
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+}
+
 var (
 	// clientsets 存储所有集群的客户端连接
-	clientsets = make(map[uint]*kubernetes.Clientset)
-	// clientsetsMutex 用于保护 clientsets map 的并发访问
+	clientsets = make(map[uint]clientEntry)
+	// clientGenerations 记录每个集群的客户端缓存被写入或移除的次数，用于丢弃过期的初始化结果
+	clientGenerations = make(map[uint]uint64)
+	// clientsetsMutex 用于保护 clientsets 和 clientGenerations 的并发访问
 	clientsetsMutex sync.RWMutex
+	// clientsetsGroup 合并同一集群、同一凭据的并发初始化
+	clientsetsGroup singleflight.Group
 )
 
+// ClusterFingerprint 计算集群连接凭据和连接参数的指纹，任一项变化时指纹随之变化
+func ClusterFingerprint(cluster models.Cluster) string {
+	hash := sha256.New()
+	for _, field := range []string{
+		cluster.AuthType,
+		cluster.ClusterApi,
+		string(cluster.KubeConfig),
+		cluster.KubeContext,
+		string(cluster.BearerToken),
+		cluster.CAData,
+		cluster.ClientCertData,
+		string(cluster.ClientKeyData),
+		fmt.Sprint(cluster.QPS),
+		fmt.Sprint(cluster.Burst),
+		fmt.Sprint(cluster.RequestTimeout),
+		string(cluster.ProxyURL),
+		cluster.TLSServerName,
+		fmt.Sprint(cluster.InsecureSkipVerify),
+	} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 // InitKubernetesClient 初始化指定集群的 Kubernetes 客户端
 func InitKubernetesClient(cluster models.Cluster) error {
-	// 解码 base64 编码的 kubeconfig
-	kubeconfigBytes, err := base64.StdEncoding.DecodeString(cluster.KubeConfig)
+	_, err := initKubernetesClient(cluster)
+	return err
+}
+
+// initKubernetesClient 创建客户端并写入缓存，同一集群同一凭据的并发调用只会创建一次
+// 创建期间缓存被其他凭据的客户端覆盖或被移除时，本次创建的客户端只返回给调用方，不写入缓存
+func initKubernetesClient(cluster models.Cluster) (*kubernetes.Clientset, error) {
+	fingerprint := ClusterFingerprint(cluster)
+	key := fmt.Sprintf("%d/%s", cluster.ID, fingerprint)
+
+	result, err, _ := clientsetsGroup.Do(key, func() (interface{}, error) {
+		clientsetsMutex.RLock()
+		generation := clientGenerations[cluster.ID]
+		clientsetsMutex.RUnlock()
+
+		clientset, err := NewClientset(cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		// 将客户端存储到全局 map 中
+		clientsetsMutex.Lock()
+		defer clientsetsMutex.Unlock()
+		if clientGenerations[cluster.ID] != generation {
+			return clientset, nil
+		}
+		clientsets[cluster.ID] = clientEntry{fingerprint: fingerprint, clientset: clientset}
+		clientGenerations[cluster.ID]++
+
+		return clientset, nil
+	})
 	if err != nil {
-		return fmt.Errorf("kubeconfig 解码失败: %v", err)
+		return nil, err
 	}
+	return result.(*kubernetes.Clientset), nil
+}
+
+// GetOrInitKubernetesClient 获取指定集群的客户端，未初始化或凭据已变化时重新创建
+func GetOrInitKubernetesClient(cluster models.Cluster) (*kubernetes.Clientset, error) {
+	clientsetsMutex.RLock()
+	entry, exists := clientsets[cluster.ID]
+	clientsetsMutex.RUnlock()
 
-	// 创建临时 kubeconfig 文件
-	tmpKubeconfig := fmt.Sprintf("%s/.kube/config_%d", homedir.HomeDir(), cluster.ID)
-	if err := os.WriteFile(tmpKubeconfig, kubeconfigBytes, 0600); err != nil {
-		return fmt.Errorf("创建临时 kubeconfig 文件失败: %v", err)
+	if exists && entry.fingerprint == ClusterFingerprint(cluster) {
+		return entry.clientset, nil
 	}
-	defer os.Remove(tmpKubeconfig)
+	return initKubernetesClient(cluster)
+}
 
-	// 创建 kubernetes 客户端
-	config, err := clientcmd.BuildConfigFromFlags("", tmpKubeconfig)
+// GetOrInitDynamicClient 获取指定集群的动态客户端和 RESTMapper，凭据未变化时复用缓存
+// RESTMapper 缓存 discovery 结果，找不到类型时才会重新读取 API 资源列表
+func GetOrInitDynamicClient(cluster models.Cluster) (dynamic.Interface, meta.RESTMapper, error) {
+	clientset, err := GetOrInitKubernetesClient(cluster)
 	if err != nil {
-		return fmt.Errorf("创建 kubernetes 配置失败: %v", err)
+		return nil, nil, err
+	}
+	fingerprint := ClusterFingerprint(cluster)
+
+	clientsetsMutex.RLock()
+	entry, exists := clientsets[cluster.ID]
+	clientsetsMutex.RUnlock()
+	if exists && entry.fingerprint == fingerprint && entry.dynamicClient != nil {
+		return entry.dynamicClient, entry.mapper, nil
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	config, err := NewRestConfig(cluster)
 	if err != nil {
-		return fmt.Errorf("创建 kubernetes 客户端失败: %v", err)
+		return nil, nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建动态客户端失败: %v", err)
+	}
+	mapper := newDiscoveryRESTMapper(clientset.Discovery())
 
-	// 将客户端存储到全局 map 中
 	clientsetsMutex.Lock()
-	clientsets[cluster.ID] = clientset
-	clientsetsMutex.Unlock()
-
-	return nil
+	defer clientsetsMutex.Unlock()
+	entry, exists = clientsets[cluster.ID]
+	if !exists || entry.fingerprint != fingerprint || entry.clientset != clientset {
+		// 客户端已被移除或凭据已变化，本次创建的动态客户端不写入缓存
+		return dynamicClient, mapper, nil
+	}
+	if entry.dynamicClient == nil {
+		entry.dynamicClient, entry.mapper = dynamicClient, mapper
+		clientsets[cluster.ID] = entry
+	}
+	return entry.dynamicClient, entry.mapper, nil
 }
 
 // GetKubernetesClient 获取指定集群的 Kubernetes 客户端
 func GetKubernetesClient(clusterID uint) (*kubernetes.Clientset, error) {
 	// 从全局 map 中获取客户端
 	clientsetsMutex.RLock()
-	clientset, exists := clientsets[clusterID]
+	entry, exists := clientsets[clusterID]
 	clientsetsMutex.RUnlock()
 
 	if exists {
-		return clientset, nil
+		return entry.clientset, nil
 	}
 
 	return nil, fmt.Errorf("集群 %d 的客户端未初始化", clusterID)
 }
 
-// RemoveKubernetesClient 从全局 map 中移除指定集群的客户端
+// RemoveKubernetesClient 从全局 map 中移除指定集群的客户端，包括动态客户端和 RESTMapper
 func RemoveKubernetesClient(clusterID uint) {
 	clientsetsMutex.Lock()
 	delete(clientsets, clusterID)
+	clientGenerations[clusterID]++
 	clientsetsMutex.Unlock()
 }
@@ -0,0 +1,381 @@
+package utils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	"k8s.io/client-go/kubernetes"
+)
+
+// newTestAPIServer 启动一个只响应 /version 的 API Server
+func newTestAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"major":"1","minor":"30","gitVersion":"v1.30.0"}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testKubeConfig 生成包含两个上下文的 base64 编码 kubeconfig
+func testKubeConfig(server, currentContext string) models.EncryptedString {
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: primary
+  cluster:
+    server: %[1]s
+- name: secondary
+  cluster:
+    server: %[1]s/secondary
+users:
+- name: admin
+  user:
+    token: test-token
+contexts:
+- name: primary
+  context:
+    cluster: primary
+    user: admin
+- name: secondary
+  context:
+    cluster: secondary
+    user: admin
+current-context: %[2]q
+`, server, currentContext)
+	return models.EncryptedString(base64.StdEncoding.EncodeToString([]byte(kubeconfig)))
+}
+
+func TestNewRestConfig(t *testing.T) {
+	server := newTestAPIServer(t)
+
+	config, err := utils.NewRestConfig(models.Cluster{KubeConfig: testKubeConfig(server.URL, "primary")})
+	if err != nil {
+		t.Fatalf("构建 rest.Config 失败: %v", err)
+	}
+	if config.Host != server.URL {
+		t.Fatalf("期望使用 current-context 的地址 %s，实际 %s", server.URL, config.Host)
+	}
+
+	config, err = utils.NewRestConfig(models.Cluster{KubeConfig: testKubeConfig(server.URL, "primary"), KubeContext: "secondary"})
+	if err != nil {
+		t.Fatalf("构建 rest.Config 失败: %v", err)
+	}
+	if config.Host != server.URL+"/secondary" {
+		t.Fatalf("期望使用指定上下文的地址，实际 %s", config.Host)
+	}
+}
+
+func TestNewRestConfigErrors(t *testing.T) {
+	server := newTestAPIServer(t)
+
+	tests := []struct {
+		name    string
+		cluster models.Cluster
+		want    error
+	}{
+		{"bad base64", models.Cluster{KubeConfig: "not base64!"}, utils.ErrKubeConfigDecode},
+		{"no current context", models.Cluster{KubeConfig: testKubeConfig(server.URL, "")}, utils.ErrNoCurrentContext},
+		{"unknown context", models.Cluster{KubeConfig: testKubeConfig(server.URL, ""), KubeContext: "missing"}, utils.ErrContextNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := utils.NewRestConfig(tt.cluster); !errors.Is(err, tt.want) {
+				t.Fatalf("期望错误 %v，实际 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewClientsetUnreachable(t *testing.T) {
+	server := newTestAPIServer(t)
+	url := server.URL
+	server.Close()
+
+	_, err := utils.NewClientset(models.Cluster{KubeConfig: testKubeConfig(url, "primary")})
+	if !errors.Is(err, utils.ErrAPIServerUnreachable) {
+		t.Fatalf("期望错误 %v，实际 %v", utils.ErrAPIServerUnreachable, err)
+	}
+}
+
+func TestClientCacheFingerprint(t *testing.T) {
+	server := newTestAPIServer(t)
+	cluster := models.Cluster{KubeConfig: testKubeConfig(server.URL, "primary")}
+	cluster.ID = 1001
+	t.Cleanup(func() { utils.RemoveKubernetesClient(cluster.ID) })
+
+	first, err := utils.GetOrInitKubernetesClient(cluster)
+	if err != nil {
+		t.Fatalf("初始化客户端失败: %v", err)
+	}
+	second, err := utils.GetOrInitKubernetesClient(cluster)
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	if first != second {
+		t.Fatalf("凭据未变化时应复用缓存的客户端")
+	}
+
+	// 切换上下文后凭据指纹变化，应重新创建客户端
+	cluster.KubeContext = "secondary"
+	rebuilt, err := utils.GetOrInitKubernetesClient(cluster)
+	if err != nil {
+		t.Fatalf("重新创建客户端失败: %v", err)
+	}
+	if rebuilt == first {
+		t.Fatalf("凭据变化后应重新创建客户端")
+	}
+
+	utils.RemoveKubernetesClient(cluster.ID)
+	if _, err := utils.GetKubernetesClient(cluster.ID); err == nil {
+		t.Fatalf("移除后不应再获取到客户端")
+	}
+}
+
+func TestClientCacheDiscardsStaleInit(t *testing.T) {
+	// primary 上下文的 /version 请求阻塞到测试放行，模拟初始化期间缓存发生变化
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/version" {
+			started <- struct{}{}
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"major":"1","minor":"30","gitVersion":"v1.30.0"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	initDuring := func(t *testing.T, cluster models.Cluster, change func()) *kubernetes.Clientset {
+		t.Helper()
+		done := make(chan *kubernetes.Clientset)
+		go func() {
+			clientset, err := utils.GetOrInitKubernetesClient(cluster)
+			if err != nil {
+				t.Errorf("初始化客户端失败: %v", err)
+			}
+			done <- clientset
+		}()
+		<-started
+		change()
+		close(release)
+		return <-done
+	}
+
+	cluster := models.Cluster{KubeConfig: testKubeConfig(server.URL, "primary")}
+	cluster.ID = 1003
+	t.Cleanup(func() { utils.RemoveKubernetesClient(cluster.ID) })
+
+	// 初始化期间集群被移除，结果不应重新写入缓存
+	if stale := initDuring(t, cluster, func() { utils.RemoveKubernetesClient(cluster.ID) }); stale == nil {
+		t.Fatal("初始化应返回客户端")
+	}
+	if _, err := utils.GetKubernetesClient(cluster.ID); err == nil {
+		t.Fatal("移除后完成的初始化不应写入缓存")
+	}
+
+	// 初始化期间新凭据的客户端已写入缓存，旧凭据的结果不应覆盖它
+	release = make(chan struct{})
+	secondary := cluster
+	secondary.KubeContext = "secondary"
+	var current *kubernetes.Clientset
+	initDuring(t, cluster, func() {
+		var err error
+		if current, err = utils.GetOrInitKubernetesClient(secondary); err != nil {
+			t.Errorf("初始化新凭据的客户端失败: %v", err)
+		}
+	})
+	if cached, err := utils.GetKubernetesClient(cluster.ID); err != nil || cached != current {
+		t.Fatalf("缓存应保留新凭据的客户端: %v", err)
+	}
+}
+
+func TestDynamicClientCache(t *testing.T) {
+	server := newTestAPIServer(t)
+	cluster := models.Cluster{KubeConfig: testKubeConfig(server.URL, "primary")}
+	cluster.ID = 1002
+	t.Cleanup(func() { utils.RemoveKubernetesClient(cluster.ID) })
+
+	first, firstMapper, err := utils.GetOrInitDynamicClient(cluster)
+	if err != nil {
+		t.Fatalf("初始化动态客户端失败: %v", err)
+	}
+	second, secondMapper, err := utils.GetOrInitDynamicClient(cluster)
+	if err != nil {
+		t.Fatalf("获取动态客户端失败: %v", err)
+	}
+	if first != second || firstMapper != secondMapper {
+		t.Fatalf("凭据未变化时应复用缓存的动态客户端和 RESTMapper")
+	}
+
+	// 切换上下文后凭据指纹变化，应重新创建动态客户端
+	cluster.KubeContext = "secondary"
+	rebuilt, _, err := utils.GetOrInitDynamicClient(cluster)
+	if err != nil {
+		t.Fatalf("重新创建动态客户端失败: %v", err)
+	}
+	if rebuilt == first {
+		t.Fatalf("凭据变化后应重新创建动态客户端")
+	}
+
+	utils.RemoveKubernetesClient(cluster.ID)
+	afterRemove, _, err := utils.GetOrInitDynamicClient(cluster)
+	if err != nil {
+		t.Fatalf("移除后重新创建动态客户端失败: %v", err)
+	}
+	if afterRemove == rebuilt {
+		t.Fatalf("移除后应重新创建动态客户端")
+	}
+}
+
+// testClientCertificate 生成自签名的 PEM 格式客户端证书和私钥
+func testClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kaiops"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestNewRestConfigAuthTypes(t *testing.T) {
+	certPEM, keyPEM := testClientCertificate(t)
+
+	config, err := utils.NewRestConfig(models.Cluster{
+		AuthType:    models.AuthTypeToken,
+		ClusterApi:  "https://api.example.com",
+		BearerToken: "test-token",
+		CAData:      certPEM,
+	})
+	if err != nil {
+		t.Fatalf("构建 token 认证的 rest.Config 失败: %v", err)
+	}
+	if config.Host != "https://api.example.com" || config.BearerToken != "test-token" || string(config.CAData) != certPEM {
+		t.Fatalf("token 认证的 rest.Config 不正确: %+v", config)
+	}
+
+	config, err = utils.NewRestConfig(models.Cluster{
+		AuthType:       models.AuthTypeCertificate,
+		ClusterApi:     "https://api.example.com",
+		ClientCertData: certPEM,
+		ClientKeyData:  models.EncryptedString(keyPEM),
+	})
+	if err != nil {
+		t.Fatalf("构建证书认证的 rest.Config 失败: %v", err)
+	}
+	if string(config.CertData) != certPEM || string(config.KeyData) != keyPEM {
+		t.Fatalf("证书认证的 rest.Config 未设置客户端证书")
+	}
+
+	if _, err := utils.NewRestConfig(models.Cluster{AuthType: "oidc"}); !errors.Is(err, utils.ErrUnsupportedAuthType) {
+		t.Fatalf("期望错误 %v，实际 %v", utils.ErrUnsupportedAuthType, err)
+	}
+}
+
+func TestValidateAuthConfig(t *testing.T) {
+	certPEM, keyPEM := testClientCertificate(t)
+	_, otherKeyPEM := testClientCertificate(t)
+
+	tests := []struct {
+		name    string
+		cluster models.Cluster
+		want    error
+	}{
+		{"kubeconfig", models.Cluster{KubeConfig: "a3ViZWNvbmZpZw=="}, nil},
+		{"missing kubeconfig", models.Cluster{AuthType: models.AuthTypeKubeConfig}, utils.ErrInvalidAuthConfig},
+		{"token", models.Cluster{AuthType: models.AuthTypeToken, ClusterApi: "https://api.example.com", BearerToken: "t", CAData: certPEM}, nil},
+		{"token without server", models.Cluster{AuthType: models.AuthTypeToken, BearerToken: "t"}, utils.ErrInvalidAuthConfig},
+		{"token with bad server", models.Cluster{AuthType: models.AuthTypeToken, ClusterApi: "api.example.com", BearerToken: "t"}, utils.ErrInvalidAuthConfig},
+		{"token without token", models.Cluster{AuthType: models.AuthTypeToken, ClusterApi: "https://api.example.com"}, utils.ErrInvalidAuthConfig},
+		{"token with bad ca", models.Cluster{AuthType: models.AuthTypeToken, ClusterApi: "https://api.example.com", BearerToken: "t", CAData: "not pem"}, utils.ErrInvalidAuthConfig},
+		{"certificate", models.Cluster{AuthType: models.AuthTypeCertificate, ClusterApi: "https://api.example.com", ClientCertData: certPEM, ClientKeyData: models.EncryptedString(keyPEM)}, nil},
+		{"certificate without key", models.Cluster{AuthType: models.AuthTypeCertificate, ClusterApi: "https://api.example.com", ClientCertData: certPEM}, utils.ErrInvalidAuthConfig},
+		{"mismatched key", models.Cluster{AuthType: models.AuthTypeCertificate, ClusterApi: "https://api.example.com", ClientCertData: certPEM, ClientKeyData: models.EncryptedString(otherKeyPEM)}, utils.ErrInvalidAuthConfig},
+		{"in cluster", models.Cluster{AuthType: models.AuthTypeInCluster}, nil},
+		{"unsupported", models.Cluster{AuthType: "oidc"}, utils.ErrUnsupportedAuthType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := utils.ValidateAuthConfig(tt.cluster)
+			if tt.want == nil && err != nil {
+				t.Fatalf("期望校验通过，实际 %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("期望错误 %v，实际 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewRestConfigConnectionSettings(t *testing.T) {
+	certPEM, _ := testClientCertificate(t)
+	cluster := models.Cluster{
+		AuthType:           models.AuthTypeToken,
+		ClusterApi:         "https://api.example.com",
+		BearerToken:        "test-token",
+		CAData:             certPEM,
+		QPS:                50,
+		Burst:              100,
+		RequestTimeout:     30,
+		ProxyURL:           "socks5://proxy.example.com:1080",
+		TLSServerName:      "kubernetes.default.svc",
+		InsecureSkipVerify: true,
+	}
+
+	config, err := utils.NewRestConfig(cluster)
+	if err != nil {
+		t.Fatalf("构建 rest.Config 失败: %v", err)
+	}
+	if config.QPS != 50 || config.Burst != 100 || config.Timeout != 30*time.Second {
+		t.Fatalf("QPS、Burst 或超时未生效: %+v", config)
+	}
+	if config.ServerName != "kubernetes.default.svc" || !config.Insecure || len(config.CAData) != 0 {
+		t.Fatalf("TLS 设置未生效: %+v", config.TLSClientConfig)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/version", nil)
+	if proxyURL, err := config.Proxy(req); err != nil || proxyURL.String() != string(cluster.ProxyURL) {
+		t.Fatalf("代理未生效: %v %v", proxyURL, err)
+	}
+
+	for _, invalid := range []models.Cluster{
+		{QPS: -1},
+		{ProxyURL: "ftp://proxy.example.com"},
+		{ProxyURL: "proxy.example.com:1080"},
+	} {
+		if err := utils.ValidateConnectionSettings(invalid); !errors.Is(err, utils.ErrInvalidConnectionSettings) {
+			t.Fatalf("期望错误 %v，实际 %v", utils.ErrInvalidConnectionSettings, err)
+		}
+	}
+}
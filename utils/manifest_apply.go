@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+// ApplyFieldManager server-side apply 使用的字段管理器
+const ApplyFieldManager = "kaiops"
+
+// 资源应用的结果
+const (
+	ApplyCreated    = "created"
+	ApplyConfigured = "configured"
+	ApplyFailed     = "failed"
+)
+
+var (
+	// ErrInvalidManifest 清单无法解析
+	ErrInvalidManifest = errors.New("清单格式错误")
+	// ErrEmptyManifest 清单中没有任何资源
+	ErrEmptyManifest = errors.New("清单中没有任何资源")
+)
+
+// ApplyOptions 应用清单的选项
+type ApplyOptions struct {
+	// 未指定命名空间的命名空间级资源使用的命名空间
+	Namespace string
+	// 与其他字段管理器冲突时是否强制接管字段
+	Force bool
+}
+
+// ApplyResult 清单中单个资源的应用结果
+type ApplyResult struct {
+	// 资源的 API 版本
+	APIVersion string `json:"api_version" example:"apps/v1"`
+	// 资源类型
+	Kind string `json:"kind" example:"Deployment"`
+	// 命名空间，集群级资源为空
+	Namespace string `json:"namespace,omitempty" example:"default"`
+	// 资源名称
+	Name string `json:"name" example:"nginx"`
+	// 结果: created, configured, failed
+	Action string `json:"action" example:"created"`
+	// 失败原因
+	Error string `json:"error,omitempty"`
+	// 是否已写入工作负载记录
+	Recorded bool `json:"recorded,omitempty" example:"true"`
+
+	// 应用后 API Server 返回的对象，失败时为空
+	Object *unstructured.Unstructured `json:"-"`
+}
+
+// DecodeManifest 解析多文档 YAML 或 JSON 清单，跳过空文档并展开 List 中的资源
+func DecodeManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+
+	var objects []*unstructured.Unstructured
+	for doc := 1; ; doc++ {
+		var raw runtime.RawExtension
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%w: 第 %d 个文档: %v", ErrInvalidManifest, doc, err)
+		}
+		raw.Raw = bytes.TrimSpace(raw.Raw)
+		if len(raw.Raw) == 0 || bytes.Equal(raw.Raw, []byte("null")) {
+			continue
+		}
+
+		obj, _, err := unstructured.UnstructuredJSONScheme.Decode(raw.Raw, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 第 %d 个文档: %v", ErrInvalidManifest, doc, err)
+		}
+		var items []*unstructured.Unstructured
+		switch o := obj.(type) {
+		case *unstructured.UnstructuredList:
+			for i := range o.Items {
+				items = append(items, &o.Items[i])
+			}
+		case *unstructured.Unstructured:
+			items = append(items, o)
+		}
+		for _, item := range items {
+			if item.GetName() == "" {
+				return nil, fmt.Errorf("%w: 第 %d 个文档中的 %s 缺少 metadata.name", ErrInvalidManifest, doc, item.GetKind())
+			}
+			objects = append(objects, item)
+		}
+	}
+
+	if len(objects) == 0 {
+		return nil, ErrEmptyManifest
+	}
+	return objects, nil
+}
+
+// ApplyManifest 使用 server-side apply 依次应用清单中的资源，单个资源失败不影响后续资源
+// 资源类型通过 RESTMapper 解析，命名空间级资源未指定命名空间时使用 opts.Namespace
+func ApplyManifest(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, objects []*unstructured.Unstructured, opts ApplyOptions) []ApplyResult {
+	results := make([]ApplyResult, 0, len(objects))
+	for _, obj := range objects {
+		results = append(results, applyObject(ctx, client, mapper, obj, opts))
+	}
+	return results
+}
+
+// applyObject 应用单个资源
+func applyObject(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured, opts ApplyOptions) ApplyResult {
+	gvk := obj.GroupVersionKind()
+	result := ApplyResult{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+	fail := func(err error) ApplyResult {
+		result.Action = ApplyFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fail(fmt.Errorf("无法识别的资源类型 %s: %v", gvk.String(), err))
+	}
+
+	var resource dynamic.ResourceInterface
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if result.Namespace == "" {
+			result.Namespace = opts.Namespace
+		}
+		obj.SetNamespace(result.Namespace)
+		resource = client.Resource(mapping.Resource).Namespace(result.Namespace)
+	} else {
+		result.Namespace = ""
+		obj.SetNamespace("")
+		resource = client.Resource(mapping.Resource)
+	}
+	// 从集群导出的清单会带有 managedFields，apply 请求中不允许设置
+	obj.SetManagedFields(nil)
+
+	result.Action = ApplyConfigured
+	if _, err := resource.Get(ctx, result.Name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		result.Action = ApplyCreated
+	} else if err != nil {
+		return fail(err)
+	}
+
+	applied, err := resource.Apply(ctx, result.Name, obj, metav1.ApplyOptions{FieldManager: ApplyFieldManager, Force: opts.Force})
+	if err != nil {
+		return fail(err)
+	}
+	result.Object = applied
+	return result
+}
+
+// WorkloadFromObject 将支持的工作负载类型转换为工作负载记录，其他类型返回 false
+func WorkloadFromObject(obj *unstructured.Unstructured) (models.Workload, bool, error) {
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "Deployment"}:
+		var deployment appsv1.Deployment
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deployment); err != nil {
+			return models.Workload{}, false, err
+		}
+		return DeploymentToWorkload(&deployment), true, nil
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "StatefulSet"}:
+		var statefulSet appsv1.StatefulSet
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &statefulSet); err != nil {
+			return models.Workload{}, false, err
+		}
+		return StatefulSetToWorkload(&statefulSet), true, nil
+	case schema.GroupKind{Group: appsv1.GroupName, Kind: "DaemonSet"}:
+		var daemonSet appsv1.DaemonSet
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &daemonSet); err != nil {
+			return models.Workload{}, false, err
+		}
+		return DaemonSetToWorkload(&daemonSet), true, nil
+	case schema.GroupKind{Group: batchv1.GroupName, Kind: "Job"}:
+		var job batchv1.Job
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &job); err != nil {
+			return models.Workload{}, false, err
+		}
+		return JobToWorkload(&job), true, nil
+	case schema.GroupKind{Group: batchv1.GroupName, Kind: "CronJob"}:
+		var cronJob batchv1.CronJob
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &cronJob); err != nil {
+			return models.Workload{}, false, err
+		}
+		return CronJobToWorkload(&cronJob), true, nil
+	default:
+		return models.Workload{}, false, nil
+	}
+}
+
+// RecordAppliedWorkload 将应用后的工作负载写入数据库，不是支持的工作负载类型时返回 false
+func RecordAppliedWorkload(ctx context.Context, db *gorm.DB, clusterID uint, obj *unstructured.Unstructured) (bool, error) {
+	workload, ok, err := WorkloadFromObject(obj)
+	if err != nil || !ok {
+		return false, err
+	}
+	workload.ClusterID = clusterID
+	if err := SaveLiveWorkload(ctx, db, workload); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// ErrSystemNamespace 集群内置的命名空间不能删除
+	ErrSystemNamespace = errors.New("不能删除系统命名空间")
+	// ErrNamespaceNotEmpty 命名空间中还有工作负载，需要确认后才能删除
+	ErrNamespaceNotEmpty = errors.New("命名空间中存在工作负载，确认删除请设置 force=true")
+)
+
+// systemNamespaces 集群内置的命名空间
+var systemNamespaces = map[string]bool{
+	metav1.NamespaceDefault:   true,
+	metav1.NamespaceSystem:    true,
+	metav1.NamespacePublic:    true,
+	corev1.NamespaceNodeLease: true,
+}
+
+// NamespaceWorkload 命名空间中的一个工作负载
+type NamespaceWorkload struct {
+	// 工作负载类型
+	Kind string `json:"kind" example:"Deployment"`
+	// 工作负载名称
+	Name string `json:"name" example:"nginx"`
+}
+
+// IsSystemNamespace 判断是否为集群内置的命名空间
+func IsSystemNamespace(name string) bool {
+	return systemNamespaces[name]
+}
+
+// ListNamespaceWorkloads 从集群读取命名空间中的工作负载，按类型和名称排序
+// 由 CronJob 创建的 Job 随 CronJob 一起列出，不单独返回
+func ListNamespaceWorkloads(ctx context.Context, client kubernetes.Interface, namespace string) ([]NamespaceWorkload, error) {
+	workloads := make([]NamespaceWorkload, 0)
+	add := func(kind string, names ...string) {
+		for _, name := range names {
+			workloads = append(workloads, NamespaceWorkload{Kind: kind, Name: name})
+		}
+	}
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range deployments.Items {
+		add("Deployment", item.Name)
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range statefulSets.Items {
+		add("StatefulSet", item.Name)
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range daemonSets.Items {
+		add("DaemonSet", item.Name)
+	}
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		if !isCronJobOwned(&jobs.Items[i]) {
+			add("Job", jobs.Items[i].Name)
+		}
+	}
+	cronJobs, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range cronJobs.Items {
+		add("CronJob", item.Name)
+	}
+
+	sort.SliceStable(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		return a.Kind < b.Kind || (a.Kind == b.Kind && a.Name < b.Name)
+	})
+	return workloads, nil
+}
+
+// EnsureNamespace 命名空间不存在时创建，返回是否新建了命名空间。dryRun 为 true 时只试运行
+func EnsureNamespace(ctx context.Context, client kubernetes.Interface, name string, dryRun bool) (bool, error) {
+	_, err := client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	_, err = client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{DryRun: DryRunOptions(dryRun)})
+	if apierrors.IsAlreadyExists(err) {
+		// 并发请求已创建
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// reencryptBatchSize 重新加密时每批读取的行数
+const reencryptBatchSize = 100
+
+// EncryptedModels 包含 EncryptedString 字段的模型，新增加密字段的模型需要加入这里才会在轮换主密钥时重新加密
+var EncryptedModels = []interface{}{&models.Cluster{}, &models.ConfigRevision{}}
+
+// encryptedStringType EncryptedString 的反射类型
+var encryptedStringType = reflect.TypeOf(models.EncryptedString(""))
+
+// ReencryptAll 使用当前主密钥重新加密 EncryptedModels 中所有 EncryptedString 字段，包括已软删除的记录
+// 返回每张表重新加密的行数。读取时使用旧主密钥解密，因此执行前旧主密钥需要保留在 keyring 中
+func ReencryptAll(db *gorm.DB) (map[string]int, error) {
+	counts := make(map[string]int, len(EncryptedModels))
+	for _, model := range EncryptedModels {
+		table, count, err := reencryptModel(db, model)
+		if err != nil {
+			return counts, err
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// reencryptModel 重新加密一个模型的所有 EncryptedString 字段，返回表名和行数
+func reencryptModel(db *gorm.DB, model interface{}) (string, int, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", 0, err
+	}
+	table := stmt.Schema.Table
+
+	var fields []*schema.Field
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && field.FieldType == encryptedStringType {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return table, 0, nil
+	}
+
+	count := 0
+	rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	result := db.Unscoped().Model(model).FindInBatches(rows.Interface(), reencryptBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := 0; i < rows.Elem().Len(); i++ {
+			row := rows.Elem().Index(i)
+			// 写入时会使用当前主密钥和新的数据密钥重新加密
+			columns := make(map[string]interface{}, len(fields))
+			for _, field := range fields {
+				value, _ := field.ValueOf(db.Statement.Context, row)
+				columns[field.DBName] = value
+			}
+			if err := db.Unscoped().Model(row.Addr().Interface()).UpdateColumns(columns).Error; err != nil {
+				return fmt.Errorf("重新加密 %s 失败: %w", table, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return table, count, result.Error
+}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/kbsonlong/kaiops/models"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// 滚动更新的阶段
+const (
+	RolloutProgressing = "progressing"
+	RolloutComplete    = "complete"
+	RolloutFailed      = "failed"
+	RolloutTimeout     = "timeout"
+)
+
+// Deployment 超过 progressDeadlineSeconds 仍未完成时控制器写入的原因
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+// RolloutStatus 工作负载滚动更新的进度，判断逻辑与 kubectl rollout status 一致
+type RolloutStatus struct {
+	// 阶段: progressing, complete, failed, timeout
+	Phase string `json:"phase" example:"progressing"`
+	// 进度说明
+	Message string `json:"message" example:"Waiting for deployment \"nginx\" rollout to finish: 1 of 3 updated replicas are available..."`
+	// 期望副本数
+	DesiredReplicas int32 `json:"desired_replicas" example:"3"`
+	// 已更新到最新模板的副本数
+	UpdatedReplicas int32 `json:"updated_replicas" example:"3"`
+	// 就绪副本数
+	ReadyReplicas int32 `json:"ready_replicas" example:"2"`
+	// 可用副本数
+	AvailableReplicas int32 `json:"available_replicas" example:"1"`
+	// 工作负载的状态条件
+	Conditions []models.WorkloadCondition `json:"conditions"`
+}
+
+// Done 滚动更新是否已结束，结束后不会再变化
+func (s RolloutStatus) Done() bool {
+	return s.Phase != RolloutProgressing
+}
+
+// DeploymentRolloutStatus 计算 Deployment 的滚动更新进度
+// 控制器标记 ProgressDeadlineExceeded 时视为卡住，返回 failed
+func DeploymentRolloutStatus(deployment *appsv1.Deployment) RolloutStatus {
+	status := RolloutStatus{
+		Phase:             RolloutProgressing,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		Conditions:        DeploymentStatus(deployment).Conditions,
+	}
+	if deployment.Spec.Replicas != nil {
+		status.DesiredReplicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		status.Message = "Waiting for deployment spec update to be observed..."
+		return status
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == progressDeadlineExceededReason {
+			status.Phase = RolloutFailed
+			status.Message = fmt.Sprintf("deployment %q exceeded its progress deadline", deployment.Name)
+			return status
+		}
+	}
+
+	switch {
+	case deployment.Spec.Paused:
+		status.Message = fmt.Sprintf("Deployment %q is paused", deployment.Name)
+	case status.UpdatedReplicas < status.DesiredReplicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...",
+			deployment.Name, status.UpdatedReplicas, status.DesiredReplicas)
+	case deployment.Status.Replicas > status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...",
+			deployment.Name, deployment.Status.Replicas-status.UpdatedReplicas)
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...",
+			deployment.Name, status.AvailableReplicas, status.UpdatedReplicas)
+	default:
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("deployment %q successfully rolled out", deployment.Name)
+	}
+	return status
+}
+
+// StatefulSetRolloutStatus 计算 StatefulSet 的滚动更新进度，设置了 partition 时只等待分区内的副本
+func StatefulSetRolloutStatus(statefulSet *appsv1.StatefulSet) RolloutStatus {
+	status := RolloutStatus{
+		Phase:             RolloutProgressing,
+		UpdatedReplicas:   statefulSet.Status.UpdatedReplicas,
+		ReadyReplicas:     statefulSet.Status.ReadyReplicas,
+		AvailableReplicas: statefulSet.Status.AvailableReplicas,
+		Conditions:        StatefulSetStatus(statefulSet).Conditions,
+	}
+	if statefulSet.Spec.Replicas != nil {
+		status.DesiredReplicas = *statefulSet.Spec.Replicas
+	}
+
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+		return status
+	}
+	if statefulSet.Status.ObservedGeneration == 0 || statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		status.Message = "Waiting for statefulset spec update to be observed..."
+		return status
+	}
+	if status.ReadyReplicas < status.DesiredReplicas {
+		status.Message = fmt.Sprintf("Waiting for %d pods to be ready...", status.DesiredReplicas-status.ReadyReplicas)
+		return status
+	}
+
+	rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0 {
+		partition := *rollingUpdate.Partition
+		if status.UpdatedReplicas < status.DesiredReplicas-partition {
+			status.Message = fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...",
+				status.UpdatedReplicas, status.DesiredReplicas-partition)
+			return status
+		}
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("partitioned roll out complete: %d new pods have been updated...", status.UpdatedReplicas)
+		return status
+	}
+
+	if statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision {
+		status.Message = fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...",
+			status.UpdatedReplicas, statefulSet.Status.UpdateRevision)
+		return status
+	}
+	status.Phase = RolloutComplete
+	status.Message = fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...",
+		statefulSet.Status.CurrentReplicas, statefulSet.Status.CurrentRevision)
+	return status
+}
+
+// DaemonSetRolloutStatus 计算 DaemonSet 的滚动更新进度
+func DaemonSetRolloutStatus(daemonSet *appsv1.DaemonSet) RolloutStatus {
+	status := RolloutStatus{
+		Phase:             RolloutProgressing,
+		DesiredReplicas:   daemonSet.Status.DesiredNumberScheduled,
+		UpdatedReplicas:   daemonSet.Status.UpdatedNumberScheduled,
+		ReadyReplicas:     daemonSet.Status.NumberReady,
+		AvailableReplicas: daemonSet.Status.NumberAvailable,
+		Conditions:        DaemonSetStatus(daemonSet).Conditions,
+	}
+
+	if daemonSet.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("rollout status is only available for %s strategy type", appsv1.RollingUpdateDaemonSetStrategyType)
+		return status
+	}
+
+	switch {
+	case daemonSet.Generation > daemonSet.Status.ObservedGeneration:
+		status.Message = "Waiting for daemon set spec update to be observed..."
+	case status.UpdatedReplicas < status.DesiredReplicas:
+		status.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...",
+			daemonSet.Name, status.UpdatedReplicas, status.DesiredReplicas)
+	case status.AvailableReplicas < status.DesiredReplicas:
+		status.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...",
+			daemonSet.Name, status.AvailableReplicas, status.DesiredReplicas)
+	default:
+		status.Phase = RolloutComplete
+		status.Message = fmt.Sprintf("daemon set %q successfully rolled out", daemonSet.Name)
+	}
+	return status
+}
+
+// CachedRolloutStatus 从集群资源缓存中读取工作负载并计算滚动更新进度
+func CachedRolloutStatus(clusterCache *ClusterCache, kind, namespace, name string) (RolloutStatus, error) {
+	switch kind {
+	case "Deployment":
+		deployment, err := clusterCache.Deployments().Deployments(namespace).Get(name)
+		if err != nil {
+			return RolloutStatus{}, err
+		}
+		return DeploymentRolloutStatus(deployment), nil
+	case "StatefulSet":
+		statefulSet, err := clusterCache.StatefulSets().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return RolloutStatus{}, err
+		}
+		return StatefulSetRolloutStatus(statefulSet), nil
+	case "DaemonSet":
+		daemonSet, err := clusterCache.DaemonSets().DaemonSets(namespace).Get(name)
+		if err != nil {
+			return RolloutStatus{}, err
+		}
+		return DaemonSetRolloutStatus(daemonSet), nil
+	default:
+		return RolloutStatus{}, fmt.Errorf("%w: %s", ErrRolloutUnsupported, kind)
+	}
+}
+
+// deploymentCondition 转换 Deployment 的状态条件
+func deploymentCondition(condition appsv1.DeploymentCondition) models.WorkloadCondition {
+	return models.WorkloadCondition{
+		Type:               string(condition.Type),
+		Status:             string(condition.Status),
+		LastUpdateTime:     condition.LastUpdateTime,
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+}
+
+// statefulSetCondition 转换 StatefulSet 的状态条件
+func statefulSetCondition(condition appsv1.StatefulSetCondition) models.WorkloadCondition {
+	return models.WorkloadCondition{
+		Type:               string(condition.Type),
+		Status:             string(condition.Status),
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+}
+
+// daemonSetCondition 转换 DaemonSet 的状态条件
+func daemonSetCondition(condition appsv1.DaemonSetCondition) models.WorkloadCondition {
+	return models.WorkloadCondition{
+		Type:               string(condition.Type),
+		Status:             string(condition.Status),
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+}
@@ -0,0 +1,56 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/kbsonlong/kaiops/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatefulSetAndDaemonSetRolloutStatus(t *testing.T) {
+	replicas, partition := int32(3), int32(2)
+	statefulSet := func(mutate func(*appsv1.StatefulSet)) *appsv1.StatefulSet {
+		s := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "pg", Generation: 2},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:       &replicas,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+			},
+			Status: appsv1.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "pg-1", UpdateRevision: "pg-2"},
+		}
+		mutate(s)
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		status utils.RolloutStatus
+		want   string
+	}{
+		{"statefulset not observed", utils.StatefulSetRolloutStatus(statefulSet(func(s *appsv1.StatefulSet) { s.Generation = 3 })), utils.RolloutProgressing},
+		{"statefulset updating", utils.StatefulSetRolloutStatus(statefulSet(func(s *appsv1.StatefulSet) {})), utils.RolloutProgressing},
+		{"statefulset partitioned", utils.StatefulSetRolloutStatus(statefulSet(func(s *appsv1.StatefulSet) {
+			s.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+		})), utils.RolloutComplete},
+		{"statefulset complete", utils.StatefulSetRolloutStatus(statefulSet(func(s *appsv1.StatefulSet) { s.Status.CurrentRevision = "pg-2" })), utils.RolloutComplete},
+		{"statefulset on delete", utils.StatefulSetRolloutStatus(statefulSet(func(s *appsv1.StatefulSet) {
+			s.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+		})), utils.RolloutComplete},
+		{"daemonset updating", utils.DaemonSetRolloutStatus(&appsv1.DaemonSet{
+			Spec:   appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+			Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+		}), utils.RolloutProgressing},
+		{"daemonset complete", utils.DaemonSetRolloutStatus(&appsv1.DaemonSet{
+			Spec:   appsv1.DaemonSetSpec{UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType}},
+			Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3},
+		}), utils.RolloutComplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.status.Phase != tt.want {
+				t.Fatalf("期望阶段 %s，实际 %s: %s", tt.want, tt.status.Phase, tt.status.Message)
+			}
+		})
+	}
+}
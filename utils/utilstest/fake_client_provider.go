@@ -0,0 +1,420 @@
+// Package utilstest 提供基于 fake clientset 的 utils.ClientProvider 实现，只在测试中使用
+package utilstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// informerIdleTimeout 测试中资源缓存的空闲回收时间，测试结束时统一停止
+const informerIdleTimeout = 10 * time.Minute
+
+// fakeAPIResources fake 客户端的 discovery 返回的资源列表，用于构造 RESTMapper
+var fakeAPIResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false},
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "services", Kind: "Service", Namespaced: true},
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			{Name: "secrets", Kind: "Secret", Namespaced: true},
+			{Name: "serviceaccounts", Kind: "ServiceAccount", Namespaced: true},
+			{Name: "persistentvolumeclaims", Kind: "PersistentVolumeClaim", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true},
+			{Name: "statefulsets", Kind: "StatefulSet", Namespaced: true},
+			{Name: "daemonsets", Kind: "DaemonSet", Namespaced: true},
+			{Name: "replicasets", Kind: "ReplicaSet", Namespaced: true},
+			{Name: "controllerrevisions", Kind: "ControllerRevision", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "jobs", Kind: "Job", Namespaced: true},
+			{Name: "cronjobs", Kind: "CronJob", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: "networking.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+		},
+	},
+}
+
+// FakeClientProvider 基于 fake clientset 的 ClientProvider 实现，用于测试
+type FakeClientProvider struct {
+	// Err 不为空时 GetClient 直接返回该错误，用于模拟集群连接失败
+	Err error
+	// NewClientFunc 不为空时 NewClient 使用它创建客户端，用于预置校验场景
+	NewClientFunc func(cluster models.Cluster) *fake.Clientset
+	// Informers 集群资源缓存管理器
+	Informers *utils.InformerManager
+
+	mu      sync.Mutex
+	clients map[uint]*fake.Clientset
+}
+
+// NewFakeClientProvider 创建 FakeClientProvider
+func NewFakeClientProvider() *FakeClientProvider {
+	return &FakeClientProvider{
+		Informers: utils.NewInformerManager(informerIdleTimeout),
+		clients:   make(map[uint]*fake.Clientset),
+	}
+}
+
+// AddClient 为集群注册预置了指定对象的 fake 客户端
+func (p *FakeClientProvider) AddClient(clusterID uint, objects ...runtime.Object) *fake.Clientset {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clientset := newFakeClientset(objects...)
+	p.clients[clusterID] = clientset
+	return clientset
+}
+
+// Client 返回集群的 fake 客户端，不存在时返回 nil
+func (p *FakeClientProvider) Client(clusterID uint) *fake.Clientset {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.clients[clusterID]
+}
+
+// GetClient 获取集群的 fake 客户端，未注册时创建一个空的客户端
+func (p *FakeClientProvider) GetClient(cluster models.Cluster) (kubernetes.Interface, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clientset, exists := p.clients[cluster.ID]
+	if !exists {
+		clientset = newFakeClientset()
+		p.clients[cluster.ID] = clientset
+	}
+	return clientset, nil
+}
+
+// NewClient 创建一个新的空 fake 客户端，不写入缓存
+func (p *FakeClientProvider) NewClient(cluster models.Cluster) (kubernetes.Interface, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	if p.NewClientFunc != nil {
+		return p.NewClientFunc(cluster), nil
+	}
+	return fake.NewClientset(), nil
+}
+
+// GetDynamicClient 获取与集群 fake 客户端共享对象的动态客户端和 RESTMapper
+func (p *FakeClientProvider) GetDynamicClient(cluster models.Cluster) (dynamic.Interface, meta.RESTMapper, error) {
+	client, err := p.GetClient(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientset := client.(*fake.Clientset)
+	return newFakeDynamicClient(clientset), restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery())), nil
+}
+
+// GetCache 获取基于 fake 客户端的资源缓存
+func (p *FakeClientProvider) GetCache(cluster models.Cluster) (*utils.ClusterCache, error) {
+	client, err := p.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return p.Informers.GetCache(cluster, client), nil
+}
+
+// LookupCache 返回集群正在运行的资源缓存
+func (p *FakeClientProvider) LookupCache(clusterID uint) (*utils.ClusterCache, bool) {
+	return p.Informers.Lookup(clusterID)
+}
+
+// CacheStatus 返回集群资源缓存的同步状态
+func (p *FakeClientProvider) CacheStatus(clusterID uint) utils.CacheStatus {
+	return p.Informers.Status(clusterID)
+}
+
+// RemoveClient 移除集群的 fake 客户端并停止资源缓存
+func (p *FakeClientProvider) RemoveClient(clusterID uint) {
+	p.Informers.Remove(clusterID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.clients, clusterID)
+}
+
+// newFakeClientset 创建支持 scale 子资源和 discovery 的 fake 客户端
+// fake 客户端读取子资源时返回的是工作负载本身，这里将其转换为 Scale
+func newFakeClientset(objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewClientset(objects...)
+	clientset.Resources = fakeAPIResources
+
+	// 先注册的 reactor 后执行，scale 子资源由下面的 reactor 处理
+	clientset.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "" || !fakeDryRun(action) {
+			return false, nil, nil
+		}
+		return fakeDryRunReaction(clientset.Tracker(), action)
+	})
+
+	clientset.PrependReactor("get", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get, ok := action.(k8stesting.GetAction)
+		if !ok || get.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		obj, err := clientset.Tracker().Get(get.GetResource(), get.GetNamespace(), get.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		scale, err := fakeScale(obj)
+		return true, scale, err
+	})
+
+	clientset.PrependReactor("update", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		update, ok := action.(k8stesting.UpdateAction)
+		if !ok || update.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		scale := update.GetObject().(*autoscalingv1.Scale)
+		obj, err := clientset.Tracker().Get(update.GetResource(), update.GetNamespace(), scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		if scale.ResourceVersion != "" && scale.ResourceVersion != accessor.GetResourceVersion() {
+			return true, nil, apierrors.NewConflict(update.GetResource().GroupResource(), scale.Name, fmt.Errorf("resourceVersion 不匹配"))
+		}
+
+		replicas := scale.Spec.Replicas
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			o.Spec.Replicas = &replicas
+		case *appsv1.StatefulSet:
+			o.Spec.Replicas = &replicas
+		case *appsv1.ReplicaSet:
+			o.Spec.Replicas = &replicas
+		case *corev1.ReplicationController:
+			o.Spec.Replicas = &replicas
+		}
+		if !fakeDryRun(action) {
+			if err := clientset.Tracker().Update(update.GetResource(), obj, update.GetNamespace()); err != nil {
+				return true, nil, err
+			}
+		}
+		result, err := fakeScale(obj)
+		return true, result, err
+	})
+	return clientset
+}
+
+// fakeDryRun 判断写操作是否设置了 DryRun
+func fakeDryRun(action k8stesting.Action) bool {
+	var dryRun []string
+	switch a := action.(type) {
+	case k8stesting.CreateActionImpl:
+		dryRun = a.CreateOptions.DryRun
+	case k8stesting.UpdateActionImpl:
+		dryRun = a.UpdateOptions.DryRun
+	case k8stesting.PatchActionImpl:
+		dryRun = a.PatchOptions.DryRun
+	case k8stesting.DeleteActionImpl:
+		dryRun = a.DeleteOptions.DryRun
+	}
+	return len(dryRun) > 0
+}
+
+// fakeDryRunReaction 模拟 API Server 的试运行：校验对象是否存在并返回写入后的结果，不修改 tracker
+// fake 客户端没有准入控制，返回的对象不包含默认值和 webhook 的修改
+func fakeDryRunReaction(tracker k8stesting.ObjectTracker, action k8stesting.Action) (bool, runtime.Object, error) {
+	gvr := action.GetResource()
+	ns := action.GetNamespace()
+
+	switch a := action.(type) {
+	case k8stesting.CreateActionImpl:
+		obj := a.GetObject().DeepCopyObject()
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		if _, err := tracker.Get(gvr, ns, accessor.GetName()); err == nil {
+			return true, nil, apierrors.NewAlreadyExists(gvr.GroupResource(), accessor.GetName())
+		}
+		if ns != "" {
+			accessor.SetNamespace(ns)
+		}
+		return true, obj, nil
+	case k8stesting.UpdateActionImpl:
+		obj := a.GetObject().DeepCopyObject()
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		if _, err := tracker.Get(gvr, ns, accessor.GetName()); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	case k8stesting.PatchActionImpl:
+		current, err := tracker.Get(gvr, ns, a.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		if a.GetPatchType() != types.StrategicMergePatchType {
+			return true, nil, apierrors.NewBadRequest(fmt.Sprintf("fake 客户端试运行不支持 %s 补丁", a.GetPatchType()))
+		}
+		original, err := json.Marshal(current)
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := strategicpatch.StrategicMergePatch(original, a.GetPatch(), current)
+		if err != nil {
+			return true, nil, apierrors.NewBadRequest(err.Error())
+		}
+		gvks, _, err := scheme.Scheme.ObjectKinds(current)
+		if err != nil {
+			return true, nil, err
+		}
+		obj, err := scheme.Scheme.New(gvks[0])
+		if err != nil {
+			return true, nil, err
+		}
+		if err := json.Unmarshal(patched, obj); err != nil {
+			return true, nil, err
+		}
+		return true, obj, nil
+	case k8stesting.DeleteActionImpl:
+		_, err := tracker.Get(gvr, ns, a.GetName())
+		return true, nil, err
+	}
+	return false, nil, nil
+}
+
+// newFakeDynamicClient 创建读写 fake 客户端 tracker 的动态客户端，通过两种客户端写入的对象互相可见
+// fake 动态客户端不会传递 ApplyOptions，server-side apply 统一使用 kaiops 字段管理器并强制接管冲突字段
+func newFakeDynamicClient(clientset *fake.Clientset) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	client.ReactionChain = nil
+
+	tracker := clientset.Tracker()
+	client.AddReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if err := yaml.Unmarshal(patch.GetPatch(), &obj.Object); err != nil {
+			return true, nil, apierrors.NewBadRequest(err.Error())
+		}
+		force := true
+		opts := metav1.PatchOptions{FieldManager: "kaiops", Force: &force}
+		if err := tracker.Apply(patch.GetResource(), obj, patch.GetNamespace(), opts); err != nil {
+			return true, nil, err
+		}
+		applied, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		result, err := toUnstructured(applied)
+		return true, result, err
+	})
+
+	reaction := k8stesting.ObjectReaction(tracker)
+	client.AddReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		handled, obj, err := reaction(action)
+		if err != nil || obj == nil {
+			return handled, obj, err
+		}
+		result, err := toUnstructured(obj)
+		return handled, result, err
+	})
+	return client
+}
+
+// toUnstructured 将 tracker 中的结构化对象转换为动态客户端使用的 Unstructured
+func toUnstructured(obj runtime.Object) (runtime.Object, error) {
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := &unstructured.Unstructured{Object: content}
+	result.SetGroupVersionKind(gvks[0])
+	return result, nil
+}
+
+// fakeScale 使用工作负载的副本数和选择器构造 Scale
+func fakeScale(obj runtime.Object) (*autoscalingv1.Scale, error) {
+	var (
+		objectMeta metav1.ObjectMeta
+		replicas   *int32
+		current    int32
+		selector   *metav1.LabelSelector
+	)
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		objectMeta, replicas, current, selector = o.ObjectMeta, o.Spec.Replicas, o.Status.Replicas, o.Spec.Selector
+	case *appsv1.StatefulSet:
+		objectMeta, replicas, current, selector = o.ObjectMeta, o.Spec.Replicas, o.Status.Replicas, o.Spec.Selector
+	case *appsv1.ReplicaSet:
+		objectMeta, replicas, current, selector = o.ObjectMeta, o.Spec.Replicas, o.Status.Replicas, o.Spec.Selector
+	case *corev1.ReplicationController:
+		objectMeta, replicas, current = o.ObjectMeta, o.Spec.Replicas, o.Status.Replicas
+		selector = &metav1.LabelSelector{MatchLabels: o.Spec.Selector}
+	default:
+		return nil, fmt.Errorf("%T 没有 scale 子资源", obj)
+	}
+
+	scale := &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: objectMeta.Name, Namespace: objectMeta.Namespace, ResourceVersion: objectMeta.ResourceVersion},
+		Status:     autoscalingv1.ScaleStatus{Replicas: current},
+	}
+	if replicas != nil {
+		scale.Spec.Replicas = *replicas
+	}
+	if selector != nil {
+		if s, err := metav1.LabelSelectorAsSelector(selector); err == nil {
+			scale.Status.Selector = s.String()
+		}
+	}
+	return scale, nil
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CronJob 控制器和 kubectl create job --from 写入手动触发的 Job 的注解
+	cronJobInstantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+	// Job 控制器为 Pod 设置的 Job 名称标签
+	JobNameLabel = "job-name"
+)
+
+// Job 的运行状态
+const (
+	JobRunning   = "Running"
+	JobComplete  = "Complete"
+	JobFailed    = "Failed"
+	JobSuspended = "Suspended"
+)
+
+// ErrTriggerUnsupported 工作负载类型不支持手动触发
+var ErrTriggerUnsupported = errors.New("只有 CronJob 支持手动触发")
+
+// JobRecord Job 的一次执行记录
+type JobRecord struct {
+	// Job 名称
+	Name string `json:"name" example:"backup-28900000"`
+	// 运行状态: Running, Complete, Failed, Suspended
+	Status string `json:"status" example:"Complete"`
+	// 运行中的 Pod 数
+	Active int32 `json:"active" example:"0"`
+	// 成功完成的 Pod 数
+	Succeeded int32 `json:"succeeded" example:"1"`
+	// 失败的 Pod 数
+	Failed int32 `json:"failed" example:"0"`
+	// 是否为手动触发
+	Manual bool `json:"manual" example:"false"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+	// 开始运行的时间
+	StartTime *metav1.Time `json:"start_time,omitempty"`
+	// 完成时间，失败的 Job 为空
+	CompletionTime *metav1.Time `json:"completion_time,omitempty"`
+}
+
+// JobToWorkload 将 Job 转换为工作负载记录
+func JobToWorkload(job *batchv1.Job) models.Workload {
+	workload := objectToWorkload("Job", job.ObjectMeta, job.Spec.Template)
+	workload.Batch = batchSpecFromJob(job.Spec)
+	workload.Status = JobStatus(job)
+	return workload
+}
+
+// CronJobToWorkload 将 CronJob 转换为工作负载记录，容器和卷来自 Job 模板
+func CronJobToWorkload(cronJob *batchv1.CronJob) models.Workload {
+	workload := objectToWorkload("CronJob", cronJob.ObjectMeta, cronJob.Spec.JobTemplate.Spec.Template)
+	batch := batchSpecFromJob(cronJob.Spec.JobTemplate.Spec)
+	batch.Schedule = cronJob.Spec.Schedule
+	if cronJob.Spec.TimeZone != nil {
+		batch.TimeZone = *cronJob.Spec.TimeZone
+	}
+	batch.ConcurrencyPolicy = string(cronJob.Spec.ConcurrencyPolicy)
+	batch.SuccessfulJobsHistoryLimit = cronJob.Spec.SuccessfulJobsHistoryLimit
+	batch.FailedJobsHistoryLimit = cronJob.Spec.FailedJobsHistoryLimit
+	batch.Suspend = cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend
+	workload.Batch = batch
+	workload.Status = CronJobStatus(cronJob)
+	return workload
+}
+
+// batchSpecFromJob 读取 Job 的运行配置
+func batchSpecFromJob(spec batchv1.JobSpec) *models.BatchSpec {
+	return &models.BatchSpec{
+		Suspend:                 spec.Suspend != nil && *spec.Suspend,
+		BackoffLimit:            spec.BackoffLimit,
+		Completions:             spec.Completions,
+		Parallelism:             spec.Parallelism,
+		ActiveDeadlineSeconds:   spec.ActiveDeadlineSeconds,
+		TTLSecondsAfterFinished: spec.TTLSecondsAfterFinished,
+		RestartPolicy:           string(spec.Template.Spec.RestartPolicy),
+	}
+}
+
+// JobStatus 读取 Job 的 Pod 计数和状态条件，期望副本数为需要完成的 Pod 数
+func JobStatus(job *batchv1.Job) models.WorkloadStatus {
+	status := models.WorkloadStatus{
+		DesiredReplicas: 1,
+		CurrentReplicas: job.Status.Active,
+		Active:          job.Status.Active,
+		Succeeded:       job.Status.Succeeded,
+		Failed:          job.Status.Failed,
+	}
+	if job.Spec.Completions != nil {
+		status.DesiredReplicas = *job.Spec.Completions
+	}
+	if job.Status.Ready != nil {
+		status.ReadyReplicas = *job.Status.Ready
+	}
+	for _, condition := range job.Status.Conditions {
+		status.Conditions = append(status.Conditions, jobCondition(condition))
+	}
+	return status
+}
+
+// CronJobStatus 读取 CronJob 运行中的 Job 数和最近的调度时间
+func CronJobStatus(cronJob *batchv1.CronJob) models.WorkloadStatus {
+	return models.WorkloadStatus{
+		CurrentReplicas:    int32(len(cronJob.Status.Active)),
+		Active:             int32(len(cronJob.Status.Active)),
+		LastScheduleTime:   cronJob.Status.LastScheduleTime,
+		LastSuccessfulTime: cronJob.Status.LastSuccessfulTime,
+	}
+}
+
+// jobCondition 转换 Job 的状态条件
+func jobCondition(condition batchv1.JobCondition) models.WorkloadCondition {
+	return models.WorkloadCondition{
+		Type:               string(condition.Type),
+		Status:             string(condition.Status),
+		LastUpdateTime:     condition.LastProbeTime,
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+}
+
+// isCronJobOwned 判断 Job 是否由 CronJob 创建
+func isCronJobOwned(job *batchv1.Job) bool {
+	owner := metav1.GetControllerOf(job)
+	return owner != nil && owner.Kind == "CronJob"
+}
+
+// ownedByCronJob 判断 Job 是否由指定的 CronJob 创建，fake 客户端中的对象没有 UID 时只比较名称
+func ownedByCronJob(job *batchv1.Job, cronJob *batchv1.CronJob) bool {
+	owner := metav1.GetControllerOf(job)
+	if owner == nil || owner.Kind != "CronJob" || owner.Name != cronJob.Name {
+		return false
+	}
+	return owner.UID == "" || cronJob.UID == "" || owner.UID == cronJob.UID
+}
+
+// NewJobRecord 读取 Job 的执行记录
+func NewJobRecord(job *batchv1.Job) JobRecord {
+	record := JobRecord{
+		Name:           job.Name,
+		Status:         JobRunning,
+		Active:         job.Status.Active,
+		Succeeded:      job.Status.Succeeded,
+		Failed:         job.Status.Failed,
+		Manual:         job.Annotations[cronJobInstantiateAnnotation] == "manual",
+		CreatedAt:      job.CreationTimestamp.Time,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			record.Status = JobComplete
+		case batchv1.JobFailed:
+			record.Status = JobFailed
+		case batchv1.JobSuspended:
+			if record.Status == JobRunning {
+				record.Status = JobSuspended
+			}
+		}
+	}
+	return record
+}
+
+// ListJobHistory 读取 Job 的执行记录，CronJob 返回其创建的所有 Job，按创建时间从新到旧排序
+func ListJobHistory(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) ([]JobRecord, error) {
+	switch kind {
+	case "Job":
+		job, err := client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []JobRecord{NewJobRecord(job)}, nil
+	case "CronJob":
+		cronJob, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		records := make([]JobRecord, 0)
+		for i := range jobs.Items {
+			if ownedByCronJob(&jobs.Items[i], cronJob) {
+				records = append(records, NewJobRecord(&jobs.Items[i]))
+			}
+		}
+		sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.After(records[j].CreatedAt) })
+		return records, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKind, kind)
+	}
+}
+
+// TriggerCronJob 使用 CronJob 的 Job 模板立即创建一个 Job，与 kubectl create job --from=cronjob 一致
+// 创建的 Job 由 CronJob 管理，会计入执行历史。dryRun 为 true 时只试运行
+func TriggerCronJob(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, dryRun bool) (*batchv1.Job, error) {
+	if kind != "CronJob" {
+		return nil, fmt.Errorf("%w: %s", ErrTriggerUnsupported, kind)
+	}
+	cronJob, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	template := cronJob.Spec.JobTemplate
+	annotations := maps.Clone(template.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[cronJobInstantiateAnnotation] = "manual"
+
+	// Job 名称会写入 Pod 的标签，不能超过 63 个字符
+	prefix := name
+	if len(prefix) > 50 {
+		prefix = prefix[:50]
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            fmt.Sprintf("%s-manual-%s", prefix, utilrand.String(5)),
+			Namespace:       namespace,
+			Labels:          maps.Clone(template.Labels),
+			Annotations:     annotations,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Spec: template.Spec,
+	}
+	return client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{DryRun: DryRunOptions(dryRun)})
+}
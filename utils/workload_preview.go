@@ -0,0 +1,308 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// 字段变更的类型
+const (
+	ChangeAdd     = "add"
+	ChangeRemove  = "remove"
+	ChangeReplace = "replace"
+)
+
+// ErrUnsupportedKind 不支持的工作负载类型
+var ErrUnsupportedKind = errors.New("不支持的工作负载类型")
+
+// diffContextLines 统一格式差异中变更前后保留的上下文行数
+const diffContextLines = 3
+
+// FieldChange 预览中单个字段的变更
+type FieldChange struct {
+	// 字段路径
+	Path string `json:"path" example:"spec.template.spec.containers[0].image"`
+	// 变更类型: add, remove, replace
+	Op string `json:"op" example:"replace"`
+	// 变更前的值
+	Old interface{} `json:"old,omitempty"`
+	// 变更后的值
+	New interface{} `json:"new,omitempty"`
+}
+
+// WorkloadPreview 使用 DryRun 提交变更后 API Server 返回的结果
+type WorkloadPreview struct {
+	// 固定为 true，表示变更没有写入集群
+	DryRun bool `json:"dry_run" example:"true"`
+	// API Server 准入后的对象，包含 webhook 修改和默认值；删除时为空
+	Object runtime.Object `json:"object,omitempty" swaggertype:"object"`
+	// 与集群中当前对象相比的字段变更
+	Changes []FieldChange `json:"changes"`
+	// 当前对象与准入后对象 YAML 的统一格式差异
+	Diff string `json:"diff"`
+	// 与工作负载一起创建的 Service，API Server 准入后的对象
+	Service *corev1.Service `json:"service,omitempty"`
+}
+
+// DryRunOptions 将是否试运行转换为请求选项中的 DryRun 字段
+func DryRunOptions(dryRun bool) []string {
+	if dryRun {
+		return []string{metav1.DryRunAll}
+	}
+	return nil
+}
+
+// GetWorkloadObject 从集群读取工作负载对象
+func GetWorkloadObject(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (runtime.Object, error) {
+	switch kind {
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Job":
+		return client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "CronJob":
+		return client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKind, kind)
+	}
+}
+
+// NewWorkloadPreview 对比集群中的当前对象和试运行返回的对象，创建时 live 为空，删除时 admitted 为空
+func NewWorkloadPreview(live, admitted runtime.Object) (WorkloadPreview, error) {
+	before, err := previewContent(live)
+	if err != nil {
+		return WorkloadPreview{}, err
+	}
+	after, err := previewContent(admitted)
+	if err != nil {
+		return WorkloadPreview{}, err
+	}
+	diff, err := unifiedDiff(before, after)
+	if err != nil {
+		return WorkloadPreview{}, err
+	}
+
+	preview := WorkloadPreview{DryRun: true, Object: admitted, Changes: []FieldChange{}, Diff: diff}
+	diffValues("", before, after, &preview.Changes)
+	return preview, nil
+}
+
+// previewContent 将对象转换为用于对比的内容，去掉每次写入都会变化的元数据
+func previewContent(obj runtime.Object) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return nil, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	// 结构化客户端返回的对象不带 apiVersion 和 kind，对比时统一去掉
+	delete(content, "apiVersion")
+	delete(content, "kind")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "generation"} {
+			delete(metadata, field)
+		}
+	}
+	return content, nil
+}
+
+// diffValues 递归对比两个值，将不同的字段追加到 changes
+// 对象按字段名排序对比，列表按下标对比
+func diffValues(path string, before, after interface{}, changes *[]FieldChange) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	switch {
+	case before == nil:
+		*changes = append(*changes, FieldChange{Path: path, Op: ChangeAdd, New: after})
+		return
+	case after == nil:
+		*changes = append(*changes, FieldChange{Path: path, Op: ChangeRemove, Old: before})
+		return
+	}
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(b)+len(a))
+		for key := range b {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, ok := b[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			diffValues(joinFieldPath(path, key), b[key], a[key], changes)
+		}
+		return
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(b) || i < len(a); i++ {
+			var oldItem, newItem interface{}
+			if i < len(b) {
+				oldItem = b[i]
+			}
+			if i < len(a) {
+				newItem = a[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), oldItem, newItem, changes)
+		}
+		return
+	}
+	*changes = append(*changes, FieldChange{Path: path, Op: ChangeReplace, Old: before, New: after})
+}
+
+// joinFieldPath 拼接字段路径，字段名包含点号时使用方括号，例如注解的键
+func joinFieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// unifiedDiff 生成两个对象 YAML 的统一格式差异，内容相同时返回空字符串
+func unifiedDiff(before, after map[string]interface{}) (string, error) {
+	oldLines, err := yamlLines(before)
+	if err != nil {
+		return "", err
+	}
+	newLines, err := yamlLines(after)
+	if err != nil {
+		return "", err
+	}
+	edits := diffLines(oldLines, newLines)
+
+	var changed []int
+	for i, e := range edits {
+		if e.op != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("--- live\n+++ dry-run\n")
+	for start := 0; start < len(changed); {
+		// 合并上下文重叠的变更
+		end := start
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*diffContextLines {
+			end++
+		}
+		from := max(changed[start]-diffContextLines, 0)
+		to := min(changed[end]+diffContextLines+1, len(edits))
+		writeHunk(&b, edits, from, to)
+		start = end + 1
+	}
+	return b.String(), nil
+}
+
+// yamlLines 将对象序列化为按字段名排序的 YAML 行
+func yamlLines(content map[string]interface{}) ([]string, error) {
+	if content == nil {
+		return nil, nil
+	}
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+// lineEdit 差异中的一行，op 为 ' '、'-' 或 '+'
+type lineEdit struct {
+	op      byte
+	text    string
+	oldLine int
+	newLine int
+}
+
+// diffLines 基于最长公共子序列计算逐行差异
+func diffLines(oldLines, newLines []string) []lineEdit {
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]lineEdit, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			edits = append(edits, lineEdit{op: ' ', text: oldLines[i], oldLine: i, newLine: j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, lineEdit{op: '-', text: oldLines[i], oldLine: i, newLine: j})
+			i++
+		default:
+			edits = append(edits, lineEdit{op: '+', text: newLines[j], oldLine: i, newLine: j})
+			j++
+		}
+	}
+	return edits
+}
+
+// writeHunk 输出 edits[from:to] 对应的差异块
+func writeHunk(b *strings.Builder, edits []lineEdit, from, to int) {
+	var oldCount, newCount int
+	for _, e := range edits[from:to] {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	oldStart, newStart := edits[from].oldLine+1, edits[from].newLine+1
+	// 统一格式中行数为 0 的一侧起始行号指向变更之前的一行
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, e := range edits[from:to] {
+		b.WriteByte(e.op)
+		b.WriteString(e.text)
+		b.WriteByte('\n')
+	}
+}
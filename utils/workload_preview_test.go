@@ -0,0 +1,67 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/kbsonlong/kaiops/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewWorkloadPreview(t *testing.T) {
+	live := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "1", Annotations: map[string]string{"note": "x"}},
+		Data:       map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "5", "f": "6", "g": "7", "h": "8", "i": "9"},
+	}
+	admitted := live.DeepCopy()
+	admitted.ResourceVersion = "2"
+	admitted.Annotations["example.com/owner"] = "team"
+	admitted.Data["h"] = "80"
+	delete(admitted.Data, "i")
+
+	preview, err := utils.NewWorkloadPreview(live, admitted)
+	if err != nil {
+		t.Fatalf("生成预览失败: %v", err)
+	}
+
+	want := []utils.FieldChange{
+		{Path: "data.h", Op: utils.ChangeReplace, Old: "8", New: "80"},
+		{Path: "data.i", Op: utils.ChangeRemove, Old: "9"},
+		{Path: `metadata.annotations["example.com/owner"]`, Op: utils.ChangeAdd, New: "team"},
+	}
+	if len(preview.Changes) != len(want) {
+		t.Fatalf("字段变更不符合预期: %+v", preview.Changes)
+	}
+	for i := range want {
+		if preview.Changes[i] != want[i] {
+			t.Fatalf("第 %d 个字段变更期望 %+v，实际 %+v", i, want[i], preview.Changes[i])
+		}
+	}
+
+	wantDiff := `--- live
++++ dry-run
+@@ -6,10 +6,10 @@
+   e: "5"
+   f: "6"
+   g: "7"
+-  h: "8"
+-  i: "9"
++  h: "80"
+ metadata:
+   annotations:
++    example.com/owner: team
+     note: x
+   creationTimestamp: null
+   name: web
+`
+	if preview.Diff != wantDiff {
+		t.Fatalf("统一格式差异不符合预期:\n%s", preview.Diff)
+	}
+
+	// 内容相同时没有差异，resourceVersion 的变化不计入
+	same := live.DeepCopy()
+	same.ResourceVersion = "3"
+	if preview, _ := utils.NewWorkloadPreview(live, same); len(preview.Changes) != 0 || preview.Diff != "" {
+		t.Fatalf("相同对象不应有差异: %+v %q", preview.Changes, preview.Diff)
+	}
+}
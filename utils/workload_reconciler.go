@@ -0,0 +1,425 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// 默认的工作负载对比间隔
+	defaultReconcileInterval = 5 * time.Minute
+	// 等待集群资源缓存同步的默认超时时间
+	defaultReconcileSyncTimeout = 30 * time.Second
+)
+
+// 工作负载差异字段
+const (
+	DriftImage     = "image"
+	DriftReplicas  = "replicas"
+	DriftLabels    = "labels"
+	DriftEnv       = "env"
+	DriftContainer = "container"
+)
+
+// ReconcileResult 单个集群的工作负载对比结果
+type ReconcileResult struct {
+	// 对比的工作负载数量
+	Checked int `json:"checked" example:"12"`
+	// 存在差异的工作负载数量
+	Drifted int `json:"drifted" example:"2"`
+	// 集群中已不存在的工作负载数量
+	Missing int `json:"missing" example:"1"`
+}
+
+// liveWorkload 集群中实际对象的关键字段
+type liveWorkload struct {
+	replicas *int32
+	labels   map[string]string
+	template corev1.PodTemplateSpec
+}
+
+// ReconcileIntervalFromEnv 从环境变量 WORKLOAD_RECONCILE_INTERVAL 读取工作负载对比间隔
+func ReconcileIntervalFromEnv() time.Duration {
+	value := os.Getenv("WORKLOAD_RECONCILE_INTERVAL")
+	if value == "" {
+		return defaultReconcileInterval
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WORKLOAD_RECONCILE_INTERVAL 格式错误，使用默认值 %s: %v", defaultReconcileInterval, err)
+		return defaultReconcileInterval
+	}
+	return interval
+}
+
+// WorkloadReconciler 定期将数据库中的工作负载与集群中的实际对象对比，并记录差异
+type WorkloadReconciler struct {
+	DB      *gorm.DB
+	Clients ClientProvider
+	// 对比间隔，小于等于 0 时不启动定时对比
+	Interval time.Duration
+	// 等待集群资源缓存同步的超时时间
+	SyncTimeout time.Duration
+}
+
+// NewWorkloadReconciler 创建工作负载对比器
+func NewWorkloadReconciler(db *gorm.DB, clients ClientProvider, interval time.Duration) *WorkloadReconciler {
+	return &WorkloadReconciler{
+		DB:          db,
+		Clients:     clients,
+		Interval:    interval,
+		SyncTimeout: defaultReconcileSyncTimeout,
+	}
+}
+
+// Run 按间隔定期对比所有集群的工作负载，直到 ctx 结束
+func (r *WorkloadReconciler) Run(ctx context.Context) {
+	if r.Interval <= 0 {
+		log.Println("工作负载对比已禁用")
+		return
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.ReconcileAll(ctx); err != nil {
+			log.Printf("工作负载对比失败: %v", err)
+		}
+	}
+}
+
+// ReconcileAll 对比所有集群的工作负载
+// 资源缓存正在运行且已同步的集群从缓存读取；其他集群直接从 API Server 列出工作负载，
+// 定时对比不会启动缓存或刷新其访问时间，空闲集群的缓存仍会按时回收
+func (r *WorkloadReconciler) ReconcileAll(ctx context.Context) error {
+	var clusters []models.Cluster
+	if err := r.DB.WithContext(ctx).Find(&clusters).Error; err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters {
+		var lookup liveWorkloadLookup
+		if clusterCache, running := r.Clients.LookupCache(cluster.ID); running && clusterCache.HasSynced(WorkloadResources()...) {
+			lookup = cachedWorkloadLookup(clusterCache)
+		} else {
+			client, err := r.Clients.GetClient(cluster)
+			if err != nil {
+				log.Printf("获取集群 %d 的客户端失败: %v", cluster.ID, err)
+				continue
+			}
+			if lookup, err = listedWorkloadLookup(ctx, client); err != nil {
+				log.Printf("列出集群 %d 的工作负载失败: %v", cluster.ID, err)
+				continue
+			}
+		}
+		if _, err := r.reconcile(ctx, cluster, lookup); err != nil {
+			log.Printf("对比集群 %d 的工作负载失败: %v", cluster.ID, err)
+		}
+	}
+	return nil
+}
+
+// ReconcileCluster 对比单个集群的工作负载，并将差异回写到数据库，缓存未启动时先启动缓存
+func (r *WorkloadReconciler) ReconcileCluster(ctx context.Context, cluster models.Cluster) (ReconcileResult, error) {
+	timeout := r.SyncTimeout
+	if timeout <= 0 {
+		timeout = defaultReconcileSyncTimeout
+	}
+	clusterCache, err := SyncedCache(ctx, r.Clients, cluster, timeout, WorkloadResources()...)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+	return r.reconcile(ctx, cluster, cachedWorkloadLookup(clusterCache))
+}
+
+// liveWorkloadLookup 读取集群中的工作负载，对象不存在时返回 NotFound 错误
+type liveWorkloadLookup func(kind, namespace, name string) (*liveWorkload, error)
+
+// cachedWorkloadLookup 从集群资源缓存中读取工作负载
+func cachedWorkloadLookup(clusterCache *ClusterCache) liveWorkloadLookup {
+	return func(kind, namespace, name string) (*liveWorkload, error) {
+		return getLiveWorkload(clusterCache, kind, namespace, name)
+	}
+}
+
+// listedWorkloadLookup 直接从 API Server 列出所有命名空间的工作负载，用于没有运行资源缓存的集群
+func listedWorkloadLookup(ctx context.Context, client kubernetes.Interface) (liveWorkloadLookup, error) {
+	index := make(map[string]*liveWorkload)
+	add := func(kind string, meta metav1.ObjectMeta, live *liveWorkload) {
+		index[kind+"/"+meta.Namespace+"/"+meta.Name] = live
+	}
+
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		add("Deployment", deployments.Items[i].ObjectMeta, deploymentLiveWorkload(&deployments.Items[i]))
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		add("StatefulSet", statefulSets.Items[i].ObjectMeta, statefulSetLiveWorkload(&statefulSets.Items[i]))
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		add("DaemonSet", daemonSets.Items[i].ObjectMeta, daemonSetLiveWorkload(&daemonSets.Items[i]))
+	}
+	jobs, err := client.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs.Items {
+		add("Job", jobs.Items[i].ObjectMeta, jobLiveWorkload(&jobs.Items[i]))
+	}
+	cronJobs, err := client.BatchV1().CronJobs(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range cronJobs.Items {
+		add("CronJob", cronJobs.Items[i].ObjectMeta, cronJobLiveWorkload(&cronJobs.Items[i]))
+	}
+
+	return func(kind, namespace, name string) (*liveWorkload, error) {
+		resource := WorkloadResource(kind)
+		if resource == "" {
+			return nil, fmt.Errorf("不支持的工作负载类型: %s", kind)
+		}
+		live, ok := index[kind+"/"+namespace+"/"+name]
+		if !ok {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: resource}, name)
+		}
+		return live, nil
+	}, nil
+}
+
+// reconcile 对比工作负载记录与 lookup 读取到的集群中的对象
+func (r *WorkloadReconciler) reconcile(ctx context.Context, cluster models.Cluster, lookup liveWorkloadLookup) (ReconcileResult, error) {
+	var result ReconcileResult
+
+	var workloads []models.Workload
+	if err := r.DB.WithContext(ctx).Where("cluster_id = ?", cluster.ID).Find(&workloads).Error; err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	for _, workload := range workloads {
+		live, err := lookup(workload.Kind, workload.Namespace, workload.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Printf("读取工作负载 %s/%s/%s 失败: %v", workload.Kind, workload.Namespace, workload.Name, err)
+			continue
+		}
+		result.Checked++
+
+		updates := map[string]interface{}{"reconciled_at": now}
+		if live == nil {
+			updates["missing"] = true
+			updates["drift"] = nil
+			result.Missing++
+		} else {
+			drift := detectWorkloadDrift(workload, *live)
+			updates["missing"] = false
+			updates["drift"] = drift
+			if len(drift) > 0 {
+				result.Drifted++
+			}
+		}
+
+		// 只更新对比相关的字段，避免覆盖同时进行的修改
+		if err := r.DB.WithContext(ctx).Model(&models.Workload{}).Where("id = ?", workload.ID).UpdateColumns(updates).Error; err != nil {
+			return result, fmt.Errorf("保存工作负载 %d 的对比结果失败: %v", workload.ID, err)
+		}
+	}
+	return result, nil
+}
+
+// getLiveWorkload 从集群资源缓存中读取工作负载，对象不存在时返回 NotFound 错误
+func getLiveWorkload(clusterCache *ClusterCache, kind, namespace, name string) (*liveWorkload, error) {
+	switch kind {
+	case "Deployment":
+		deployment, err := clusterCache.Deployments().Deployments(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return deploymentLiveWorkload(deployment), nil
+	case "StatefulSet":
+		statefulSet, err := clusterCache.StatefulSets().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return statefulSetLiveWorkload(statefulSet), nil
+	case "DaemonSet":
+		daemonSet, err := clusterCache.DaemonSets().DaemonSets(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return daemonSetLiveWorkload(daemonSet), nil
+	case "Job":
+		job, err := clusterCache.Jobs().Jobs(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return jobLiveWorkload(job), nil
+	case "CronJob":
+		cronJob, err := clusterCache.CronJobs().CronJobs(namespace).Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return cronJobLiveWorkload(cronJob), nil
+	default:
+		return nil, fmt.Errorf("不支持的工作负载类型: %s", kind)
+	}
+}
+
+// deploymentLiveWorkload 读取 Deployment 中参与对比的字段
+func deploymentLiveWorkload(deployment *appsv1.Deployment) *liveWorkload {
+	return &liveWorkload{replicas: deployment.Spec.Replicas, labels: deployment.Labels, template: deployment.Spec.Template}
+}
+
+// statefulSetLiveWorkload 读取 StatefulSet 中参与对比的字段
+func statefulSetLiveWorkload(statefulSet *appsv1.StatefulSet) *liveWorkload {
+	return &liveWorkload{replicas: statefulSet.Spec.Replicas, labels: statefulSet.Labels, template: statefulSet.Spec.Template}
+}
+
+// daemonSetLiveWorkload 读取 DaemonSet 中参与对比的字段
+func daemonSetLiveWorkload(daemonSet *appsv1.DaemonSet) *liveWorkload {
+	return &liveWorkload{labels: daemonSet.Labels, template: daemonSet.Spec.Template}
+}
+
+// jobLiveWorkload 读取 Job 中参与对比的字段
+func jobLiveWorkload(job *batchv1.Job) *liveWorkload {
+	return &liveWorkload{labels: job.Labels, template: job.Spec.Template}
+}
+
+// cronJobLiveWorkload 读取 CronJob 中参与对比的字段
+func cronJobLiveWorkload(cronJob *batchv1.CronJob) *liveWorkload {
+	return &liveWorkload{labels: cronJob.Labels, template: cronJob.Spec.JobTemplate.Spec.Template}
+}
+
+// detectWorkloadDrift 对比工作负载记录与集群中的对象，返回镜像、副本数、标签和环境变量的差异
+func detectWorkloadDrift(workload models.Workload, live liveWorkload) models.DriftItems {
+	drift := models.DriftItems{}
+
+	// DaemonSet、Job 和 CronJob 没有副本数
+	if live.replicas != nil && *live.replicas != workload.Replicas {
+		drift = append(drift, models.DriftItem{
+			Field:    DriftReplicas,
+			Expected: fmt.Sprint(workload.Replicas),
+			Actual:   fmt.Sprint(*live.replicas),
+		})
+	}
+
+	if expected, actual := formatStringMap(workload.Labels), formatStringMap(live.labels); expected != actual {
+		drift = append(drift, models.DriftItem{Field: DriftLabels, Expected: expected, Actual: actual})
+	}
+
+	liveContainers := make(map[string]corev1.Container, len(live.template.Spec.Containers))
+	for _, container := range live.template.Spec.Containers {
+		liveContainers[container.Name] = container
+	}
+	for _, container := range workload.Containers {
+		liveContainer, exists := liveContainers[container.Name]
+		if !exists {
+			drift = append(drift, models.DriftItem{Field: DriftContainer, Container: container.Name, Expected: container.Image})
+			continue
+		}
+		delete(liveContainers, container.Name)
+
+		if container.Image != liveContainer.Image {
+			drift = append(drift, models.DriftItem{
+				Field:     DriftImage,
+				Container: container.Name,
+				Expected:  container.Image,
+				Actual:    liveContainer.Image,
+			})
+		}
+		if expected, actual := formatModelEnv(container.Env), formatLiveEnv(liveContainer.Env); expected != actual {
+			drift = append(drift, models.DriftItem{Field: DriftEnv, Container: container.Name, Expected: expected, Actual: actual})
+		}
+	}
+	// 集群中多出的容器
+	for name, container := range liveContainers {
+		drift = append(drift, models.DriftItem{Field: DriftContainer, Container: name, Actual: container.Image})
+	}
+
+	sort.SliceStable(drift, func(i, j int) bool {
+		if drift[i].Field != drift[j].Field {
+			return drift[i].Field < drift[j].Field
+		}
+		return drift[i].Container < drift[j].Container
+	})
+	return drift
+}
+
+// formatStringMap 将 map 格式化为按键排序的 k=v 列表
+func formatStringMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// formatModelEnv 将记录中的环境变量格式化为按名称排序的 k=v 列表，引用的值格式化为来源
+func formatModelEnv(envVars []models.EnvVar) string {
+	m := make(map[string]string, len(envVars))
+	for _, env := range envVars {
+		m[env.Name] = formatEnvValue(env)
+	}
+	return formatStringMap(m)
+}
+
+// formatLiveEnv 将容器的环境变量格式化为按名称排序的 k=v 列表，引用的值格式化为来源
+func formatLiveEnv(envVars []corev1.EnvVar) string {
+	m := make(map[string]string, len(envVars))
+	for _, env := range envVars {
+		m[env.Name] = formatEnvValue(envVarFromK8s(env))
+	}
+	return formatStringMap(m)
+}
+
+// formatEnvValue 返回环境变量的值，引用的值返回来源，如 secretKeyRef(db/password)
+func formatEnvValue(env models.EnvVar) string {
+	source := env.ValueFrom
+	switch {
+	case source == nil:
+		return env.Value
+	case source.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("configMapKeyRef(%s/%s)", source.ConfigMapKeyRef.Name, source.ConfigMapKeyRef.Key)
+	case source.SecretKeyRef != nil:
+		return fmt.Sprintf("secretKeyRef(%s/%s)", source.SecretKeyRef.Name, source.SecretKeyRef.Key)
+	case source.FieldRef != nil:
+		return fmt.Sprintf("fieldRef(%s)", source.FieldRef.FieldPath)
+	case source.ResourceFieldRef != nil:
+		return fmt.Sprintf("resourceFieldRef(%s/%s)", source.ResourceFieldRef.ContainerName, source.ResourceFieldRef.Resource)
+	}
+	return env.Value
+}
@@ -0,0 +1,72 @@
+package utils_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kbsonlong/kaiops/models"
+	"github.com/kbsonlong/kaiops/utils"
+	"github.com/kbsonlong/kaiops/utils/utilstest"
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileAllWithoutCache(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("连接测试数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Cluster{}, &models.Workload{}); err != nil {
+		t.Fatalf("迁移测试数据库失败: %v", err)
+	}
+
+	cluster := models.Cluster{Name: "idle"}
+	db.Create(&cluster)
+	replicas := int32(2)
+	labels := map[string]string{"app": "web"}
+	clients := utilstest.NewFakeClientProvider()
+	clients.AddClient(cluster.ID, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.25"}}},
+			},
+		},
+	})
+	for _, workload := range []models.Workload{
+		{Name: "web", Replicas: 2, Containers: models.Containers{{Name: "app", Image: "nginx:1.14"}}},
+		{Name: "gone", Replicas: 1, Containers: models.Containers{{Name: "app", Image: "gone:v1"}}},
+	} {
+		workload.Kind = "Deployment"
+		workload.Namespace = "default"
+		workload.ClusterID = cluster.ID
+		workload.Labels = models.StringMap{"app": workload.Name}
+		db.Create(&workload)
+	}
+
+	// 集群没有运行中的资源缓存（例如已被空闲回收），定时对比应直接列出工作负载
+	reconciler := utils.NewWorkloadReconciler(db, clients, 0)
+	if err := reconciler.ReconcileAll(context.Background()); err != nil {
+		t.Fatalf("对比工作负载失败: %v", err)
+	}
+
+	var web, gone models.Workload
+	db.Where("name = ?", "web").First(&web)
+	db.Where("name = ?", "gone").First(&gone)
+	if web.ReconciledAt == nil || web.Missing || len(web.Drift) != 1 || web.Drift[0].Field != utils.DriftImage {
+		t.Fatalf("web 的对比结果不符合预期: %+v", web)
+	}
+	if gone.ReconciledAt == nil || !gone.Missing {
+		t.Fatalf("gone 应被标记为不存在: %+v", gone)
+	}
+	if _, running := clients.LookupCache(cluster.ID); running {
+		t.Fatalf("定时对比不应启动资源缓存")
+	}
+}
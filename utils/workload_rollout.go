@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// Deployment 控制器记录在 ReplicaSet 上的版本号
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	// ChangeCauseAnnotation 记录变更原因的注解
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+	// RestartedAtAnnotation 滚动重启时写入 Pod 模板的注解，与 kubectl rollout restart 一致
+	RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+var (
+	// ErrRolloutUnsupported 工作负载类型不支持版本历史
+	ErrRolloutUnsupported = errors.New("只有 Deployment、StatefulSet 和 DaemonSet 支持版本历史")
+	// ErrRevisionNotFound 指定的历史版本不存在
+	ErrRevisionNotFound = errors.New("历史版本不存在")
+	// ErrPauseUnsupported 工作负载类型不支持暂停
+	ErrPauseUnsupported = errors.New("只有 Deployment、Job 和 CronJob 支持暂停和恢复")
+)
+
+// WorkloadRevision 工作负载的一个历史版本
+type WorkloadRevision struct {
+	// 版本号
+	Revision int64 `json:"revision" example:"3"`
+	// 记录该版本的 ReplicaSet 或 ControllerRevision 名称
+	Name string `json:"name" example:"nginx-7c5ddbdf54"`
+	// 各容器使用的镜像
+	Images map[string]string `json:"images"`
+	// 变更原因
+	ChangeCause string `json:"change_cause,omitempty" example:"kubectl set image deployment/nginx nginx=nginx:1.25"`
+	// 是否为当前版本
+	Current bool `json:"current" example:"true"`
+	// 创建时间
+	CreatedAt time.Time `json:"created_at"`
+
+	template corev1.PodTemplateSpec
+}
+
+// Template 返回该版本的 Pod 模板
+func (r WorkloadRevision) Template() corev1.PodTemplateSpec {
+	return r.template
+}
+
+// ListRevisions 读取工作负载的历史版本，按版本号从新到旧排序
+// Deployment 的历史来自其管理的 ReplicaSet，StatefulSet 和 DaemonSet 的历史来自 ControllerRevision
+func ListRevisions(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) ([]WorkloadRevision, error) {
+	var revisions []WorkloadRevision
+	switch kind {
+	case "Deployment":
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		revisions, err = deploymentRevisions(ctx, client, deployment)
+		if err != nil {
+			return nil, err
+		}
+	case "StatefulSet":
+		statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		revisions, err = controllerRevisions(ctx, client, statefulSet, statefulSet.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+	case "DaemonSet":
+		daemonSet, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		revisions, err = controllerRevisions(ctx, client, daemonSet, daemonSet.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrRolloutUnsupported, kind)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision > revisions[j].Revision })
+	if len(revisions) > 0 {
+		revisions[0].Current = true
+	}
+	return revisions, nil
+}
+
+// RollbackWorkload 将工作负载的 Pod 模板恢复为指定版本，revision 为 0 时回滚到上一个版本
+// 返回回滚前的当前版本、回滚到的版本和 API Server 返回的对象，回滚到的版本的变更原因会写入工作负载的注解
+// dryRun 为 true 时只由 API Server 试运行，不会写入集群
+func RollbackWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, revision int64, dryRun bool) (current, target WorkloadRevision, updated runtime.Object, err error) {
+	revisions, err := ListRevisions(ctx, client, kind, namespace, name)
+	if err != nil {
+		return current, target, nil, err
+	}
+	target, err = findRevision(revisions, revision)
+	if err != nil {
+		return current, target, nil, err
+	}
+	current = revisions[0]
+
+	opts := metav1.UpdateOptions{DryRun: DryRunOptions(dryRun)}
+	// 使用最新的对象重试，避免覆盖并发的修改
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		switch kind {
+		case "Deployment":
+			deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			deployment.Spec.Template = target.Template()
+			setChangeCause(&deployment.ObjectMeta, target.ChangeCause)
+			updated, err = client.AppsV1().Deployments(namespace).Update(ctx, deployment, opts)
+			return err
+		case "StatefulSet":
+			statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			statefulSet.Spec.Template = target.Template()
+			setChangeCause(&statefulSet.ObjectMeta, target.ChangeCause)
+			updated, err = client.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, opts)
+			return err
+		default:
+			daemonSet, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			daemonSet.Spec.Template = target.Template()
+			setChangeCause(&daemonSet.ObjectMeta, target.ChangeCause)
+			updated, err = client.AppsV1().DaemonSets(namespace).Update(ctx, daemonSet, opts)
+			return err
+		}
+	})
+	return current, target, updated, err
+}
+
+// findRevision 查找指定版本，revision 为 0 时返回当前版本的上一个版本
+func findRevision(revisions []WorkloadRevision, revision int64) (WorkloadRevision, error) {
+	if revision == 0 {
+		if len(revisions) < 2 {
+			return WorkloadRevision{}, fmt.Errorf("%w: 没有可以回滚的上一个版本", ErrRevisionNotFound)
+		}
+		return revisions[1], nil
+	}
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	return WorkloadRevision{}, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+}
+
+// setChangeCause 更新对象的变更原因注解，变更原因为空时删除该注解
+func setChangeCause(meta *metav1.ObjectMeta, changeCause string) {
+	if changeCause == "" {
+		delete(meta.Annotations, ChangeCauseAnnotation)
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[ChangeCauseAnnotation] = changeCause
+}
+
+// deploymentRevisions 读取 Deployment 管理的 ReplicaSet 作为历史版本
+func deploymentRevisions(ctx context.Context, client kubernetes.Interface, deployment *appsv1.Deployment) ([]WorkloadRevision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := client.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("读取 ReplicaSet 列表失败: %v", err)
+	}
+
+	var revisions []WorkloadRevision
+	for _, rs := range replicaSets.Items {
+		if !metav1.IsControlledBy(&rs, deployment) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		// pod-template-hash 由控制器根据模板生成，回滚时不能带上旧值
+		template := *rs.Spec.Template.DeepCopy()
+		delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		revisions = append(revisions, newWorkloadRevision(revision, rs.ObjectMeta, template))
+	}
+	return revisions, nil
+}
+
+// controllerRevisions 读取 StatefulSet 或 DaemonSet 的 ControllerRevision 作为历史版本
+func controllerRevisions(ctx context.Context, client kubernetes.Interface, owner metav1.Object, labelSelector *metav1.LabelSelector) ([]WorkloadRevision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	history, err := client.AppsV1().ControllerRevisions(owner.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("读取 ControllerRevision 列表失败: %v", err)
+	}
+
+	var revisions []WorkloadRevision
+	for _, cr := range history.Items {
+		if !metav1.IsControlledBy(&cr, owner) {
+			continue
+		}
+		// ControllerRevision 中保存的是替换 spec.template 的补丁
+		var patch struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(cr.Data.Raw, &patch); err != nil {
+			continue
+		}
+		revisions = append(revisions, newWorkloadRevision(cr.Revision, cr.ObjectMeta, patch.Spec.Template))
+	}
+	return revisions, nil
+}
+
+// newWorkloadRevision 使用记录版本的对象和 Pod 模板创建历史版本
+func newWorkloadRevision(revision int64, meta metav1.ObjectMeta, template corev1.PodTemplateSpec) WorkloadRevision {
+	images := make(map[string]string, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	return WorkloadRevision{
+		Revision:    revision,
+		Name:        meta.Name,
+		Images:      images,
+		ChangeCause: meta.Annotations[ChangeCauseAnnotation],
+		CreatedAt:   meta.CreationTimestamp.Time,
+		template:    template,
+	}
+}
+
+// RestartWorkload 滚动重启工作负载，通过补丁更新 Pod 模板的 restartedAt 注解触发
+// 返回 API Server 返回的对象，dryRun 为 true 时只试运行
+func RestartWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, restartedAt time.Time, dryRun bool) (runtime.Object, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{RestartedAtAnnotation: restartedAt.Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts := metav1.PatchOptions{DryRun: DryRunOptions(dryRun)}
+	switch kind {
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrRolloutUnsupported, kind)
+	}
+}
+
+// SetWorkloadPaused 暂停或恢复工作负载，Deployment 修改 spec.paused 暂停滚动更新，
+// Job 和 CronJob 修改 spec.suspend 挂起执行。返回 API Server 返回的对象，dryRun 为 true 时只试运行
+func SetWorkloadPaused(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, paused, dryRun bool) (runtime.Object, error) {
+	field := "suspend"
+	if kind == "Deployment" {
+		field = "paused"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]bool{field: paused},
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts := metav1.PatchOptions{DryRun: DryRunOptions(dryRun)}
+	switch kind {
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "Job":
+		return client.BatchV1().Jobs(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	case "CronJob":
+		return client.BatchV1().CronJobs(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, opts)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPauseUnsupported, kind)
+	}
+}
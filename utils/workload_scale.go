@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// ErrScaleUnsupported 工作负载类型没有 scale 子资源
+var ErrScaleUnsupported = errors.New("该工作负载类型不支持扩缩容")
+
+// ScaleStatus 扩缩容后的 scale 子资源状态
+type ScaleStatus struct {
+	// 期望副本数
+	Replicas int32 `json:"replicas" example:"3"`
+	// 当前副本数
+	CurrentReplicas int32 `json:"current_replicas" example:"2"`
+	// Pod 标签选择器
+	Selector string `json:"selector" example:"app=nginx"`
+	// 更新后工作负载的 resourceVersion，可用于下一次扩缩容的前置条件
+	ResourceVersion string `json:"resource_version" example:"123456"`
+
+	// 修改前和 API Server 返回的 scale 子资源，用于试运行预览
+	Live    *autoscalingv1.Scale `json:"-"`
+	Updated *autoscalingv1.Scale `json:"-"`
+}
+
+// scaleClient 读取和更新某类工作负载的 scale 子资源
+type scaleClient struct {
+	get    func(ctx context.Context, name string, opts metav1.GetOptions) (*autoscalingv1.Scale, error)
+	update func(ctx context.Context, name string, scale *autoscalingv1.Scale, opts metav1.UpdateOptions) (*autoscalingv1.Scale, error)
+}
+
+// scaleClientFor 返回支持 scale 子资源的工作负载类型的客户端
+func scaleClientFor(client kubernetes.Interface, kind, namespace string) (scaleClient, error) {
+	switch kind {
+	case "Deployment":
+		c := client.AppsV1().Deployments(namespace)
+		return scaleClient{get: c.GetScale, update: c.UpdateScale}, nil
+	case "StatefulSet":
+		c := client.AppsV1().StatefulSets(namespace)
+		return scaleClient{get: c.GetScale, update: c.UpdateScale}, nil
+	case "ReplicaSet":
+		c := client.AppsV1().ReplicaSets(namespace)
+		return scaleClient{get: c.GetScale, update: c.UpdateScale}, nil
+	case "ReplicationController":
+		c := client.CoreV1().ReplicationControllers(namespace)
+		return scaleClient{get: c.GetScale, update: c.UpdateScale}, nil
+	default:
+		return scaleClient{}, fmt.Errorf("%w: %s", ErrScaleUnsupported, kind)
+	}
+}
+
+// ScaleWorkload 通过 scale 子资源修改工作负载的副本数，只修改副本数，不会覆盖其他字段
+// resourceVersion 不为空时作为前置条件，工作负载已被修改时返回 Conflict 错误；
+// 为空时遇到冲突会读取最新的 scale 重试。dryRun 为 true 时只试运行
+func ScaleWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, replicas int32, resourceVersion string, dryRun bool) (ScaleStatus, error) {
+	scales, err := scaleClientFor(client, kind, namespace)
+	if err != nil {
+		return ScaleStatus{}, err
+	}
+
+	var live, updated *autoscalingv1.Scale
+	update := func() error {
+		var err error
+		live, err = scales.get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale := live.DeepCopy()
+		if resourceVersion != "" {
+			scale.ResourceVersion = resourceVersion
+		}
+		scale.Spec.Replicas = replicas
+		updated, err = scales.update(ctx, name, scale, metav1.UpdateOptions{DryRun: DryRunOptions(dryRun)})
+		return err
+	}
+
+	if resourceVersion != "" {
+		err = update()
+	} else {
+		err = retry.RetryOnConflict(retry.DefaultRetry, update)
+	}
+	if err != nil {
+		return ScaleStatus{}, err
+	}
+	return ScaleStatus{
+		Replicas:        updated.Spec.Replicas,
+		CurrentReplicas: updated.Status.Replicas,
+		Selector:        updated.Status.Selector,
+		ResourceVersion: updated.ResourceVersion,
+		Live:            live,
+		Updated:         updated,
+	}, nil
+}
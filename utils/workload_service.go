@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"sort"
+
+	"github.com/kbsonlong/kaiops/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ServiceTypeHeadless 没有 ClusterIP 的 Service，对应 spec.clusterIP 为 None 的 ClusterIP 类型
+const ServiceTypeHeadless = "Headless"
+
+// ServiceType 返回 Service 的类型，Headless Service 返回 Headless
+func ServiceType(service *corev1.Service) string {
+	if service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return ServiceTypeHeadless
+	}
+	if service.Spec.Type == "" {
+		return string(corev1.ServiceTypeClusterIP)
+	}
+	return string(service.Spec.Type)
+}
+
+// NewServiceRef 读取 Service 的类型、地址和端口
+func NewServiceRef(service *corev1.Service) models.ServiceRef {
+	ref := models.ServiceRef{
+		Name:      service.Name,
+		Type:      ServiceType(service),
+		ClusterIP: service.Spec.ClusterIP,
+		Ports:     make([]models.ServicePort, 0, len(service.Spec.Ports)),
+	}
+	for _, port := range service.Spec.Ports {
+		ref.Ports = append(ref.Ports, models.ServicePort{
+			Name:       port.Name,
+			Protocol:   string(port.Protocol),
+			Port:       port.Port,
+			TargetPort: port.TargetPort,
+			NodePort:   port.NodePort,
+		})
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ref.ExternalIPs = append(ref.ExternalIPs, ingress.IP)
+		} else if ingress.Hostname != "" {
+			ref.ExternalIPs = append(ref.ExternalIPs, ingress.Hostname)
+		}
+	}
+	return ref
+}
+
+// WorkloadServices 从集群资源缓存中查找选择器匹配工作负载 Pod 模板标签的 Service，按名称排序
+// 没有选择器的 Service 不选择任何 Pod，不会返回
+func WorkloadServices(clusterCache *ClusterCache, kind, namespace, name string) ([]models.ServiceRef, error) {
+	live, err := getLiveWorkload(clusterCache, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	services, err := clusterCache.Services().Services(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	podLabels := labels.Set(live.template.Labels)
+	refs := make([]models.ServiceRef, 0)
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(podLabels) {
+			refs = append(refs, NewServiceRef(service))
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name < refs[j].Name })
+	return refs, nil
+}
@@ -0,0 +1,553 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kbsonlong/kaiops/models"
+	"gorm.io/gorm"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// 同步时不保存的注解，内容较大且由 kubectl 维护
+var ignoredAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// 同步的工作负载类型
+var syncedWorkloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob"}
+
+// SyncResult 从集群导入工作负载的结果
+type SyncResult struct {
+	// 新增的工作负载数量
+	Created int `json:"created" example:"10"`
+	// 更新的工作负载数量
+	Updated int `json:"updated" example:"2"`
+	// 未变化的工作负载数量
+	Unchanged int `json:"unchanged" example:"30"`
+	// 集群中已不存在而被删除的工作负载数量
+	Removed int `json:"removed" example:"1"`
+}
+
+// SyncClusterWorkloads 将集群中的 Deployment、StatefulSet、DaemonSet、Job 和 CronJob 写入数据库
+// namespaces 为空时同步所有命名空间，只删除同步范围内集群中已不存在的记录，重复执行结果不变
+func SyncClusterWorkloads(ctx context.Context, db *gorm.DB, clusterCache *ClusterCache, clusterID uint, namespaces []string) (SyncResult, error) {
+	var result SyncResult
+
+	live, err := listLiveWorkloads(clusterCache, namespaces)
+	if err != nil {
+		return result, err
+	}
+
+	query := db.WithContext(ctx).Where("cluster_id = ? AND kind IN ?", clusterID, syncedWorkloadKinds)
+	if len(namespaces) > 0 {
+		query = query.Where("namespace IN ?", namespaces)
+	}
+	var existing []models.Workload
+	if err := query.Find(&existing).Error; err != nil {
+		return result, err
+	}
+	existingByKey := make(map[string]models.Workload, len(existing))
+	for _, workload := range existing {
+		existingByKey[workloadKey(workload)] = workload
+	}
+
+	now := time.Now()
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, workload := range live {
+			workload.ClusterID = clusterID
+			workload.ReconciledAt = &now
+
+			current, exists := existingByKey[workloadKey(workload)]
+			delete(existingByKey, workloadKey(workload))
+			if !exists {
+				if err := tx.Create(&workload).Error; err != nil {
+					return err
+				}
+				result.Created++
+				continue
+			}
+
+			if workloadSnapshot(current) == workloadSnapshot(workload) && !current.Missing && len(current.Drift) == 0 {
+				result.Unchanged++
+				continue
+			}
+			if err := tx.Model(&models.Workload{}).Where("id = ?", current.ID).UpdateColumns(liveWorkloadColumns(workload, now)).Error; err != nil {
+				return err
+			}
+			result.Updated++
+		}
+
+		// 剩余的记录在集群中已不存在
+		for _, workload := range existingByKey {
+			if err := tx.Delete(&workload).Error; err != nil {
+				return err
+			}
+			result.Removed++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// SaveLiveWorkload 将从集群读取的工作负载写入数据库，已有记录时更新为集群中的状态并清除偏差
+func SaveLiveWorkload(ctx context.Context, db *gorm.DB, workload models.Workload) error {
+	now := time.Now()
+	workload.ReconciledAt = &now
+
+	var current models.Workload
+	err := db.WithContext(ctx).
+		Where("cluster_id = ? AND kind = ? AND namespace = ? AND name = ?", workload.ClusterID, workload.Kind, workload.Namespace, workload.Name).
+		First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.WithContext(ctx).Create(&workload).Error
+	}
+	if err != nil {
+		return err
+	}
+	return db.WithContext(ctx).Model(&models.Workload{}).Where("id = ?", current.ID).UpdateColumns(liveWorkloadColumns(workload, now)).Error
+}
+
+// liveWorkloadColumns 使用集群中的状态更新工作负载记录时写入的字段
+func liveWorkloadColumns(workload models.Workload, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"replicas":               workload.Replicas,
+		"containers":             workload.Containers,
+		"labels":                 workload.Labels,
+		"selector":               workload.Selector,
+		"template_labels":        workload.TemplateLabels,
+		"annotations":            workload.Annotations,
+		"volumes":                workload.Volumes,
+		"volume_claim_templates": workload.VolumeClaimTemplates,
+		"batch":                  workload.Batch,
+		"status":                 workload.Status,
+		"drift":                  nil,
+		"missing":                false,
+		"reconciled_at":          now,
+	}
+}
+
+// listLiveWorkloads 从集群资源缓存中读取指定命名空间的工作负载
+func listLiveWorkloads(clusterCache *ClusterCache, namespaces []string) ([]models.Workload, error) {
+	var workloads []models.Workload
+
+	deployments, err := clusterCache.Deployments().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("读取 Deployment 列表失败: %v", err)
+	}
+	for _, deployment := range deployments {
+		if inNamespaces(deployment.Namespace, namespaces) {
+			workloads = append(workloads, DeploymentToWorkload(deployment))
+		}
+	}
+
+	statefulSets, err := clusterCache.StatefulSets().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("读取 StatefulSet 列表失败: %v", err)
+	}
+	for _, statefulSet := range statefulSets {
+		if inNamespaces(statefulSet.Namespace, namespaces) {
+			workloads = append(workloads, StatefulSetToWorkload(statefulSet))
+		}
+	}
+
+	daemonSets, err := clusterCache.DaemonSets().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("读取 DaemonSet 列表失败: %v", err)
+	}
+	for _, daemonSet := range daemonSets {
+		if inNamespaces(daemonSet.Namespace, namespaces) {
+			workloads = append(workloads, DaemonSetToWorkload(daemonSet))
+		}
+	}
+
+	jobs, err := clusterCache.Jobs().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("读取 Job 列表失败: %v", err)
+	}
+	for _, job := range jobs {
+		// CronJob 创建的 Job 属于 CronJob 的执行历史，不单独记录
+		if inNamespaces(job.Namespace, namespaces) && !isCronJobOwned(job) {
+			workloads = append(workloads, JobToWorkload(job))
+		}
+	}
+
+	cronJobs, err := clusterCache.CronJobs().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("读取 CronJob 列表失败: %v", err)
+	}
+	for _, cronJob := range cronJobs {
+		if inNamespaces(cronJob.Namespace, namespaces) {
+			workloads = append(workloads, CronJobToWorkload(cronJob))
+		}
+	}
+	return workloads, nil
+}
+
+// inNamespaces 判断命名空间是否在同步范围内，namespaces 为空时表示所有命名空间
+func inNamespaces(namespace string, namespaces []string) bool {
+	return len(namespaces) == 0 || slices.Contains(namespaces, namespace)
+}
+
+// workloadKey 工作负载在集群内的唯一标识
+func workloadKey(workload models.Workload) string {
+	return workload.Kind + "/" + workload.Namespace + "/" + workload.Name
+}
+
+// workloadSnapshot 序列化同步会覆盖的字段，用于判断记录是否需要更新
+func workloadSnapshot(workload models.Workload) string {
+	data, _ := json.Marshal([]interface{}{
+		workload.Replicas,
+		workload.Containers,
+		workload.Labels,
+		workload.Selector,
+		workload.TemplateLabels,
+		workload.Annotations,
+		workload.Volumes,
+		workload.VolumeClaimTemplates,
+		workload.Batch,
+		workload.Status,
+	})
+	return string(data)
+}
+
+// DeploymentToWorkload 将 Deployment 转换为工作负载记录
+func DeploymentToWorkload(deployment *appsv1.Deployment) models.Workload {
+	workload := objectToWorkload("Deployment", deployment.ObjectMeta, deployment.Spec.Template)
+	setPodLabels(&workload, deployment.Spec.Selector, deployment.Spec.Template)
+	if deployment.Spec.Replicas != nil {
+		workload.Replicas = *deployment.Spec.Replicas
+	}
+	workload.Status = DeploymentStatus(deployment)
+	return workload
+}
+
+// StatefulSetToWorkload 将 StatefulSet 转换为工作负载记录
+func StatefulSetToWorkload(statefulSet *appsv1.StatefulSet) models.Workload {
+	workload := objectToWorkload("StatefulSet", statefulSet.ObjectMeta, statefulSet.Spec.Template)
+	setPodLabels(&workload, statefulSet.Spec.Selector, statefulSet.Spec.Template)
+	if statefulSet.Spec.Replicas != nil {
+		workload.Replicas = *statefulSet.Spec.Replicas
+	}
+	workload.VolumeClaimTemplates = volumeClaimTemplatesFromK8s(statefulSet.Spec.VolumeClaimTemplates)
+	workload.Status = StatefulSetStatus(statefulSet)
+	return workload
+}
+
+// DaemonSetToWorkload 将 DaemonSet 转换为工作负载记录
+func DaemonSetToWorkload(daemonSet *appsv1.DaemonSet) models.Workload {
+	workload := objectToWorkload("DaemonSet", daemonSet.ObjectMeta, daemonSet.Spec.Template)
+	setPodLabels(&workload, daemonSet.Spec.Selector, daemonSet.Spec.Template)
+	workload.Status = DaemonSetStatus(daemonSet)
+	return workload
+}
+
+// DeploymentStatus 读取 Deployment 的副本状态和状态条件
+func DeploymentStatus(deployment *appsv1.Deployment) models.WorkloadStatus {
+	var status models.WorkloadStatus
+	if deployment.Spec.Replicas != nil {
+		status.DesiredReplicas = *deployment.Spec.Replicas
+	}
+	status.CurrentReplicas = deployment.Status.Replicas
+	status.ReadyReplicas = deployment.Status.ReadyReplicas
+	for _, condition := range deployment.Status.Conditions {
+		status.Conditions = append(status.Conditions, deploymentCondition(condition))
+	}
+	return status
+}
+
+// StatefulSetStatus 读取 StatefulSet 的副本状态和状态条件
+func StatefulSetStatus(statefulSet *appsv1.StatefulSet) models.WorkloadStatus {
+	var status models.WorkloadStatus
+	if statefulSet.Spec.Replicas != nil {
+		status.DesiredReplicas = *statefulSet.Spec.Replicas
+	}
+	status.CurrentReplicas = statefulSet.Status.Replicas
+	status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	for _, condition := range statefulSet.Status.Conditions {
+		status.Conditions = append(status.Conditions, statefulSetCondition(condition))
+	}
+	return status
+}
+
+// DaemonSetStatus 读取 DaemonSet 的调度状态和状态条件
+func DaemonSetStatus(daemonSet *appsv1.DaemonSet) models.WorkloadStatus {
+	status := models.WorkloadStatus{
+		DesiredReplicas: daemonSet.Status.DesiredNumberScheduled,
+		CurrentReplicas: daemonSet.Status.CurrentNumberScheduled,
+		ReadyReplicas:   daemonSet.Status.NumberReady,
+	}
+	for _, condition := range daemonSet.Status.Conditions {
+		status.Conditions = append(status.Conditions, daemonSetCondition(condition))
+	}
+	return status
+}
+
+// objectToWorkload 使用对象元数据和 Pod 模板填充工作负载记录
+func objectToWorkload(kind string, meta metav1.ObjectMeta, template corev1.PodTemplateSpec) models.Workload {
+	workload := models.Workload{
+		Name:       meta.Name,
+		Kind:       kind,
+		Namespace:  meta.Namespace,
+		Containers: ContainersFromPodSpec(template.Spec),
+		Volumes:    VolumesFromPodSpec(template.Spec),
+	}
+	if len(meta.Labels) > 0 {
+		workload.Labels = models.StringMap(meta.Labels)
+	}
+	for key, value := range meta.Annotations {
+		if slices.Contains(ignoredAnnotations, key) {
+			continue
+		}
+		if workload.Annotations == nil {
+			workload.Annotations = models.StringMap{}
+		}
+		workload.Annotations[key] = value
+	}
+	return workload
+}
+
+// WithIgnoredAnnotations 返回更新对象时写入的注解：工作负载记录中的注解加上对象现有的同步时忽略的注解
+func WithIgnoredAnnotations(live, annotations map[string]string) map[string]string {
+	var merged map[string]string
+	for key, value := range live {
+		if slices.Contains(ignoredAnnotations, key) {
+			if merged == nil {
+				merged = make(map[string]string, len(annotations)+1)
+			}
+			merged[key] = value
+		}
+	}
+	if merged == nil {
+		return annotations
+	}
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	return merged
+}
+
+// setPodLabels 记录选择器和 Pod 模板标签，它们与对象标签相互独立
+func setPodLabels(workload *models.Workload, selector *metav1.LabelSelector, template corev1.PodTemplateSpec) {
+	if selector != nil && len(selector.MatchLabels) > 0 {
+		workload.Selector = models.StringMap(selector.MatchLabels)
+	}
+	if len(template.Labels) > 0 {
+		workload.TemplateLabels = models.StringMap(template.Labels)
+	}
+}
+
+// ContainersFromPodSpec 将 Pod 模板中的容器转换为工作负载的容器配置
+func ContainersFromPodSpec(spec corev1.PodSpec) models.Containers {
+	var containers models.Containers
+	for _, c := range spec.Containers {
+		container := models.Container{
+			Name:           c.Name,
+			Image:          c.Image,
+			Requests:       resourceListFromK8s(c.Resources.Requests),
+			Limits:         resourceListFromK8s(c.Resources.Limits),
+			LivenessProbe:  probeFromK8s(c.LivenessProbe),
+			ReadinessProbe: probeFromK8s(c.ReadinessProbe),
+			StartupProbe:   probeFromK8s(c.StartupProbe),
+		}
+		for _, mount := range c.VolumeMounts {
+			container.VolumeMounts = append(container.VolumeMounts, models.VolumeMount{
+				Name:      mount.Name,
+				MountPath: mount.MountPath,
+				SubPath:   mount.SubPath,
+				ReadOnly:  mount.ReadOnly,
+			})
+		}
+		for _, env := range c.Env {
+			container.Env = append(container.Env, envVarFromK8s(env))
+		}
+		for _, envFrom := range c.EnvFrom {
+			container.EnvFrom = append(container.EnvFrom, envFromSourceFromK8s(envFrom))
+		}
+		for _, port := range c.Ports {
+			container.Ports = append(container.Ports, models.ContainerPort{
+				Name:          port.Name,
+				ContainerPort: port.ContainerPort,
+				Protocol:      string(port.Protocol),
+			})
+		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// VolumesFromPodSpec 将 Pod 模板中的卷转换为工作负载的卷配置
+// 只转换 ConfigMap、Secret、PersistentVolumeClaim、EmptyDir 和 HostPath，其他类型的卷会被忽略，
+// 挂载这些卷的容器在更新时无法通过校验，避免误删集群中的卷
+func VolumesFromPodSpec(spec corev1.PodSpec) models.Volumes {
+	var volumes models.Volumes
+	for _, v := range spec.Volumes {
+		volume := models.Volume{Name: v.Name}
+		switch {
+		case v.ConfigMap != nil:
+			volume.ConfigMap = &models.ConfigMapVolumeSource{
+				Name:     v.ConfigMap.Name,
+				Items:    keyToPathsFromK8s(v.ConfigMap.Items),
+				Optional: v.ConfigMap.Optional != nil && *v.ConfigMap.Optional,
+			}
+		case v.Secret != nil:
+			volume.Secret = &models.SecretVolumeSource{
+				SecretName: v.Secret.SecretName,
+				Items:      keyToPathsFromK8s(v.Secret.Items),
+				Optional:   v.Secret.Optional != nil && *v.Secret.Optional,
+			}
+		case v.PersistentVolumeClaim != nil:
+			volume.PersistentVolumeClaim = &models.PersistentVolumeClaimVolumeSource{
+				ClaimName: v.PersistentVolumeClaim.ClaimName,
+				ReadOnly:  v.PersistentVolumeClaim.ReadOnly,
+			}
+		case v.EmptyDir != nil:
+			volume.EmptyDir = &models.EmptyDirVolumeSource{Medium: string(v.EmptyDir.Medium)}
+			if v.EmptyDir.SizeLimit != nil {
+				volume.EmptyDir.SizeLimit = v.EmptyDir.SizeLimit.String()
+			}
+		case v.HostPath != nil:
+			volume.HostPath = &models.HostPathVolumeSource{Path: v.HostPath.Path}
+			if v.HostPath.Type != nil {
+				volume.HostPath.Type = string(*v.HostPath.Type)
+			}
+		default:
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+// envVarFromK8s 转换容器的环境变量
+func envVarFromK8s(env corev1.EnvVar) models.EnvVar {
+	envVar := models.EnvVar{Name: env.Name, Value: env.Value}
+	source := env.ValueFrom
+	if source == nil {
+		return envVar
+	}
+	envVar.ValueFrom = &models.EnvVarSource{}
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		envVar.ValueFrom.ConfigMapKeyRef = &models.KeySelector{Name: ref.Name, Key: ref.Key, Optional: ref.Optional != nil && *ref.Optional}
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		envVar.ValueFrom.SecretKeyRef = &models.KeySelector{Name: ref.Name, Key: ref.Key, Optional: ref.Optional != nil && *ref.Optional}
+	case source.FieldRef != nil:
+		envVar.ValueFrom.FieldRef = &models.ObjectFieldSelector{FieldPath: source.FieldRef.FieldPath, APIVersion: source.FieldRef.APIVersion}
+	case source.ResourceFieldRef != nil:
+		ref := source.ResourceFieldRef
+		envVar.ValueFrom.ResourceFieldRef = &models.ResourceFieldSelector{ContainerName: ref.ContainerName, Resource: ref.Resource}
+		if !ref.Divisor.IsZero() {
+			envVar.ValueFrom.ResourceFieldRef.Divisor = ref.Divisor.String()
+		}
+	}
+	return envVar
+}
+
+// envFromSourceFromK8s 转换容器从 ConfigMap 或 Secret 导入的环境变量
+func envFromSourceFromK8s(envFrom corev1.EnvFromSource) models.EnvFromSource {
+	source := models.EnvFromSource{Prefix: envFrom.Prefix}
+	if ref := envFrom.ConfigMapRef; ref != nil {
+		source.ConfigMapRef = &models.EnvFromRef{Name: ref.Name, Optional: ref.Optional != nil && *ref.Optional}
+	}
+	if ref := envFrom.SecretRef; ref != nil {
+		source.SecretRef = &models.EnvFromRef{Name: ref.Name, Optional: ref.Optional != nil && *ref.Optional}
+	}
+	return source
+}
+
+// keyToPathsFromK8s 转换 ConfigMap 和 Secret 卷中挂载的键
+func keyToPathsFromK8s(items []corev1.KeyToPath) []models.KeyToPath {
+	var result []models.KeyToPath
+	for _, item := range items {
+		result = append(result, models.KeyToPath{Key: item.Key, Path: item.Path})
+	}
+	return result
+}
+
+// volumeClaimTemplatesFromK8s 转换 StatefulSet 的持久卷声明模板
+func volumeClaimTemplatesFromK8s(pvcs []corev1.PersistentVolumeClaim) models.VolumeClaimTemplates {
+	var templates models.VolumeClaimTemplates
+	for _, pvc := range pvcs {
+		template := models.VolumeClaimTemplate{Name: pvc.Name}
+		if pvc.Spec.StorageClassName != nil {
+			template.StorageClassName = *pvc.Spec.StorageClassName
+		}
+		if size, exists := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; exists {
+			template.Size = size.String()
+		}
+		for _, mode := range pvc.Spec.AccessModes {
+			template.AccessModes = append(template.AccessModes, string(mode))
+		}
+		templates = append(templates, template)
+	}
+	return templates
+}
+
+// probeFromK8s 将容器探针转换为工作负载的探针配置
+func probeFromK8s(probe *corev1.Probe) *models.Probe {
+	if probe == nil {
+		return nil
+	}
+
+	result := &models.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		httpGet := &models.HTTPGetAction{
+			Path:   probe.HTTPGet.Path,
+			Port:   probe.HTTPGet.Port,
+			Host:   probe.HTTPGet.Host,
+			Scheme: string(probe.HTTPGet.Scheme),
+		}
+		for _, header := range probe.HTTPGet.HTTPHeaders {
+			httpGet.HTTPHeaders = append(httpGet.HTTPHeaders, models.HTTPHeader{Name: header.Name, Value: header.Value})
+		}
+		result.HTTPGet = httpGet
+	case probe.TCPSocket != nil:
+		result.TCPSocket = &models.TCPSocketAction{Port: probe.TCPSocket.Port, Host: probe.TCPSocket.Host}
+	case probe.Exec != nil:
+		result.Exec = &models.ExecAction{Command: probe.Exec.Command}
+	case probe.GRPC != nil:
+		result.GRPC = &models.GRPCAction{Port: probe.GRPC.Port}
+		if probe.GRPC.Service != nil {
+			result.GRPC.Service = *probe.GRPC.Service
+		}
+	}
+	return result
+}
+
+// resourceListFromK8s 转换资源配置，CPU、内存和临时存储以外的资源作为扩展资源
+func resourceListFromK8s(resources corev1.ResourceList) models.ResourceList {
+	var list models.ResourceList
+	for name, quantity := range resources {
+		switch name {
+		case corev1.ResourceCPU:
+			list.CPU = quantity.String()
+		case corev1.ResourceMemory:
+			list.Memory = quantity.String()
+		case corev1.ResourceEphemeralStorage:
+			list.EphemeralStorage = quantity.String()
+		default:
+			if list.Extended == nil {
+				list.Extended = map[string]string{}
+			}
+			list.Extended[string(name)] = quantity.String()
+		}
+	}
+	return list
+}